@@ -17,6 +17,7 @@ import (
 	"github.com/Sush1sui/FNS_BOT/internal/config"
 	"github.com/Sush1sui/FNS_BOT/internal/db"
 	"github.com/Sush1sui/FNS_BOT/internal/storage"
+	"github.com/Sush1sui/FNS_BOT/internal/webhook"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -43,16 +44,29 @@ func main() {
 
 	queries := db.New(pool)
 	tickets.SetQueries(queries)
+	webhook.SetQueries(queries)
 
-	// 2. Connect to Azure Blob Storage
-	azureClient := storage.NewAzureClient()
-	tickets.SetStorage(azureClient)
+	// 2. Connect to transcript storage
+	var keyring *storage.Keyring
+	if len(cfg.TranscriptKeys) > 0 {
+		keyring, err = storage.NewKeyring(cfg.TranscriptKeyID, cfg.TranscriptKeys)
+		if err != nil {
+			log.Fatalf("Invalid transcript keys: %v\n", err)
+		}
+	}
+	storageClient, err := storage.Open(cfg.Storage, keyring)
+	if err != nil {
+		log.Fatalf("Unable to open storage: %v\n", err)
+	}
+	tickets.SetStorage(storageClient)
+	tickets.SetStorageQuota(cfg.StorageQuotaBytes)
 
 	// 3. Mount Router
-	mux := api.NewRouter(queries, azureClient, cfg)
+	mux := api.NewRouter(queries, storageClient, cfg)
 
-	// 4. Start Bot
+	// 4. Start Bot and webhook deliveries
 	bot.StartBot()
+	webhook.StartDispatcher()
 
 	// 5. Start Server
 	server := &http.Server{Addr: ":" + cfg.Port, Handler: mux}
@@ -0,0 +1,103 @@
+// cmd/backfill-usage/main.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/config"
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const batchSize = 100
+
+// Backfills storage usage for transcripts stored before usage was tracked,
+// by sizing each transcript blob, its kept original and its archived
+// attachments, so guild totals and quotas cover everything in storage. Safe
+// to re-run: only transcripts without a usage row are processed, and a
+// transcript saved by the bot meanwhile is counted once.
+func main() {
+	cfg := config.Load()
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DBUrl)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	queries := db.New(pool)
+	// Sizing objects never decrypts them, so no keyring is needed.
+	storageClient, err := storage.Open(cfg.Storage, nil)
+	if err != nil {
+		log.Fatalf("Unable to open storage: %v\n", err)
+	}
+
+	var (
+		afterID int32
+		counted int
+		failed  int
+	)
+	for {
+		batch, err := queries.ListUnmeteredTranscripts(ctx, afterID, batchSize)
+		if err != nil {
+			log.Fatalf("List transcripts failed: %v\n", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, item := range batch {
+			afterID = item.ID
+			if err := meterTranscript(ctx, queries, storageClient, item); err != nil {
+				log.Printf("size transcript %d failed: %v", item.ID, err)
+				failed++
+				continue
+			}
+			counted++
+		}
+		fmt.Printf("Counted %d transcripts (%d failed)\n", counted, failed)
+	}
+
+	fmt.Printf("✅ Usage backfill finished: %d counted, %d failed.\n", counted, failed)
+}
+
+// meterTranscript counts the same objects the bot counts when it saves a
+// transcript.
+func meterTranscript(ctx context.Context, queries *db.Queries, storageClient storage.Client, item db.UnmeteredTranscript) error {
+	transcriptBytes, err := storageClient.ObjectSize(ctx, item.StorageKey)
+	if err != nil {
+		return fmt.Errorf("size transcript: %w", err)
+	}
+	originalBytes, err := storageClient.ObjectSize(ctx, transcript.OriginalKey(item.StorageKey))
+	if err != nil && !storage.IsNotFound(err) {
+		return fmt.Errorf("size original: %w", err)
+	}
+	transcriptBytes += originalBytes
+
+	keys, err := storageClient.ListObjects(ctx, transcript.ObjectPrefix(item.StorageKey)+"attachments/")
+	if err != nil {
+		return fmt.Errorf("list attachments: %w", err)
+	}
+	var attachmentBytes int64
+	for _, key := range keys {
+		size, err := storageClient.ObjectSize(ctx, key)
+		if err != nil {
+			return fmt.Errorf("size %s: %w", key, err)
+		}
+		attachmentBytes += size
+	}
+
+	return queries.RecordTranscriptUsage(ctx, db.RecordTranscriptUsageParams{
+		TranscriptID:    item.ID,
+		ServerConfigID:  item.ServerConfigID,
+		TranscriptBytes: transcriptBytes,
+		AttachmentBytes: attachmentBytes,
+		UpdatedAt:       time.Now().Unix(),
+	})
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// v1 closed every ticket through the close button, so imported transcripts
+// all share one close reason.
+const v1CloseReason = "closed"
+
+// ticketNameStyle maps the v1 "num" style to its v2 name.
+func ticketNameStyle(style string) string {
+	if style == "name" {
+		return "name"
+	}
+	return "number"
+}
+
+// ticketPermissions encodes the v1 permission toggles the way the
+// dashboard saves them: a JSON array of the names that are on.
+func ticketPermissions(cfg v1TicketConfig) []byte {
+	perms := make([]string, 0, 3)
+	if cfg.TicketPermissions.Attachments {
+		perms = append(perms, "attachFiles")
+	}
+	if cfg.TicketPermissions.Links {
+		perms = append(perms, "embedLinks")
+	}
+	if cfg.TicketPermissions.Reactions {
+		perms = append(perms, "addReactions")
+	}
+	data, _ := json.Marshal(perms)
+	return data
+}
+
+func serverConfigParams(guildID int64, server v1Server) db.UpsertServerConfigParams {
+	cfg := server.TicketConfig
+	maxTickets := int32(cfg.MaxTicketsPerUser)
+	if maxTickets <= 0 {
+		maxTickets = 2
+	}
+	return db.UpsertServerConfigParams{
+		ID:                  guildID,
+		TicketNameStyle:     ticketNameStyle(cfg.TicketNameStyle),
+		TicketTranscriptCid: utils.ToText(deref(cfg.TicketTranscript)),
+		MaxTicketPerUser:    maxTickets,
+		TicketPermissions:   ticketPermissions(cfg),
+		MaxPanel:            pgtype.Int4{Int32: 3, Valid: true},
+		MaxMultiPanel:       pgtype.Int4{Int32: 3, Valid: true},
+	}
+}
+
+// defaultServerConfigParams is used for guilds that have transcripts in v1
+// but no server document.
+func defaultServerConfigParams(guildID int64) db.UpsertServerConfigParams {
+	return serverConfigParams(guildID, v1Server{})
+}
+
+func autoCloseParams(guildID int64, cfg v1TicketConfig) db.UpsertAutoCloseConfigParams {
+	return db.UpsertAutoCloseConfigParams{
+		ServerConfigID:                   guildID,
+		IsActive:                         cfg.AutoClose.Enabled,
+		CloseOnUserLeave:                 cfg.AutoClose.CloseWhenUserLeaves,
+		CloseSinceOpenWithNoResponseMins: pgtype.Int4{Int32: cfg.AutoClose.SinceOpenWithoutResponse.minutes(), Valid: true},
+		CloseSinceLastMessageMins:        pgtype.Int4{Int32: cfg.AutoClose.SinceLastResponse.minutes(), Valid: true},
+	}
+}
+
+func panelParams(guildID int64, panel v1Panel) db.CreatePanelConfigParams {
+	return db.CreatePanelConfigParams{
+		ServerConfigID:     guildID,
+		MentionRolesOnOpen: nonNil(panel.MentionOnOpen),
+		CategoryID:         utils.ToText(deref(panel.TicketCategory)),
+		Title:              panel.Title,
+		Content:            utils.ToText(panel.Content),
+		EmbedColor:         parseHexColor(panel.Color),
+		ChannelID:          panel.Channel,
+		BtnColor:           panel.BtnColor,
+		BtnTxt:             panel.BtnText,
+		BtnEmoji:           utils.ToText(deref(panel.BtnEmoji)),
+		LargeImgUrl:        utils.ToText(deref(panel.LargeImgURL)),
+		SmallImgUrl:        utils.ToText(deref(panel.SmallImgURL)),
+	}
+}
+
+// panelQuestions returns the questions to ask, or none when v1 had asking
+// turned off.
+func panelQuestions(panel v1Panel) []string {
+	if panel.Questions == nil || !panel.Questions.AskQuestions {
+		return nil
+	}
+	questions := make([]string, 0, len(panel.Questions.Questions))
+	for _, q := range panel.Questions.Questions {
+		if prompt := strings.TrimSpace(q.Prompt); prompt != "" {
+			questions = append(questions, prompt)
+		}
+	}
+	return questions
+}
+
+// welcomeParams reports whether the panel has a welcome message worth
+// keeping, and converts it.
+func welcomeParams(panel v1Panel) (db.WelcomeMessageParams, bool) {
+	w := panel.WelcomeEmbed
+	if w == nil {
+		return db.WelcomeMessageParams{}, false
+	}
+	enabled := utils.HasWelcomeMessage(deref(w.Title), deref(w.Description), deref(w.TitleImgURL),
+		deref(w.LargeImgURL), deref(w.SmallImgURL), deref(w.FooterText), deref(w.FooterImgURL))
+	return utils.ToWelcomeParams(parseHexColor(w.Color), deref(w.Title), deref(w.Description), deref(w.TitleImgURL),
+		deref(w.LargeImgURL), deref(w.SmallImgURL), deref(w.FooterText), deref(w.FooterImgURL)), enabled
+}
+
+func multiPanelParams(guildID int64, cfg v1TicketConfig, panelIDs []int32) db.CreateMultiPanelConfigParams {
+	embed := cfg.MultiPanels.MessageEmbedConfig
+	return db.CreateMultiPanelConfigParams{
+		ServerConfigID: guildID,
+		Title:          embed.Title,
+		Content:        utils.ToText(embed.Description),
+		EmbedColor:     parseHexColor(embed.Color),
+		ChannelID:      deref(cfg.MultiPanels.Channel),
+		LargeImgUrl:    utils.ToText(deref(embed.LargeImgURL)),
+		SmallImgUrl:    utils.ToText(deref(embed.SmallImgURL)),
+		UseDropdown:    cfg.MultiPanels.DropdownConfig.Use,
+		PanelConfigIds: panelIDs,
+		Footer:         utils.ToText(deref(embed.FooterText)),
+		FootIconUrl:    utils.ToText(deref(embed.FooterImgURL)),
+	}
+}
+
+// convertTranscript turns a v1 transcript document into a version 1
+// payload. v1 kept voice activity and the ticket number next to the
+// metadata; v2 keeps them inside it.
+func convertTranscript(doc v1Transcript) transcript.Payload {
+	messages := make([]transcript.Message, 0, len(doc.Messages))
+	for _, m := range doc.Messages {
+		messages = append(messages, convertMessage(m))
+	}
+
+	meta := doc.Metadata
+	participants := make([]transcript.Participant, 0, len(meta.Participants))
+	for _, p := range meta.Participants {
+		participants = append(participants, transcript.Participant{ID: p.ID, Username: p.Username, MessageCount: p.MessageCount})
+	}
+
+	var voice []transcript.VoiceActivity
+	for _, v := range doc.VoiceActivity {
+		voice = append(voice, transcript.VoiceActivity{
+			UserID:   v.UserID,
+			Username: v.Username,
+			JoinedAt: formatTime(v.JoinedAt),
+			LeftAt:   formatTimePtr(v.LeftAt),
+			Duration: v.Duration,
+		})
+	}
+
+	return transcript.Payload{
+		SchemaVersion: transcript.SchemaVersionV1,
+		TicketID:      doc.TicketID,
+		Username:      doc.Username,
+		UserID:        doc.UserID,
+		Messages:      messages,
+		Metadata: transcript.Metadata{
+			TicketOpenedAt:   formatTime(meta.TicketOpenedAt),
+			TicketClosedAt:   formatTime(meta.TicketClosedAt),
+			ClosedBy:         transcript.ClosedBy{ID: meta.ClosedBy.ID, Username: meta.ClosedBy.Username},
+			CloseReason:      v1CloseReason,
+			TotalMessages:    meta.TotalMessages,
+			TotalAttachments: meta.TotalAttachments,
+			TotalEmbeds:      meta.TotalEmbeds,
+			Participants:     participants,
+			VoiceActivity:    voice,
+			TicketNumber:     doc.TicketNumber,
+		},
+	}
+}
+
+func convertMessage(m v1Message) transcript.Message {
+	msg := transcript.Message{
+		ID:   m.ID,
+		Type: transcript.MessageTypeDefault,
+		Author: transcript.Author{
+			ID:            m.Author.ID,
+			Username:      m.Author.Username,
+			Discriminator: m.Author.Discriminator,
+			Avatar:        m.Author.Avatar,
+			Bot:           m.Author.Bot,
+		},
+		Content:         m.Content,
+		Timestamp:       formatTime(m.Timestamp),
+		Edited:          m.Edited,
+		EditedTimestamp: formatTimePtr(m.EditedTimestamp),
+	}
+	if m.Type != "" {
+		msg.Type = m.Type
+	}
+
+	for _, e := range m.Embeds {
+		embed := transcript.Embed{
+			Title:       e.Title,
+			Description: e.Description,
+			URL:         e.URL,
+			Color:       e.Color,
+		}
+		for _, f := range e.Fields {
+			embed.Fields = append(embed.Fields, transcript.EmbedField{Name: f.Name, Value: f.Value, Inline: f.Inline})
+		}
+		if e.Image != nil {
+			embed.Image = &transcript.Image{URL: e.Image.URL}
+		}
+		if e.Thumbnail != nil {
+			embed.Thumbnail = &transcript.Image{URL: e.Thumbnail.URL}
+		}
+		if e.Footer != nil {
+			embed.Footer = &transcript.Footer{Text: e.Footer.Text, IconURL: e.Footer.IconURL}
+		}
+		if e.Author != nil {
+			embed.Author = &transcript.EmbedAuthor{Name: e.Author.Name, URL: e.Author.URL, IconURL: e.Author.IconURL}
+		}
+		msg.Embeds = append(msg.Embeds, embed)
+	}
+
+	for _, a := range m.Attachments {
+		msg.Attachments = append(msg.Attachments, transcript.Attachment{
+			ID:          a.ID,
+			Filename:    a.Filename,
+			URL:         a.URL,
+			ProxyURL:    a.ProxyURL,
+			Size:        a.Size,
+			ContentType: a.ContentType,
+			Width:       a.Width,
+			Height:      a.Height,
+		})
+	}
+
+	for _, r := range m.Reactions {
+		msg.Reactions = append(msg.Reactions, transcript.Reaction{Emoji: r.Emoji, Count: r.Count})
+	}
+	return msg
+}
+
+func participantIDs(participants []transcript.Participant) []string {
+	ids := make([]string, 0, len(participants))
+	for _, p := range participants {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// parseHexColor reads the "#RRGGBB" strings the v1 dashboard saved. Anything
+// else becomes 0, Discord's default embed color.
+func parseHexColor(value string) int32 {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	color, err := strconv.ParseInt(value, 16, 32)
+	if err != nil || color < 0 || color > 0xFFFFFF {
+		return 0
+	}
+	return int32(color)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := formatTime(*t)
+	return &formatted
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,402 @@
+// cmd/import-v1/main.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/config"
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Imports guild config, staff, panels and transcripts from the v1 MongoDB
+// database. Safe to re-run: every imported document is recorded in
+// v1_import and skipped the next time, and guilds that already have a v2
+// config keep it.
+func main() {
+	guildFlag := flag.String("guild", "", "only import this guild id")
+	flag.Parse()
+
+	cfg := config.Load()
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		log.Fatal("MONGODB_URI is required")
+	}
+	mongoDB := os.Getenv("MONGODB_DB")
+	if mongoDB == "" {
+		mongoDB = "test"
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DBUrl)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	client, err := mongo.Connect(options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		log.Fatalf("Unable to connect to MongoDB: %v\n", err)
+	}
+	defer client.Disconnect(ctx)
+
+	var keyring *storage.Keyring
+	if len(cfg.TranscriptKeys) > 0 {
+		keyring, err = storage.NewKeyring(cfg.TranscriptKeyID, cfg.TranscriptKeys)
+		if err != nil {
+			log.Fatalf("Invalid transcript keys: %v\n", err)
+		}
+	}
+	storageClient, err := storage.Open(cfg.Storage, keyring)
+	if err != nil {
+		log.Fatalf("Unable to open storage: %v\n", err)
+	}
+
+	imp := &importer{
+		pool:    pool,
+		queries: db.New(pool),
+		storage: storageClient,
+		mongo:   client.Database(mongoDB),
+		guild:   *guildFlag,
+		known:   make(map[int64]bool),
+	}
+
+	if err := imp.importServers(ctx); err != nil {
+		log.Fatalf("Import servers failed: %v\n", err)
+	}
+	if err := imp.importPanels(ctx); err != nil {
+		log.Fatalf("Import panels failed: %v\n", err)
+	}
+	if err := imp.importMultiPanels(ctx); err != nil {
+		log.Fatalf("Import multi-panels failed: %v\n", err)
+	}
+	if err := imp.importTranscripts(ctx); err != nil {
+		log.Fatalf("Import transcripts failed: %v\n", err)
+	}
+
+	fmt.Printf("✅ Import finished: %d servers, %d panels, %d multi-panels, %d transcripts imported; %d skipped, %d failed.\n",
+		imp.servers, imp.panels, imp.multiPanels, imp.transcripts, imp.skipped, imp.failed)
+}
+
+type importer struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+	storage storage.Client
+	mongo   *mongo.Database
+	guild   string
+
+	// known holds the guilds that have a server_config row.
+	known map[int64]bool
+
+	servers     int
+	panels      int
+	multiPanels int
+	transcripts int
+	skipped     int
+	failed      int
+}
+
+// filter limits a query to the -guild flag, if set.
+func (imp *importer) filter(field string) bson.M {
+	if imp.guild == "" {
+		return bson.M{}
+	}
+	return bson.M{field: imp.guild}
+}
+
+// each decodes every document of a collection matching filter into T and
+// calls fn with it. Errors from fn are logged and counted, not fatal.
+func each[T any](ctx context.Context, imp *importer, collection string, filter bson.M, fn func(T) error) error {
+	cursor, err := imp.mongo.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc T
+		if err := cursor.Decode(&doc); err != nil {
+			log.Printf("decode %s document failed: %v", collection, err)
+			imp.failed++
+			continue
+		}
+		if err := fn(doc); err != nil {
+			log.Printf("import %s document failed: %v", collection, err)
+			imp.failed++
+		}
+	}
+	return cursor.Err()
+}
+
+func (imp *importer) importServers(ctx context.Context) error {
+	return each(ctx, imp, "servers", imp.filter("serverId"), func(server v1Server) error {
+		guildID, err := strconv.ParseInt(server.ServerID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("server %q: invalid id", server.ServerID)
+		}
+
+		_, err = imp.queries.GetServerConfig(ctx, guildID)
+		switch {
+		case err == nil:
+			imp.known[guildID] = true
+			imp.skipped++
+		case errors.Is(err, pgx.ErrNoRows):
+			err = pgx.BeginFunc(ctx, imp.pool, func(tx pgx.Tx) error {
+				q := imp.queries.WithTx(tx)
+				if _, err := q.UpsertServerConfig(ctx, serverConfigParams(guildID, server)); err != nil {
+					return fmt.Errorf("save config: %w", err)
+				}
+				if _, err := q.UpsertAutoCloseConfig(ctx, autoCloseParams(guildID, server.TicketConfig)); err != nil {
+					return fmt.Errorf("save auto close config: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("server %d: %w", guildID, err)
+			}
+			imp.known[guildID] = true
+			imp.servers++
+		default:
+			return fmt.Errorf("server %d: load config: %w", guildID, err)
+		}
+
+		// Staff lists are merged into whatever v2 already has.
+		for _, userID := range server.TicketConfig.Staffs.Users {
+			if err := imp.queries.UpsertAuthorizedMember(ctx, db.UpsertAuthorizedMemberParams{ServerConfigID: guildID, MemberID: userID}); err != nil {
+				return fmt.Errorf("server %d: save staff member: %w", guildID, err)
+			}
+		}
+		for _, roleID := range server.TicketConfig.Staffs.Roles {
+			if err := imp.queries.UpsertAuthorizedRole(ctx, db.UpsertAuthorizedRoleParams{ServerConfigID: guildID, RoleID: roleID}); err != nil {
+				return fmt.Errorf("server %d: save staff role: %w", guildID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (imp *importer) importPanels(ctx context.Context) error {
+	return each(ctx, imp, "panels", imp.filter("serverId"), func(panel v1Panel) error {
+		v1ID := panel.panelID()
+		if _, err := imp.queries.GetV1Import(ctx, db.V1ImportPanel, v1ID); err == nil {
+			imp.skipped++
+			return nil
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("panel %s: %w", v1ID, err)
+		}
+
+		guildID, err := strconv.ParseInt(panel.ServerID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("panel %s: invalid server id %q", v1ID, panel.ServerID)
+		}
+		if err := imp.ensureServerConfig(ctx, guildID); err != nil {
+			return fmt.Errorf("panel %s: %w", v1ID, err)
+		}
+
+		welcome, hasWelcome := welcomeParams(panel)
+		err = pgx.BeginFunc(ctx, imp.pool, func(tx pgx.Tx) error {
+			q := imp.queries.WithTx(tx)
+			created, err := q.CreatePanelConfig(ctx, panelParams(guildID, panel))
+			if err != nil {
+				return fmt.Errorf("create panel: %w", err)
+			}
+			if err := q.ReplaceQuestionsConfig(ctx, created.ID, panelQuestions(panel)); err != nil {
+				return fmt.Errorf("save questions: %w", err)
+			}
+			if err := q.ReplaceWelcomeMsgConfig(ctx, created.ID, welcome, hasWelcome); err != nil {
+				return fmt.Errorf("save welcome message: %w", err)
+			}
+			return q.RecordV1Import(ctx, db.V1ImportPanel, v1ID, int64(created.ID), time.Now().Unix())
+		})
+		if err != nil {
+			return fmt.Errorf("panel %s: %w", v1ID, err)
+		}
+		imp.panels++
+		return nil
+	})
+}
+
+// importMultiPanels creates a multi-panel for every v1 server that had one
+// posted. v1 kept a single multi-panel per server, inside the server document.
+func (imp *importer) importMultiPanels(ctx context.Context) error {
+	return each(ctx, imp, "servers", imp.filter("serverId"), func(server v1Server) error {
+		multi := server.TicketConfig.MultiPanels
+		if deref(multi.Channel) == "" || len(multi.Panels) == 0 {
+			return nil
+		}
+		if _, err := imp.queries.GetV1Import(ctx, db.V1ImportMultiPanel, server.ServerID); err == nil {
+			imp.skipped++
+			return nil
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("multi-panel %s: %w", server.ServerID, err)
+		}
+
+		guildID, err := strconv.ParseInt(server.ServerID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("multi-panel %q: invalid server id", server.ServerID)
+		}
+
+		panelIDs := make([]int32, 0, len(multi.Panels))
+		for _, v1ID := range multi.Panels {
+			id, err := imp.queries.GetV1Import(ctx, db.V1ImportPanel, v1ID)
+			if err != nil {
+				log.Printf("multi-panel %d: panel %s was not imported, leaving it out", guildID, v1ID)
+				continue
+			}
+			panelIDs = append(panelIDs, int32(id))
+		}
+		if len(panelIDs) == 0 {
+			return fmt.Errorf("multi-panel %d: none of its panels were imported", guildID)
+		}
+
+		err = pgx.BeginFunc(ctx, imp.pool, func(tx pgx.Tx) error {
+			q := imp.queries.WithTx(tx)
+			created, err := q.CreateMultiPanelConfig(ctx, multiPanelParams(guildID, server.TicketConfig, panelIDs))
+			if err != nil {
+				return fmt.Errorf("create multi-panel: %w", err)
+			}
+			return q.RecordV1Import(ctx, db.V1ImportMultiPanel, server.ServerID, int64(created.ID), time.Now().Unix())
+		})
+		if err != nil {
+			return fmt.Errorf("multi-panel %d: %w", guildID, err)
+		}
+		imp.multiPanels++
+		return nil
+	})
+}
+
+func (imp *importer) importTranscripts(ctx context.Context) error {
+	return each(ctx, imp, "transcripts", imp.filter("guildId"), func(doc v1Transcript) error {
+		v1ID := doc.ID.Hex()
+		if _, err := imp.queries.GetV1Import(ctx, db.V1ImportTranscript, v1ID); err == nil {
+			imp.skipped++
+			return nil
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("transcript %s: %w", v1ID, err)
+		}
+
+		if err := imp.importTranscript(ctx, v1ID, doc); err != nil {
+			return fmt.Errorf("transcript %s: %w", v1ID, err)
+		}
+		imp.transcripts++
+		if imp.transcripts%100 == 0 {
+			fmt.Printf("Imported %d transcripts (%d failed)\n", imp.transcripts, imp.failed)
+		}
+		return nil
+	})
+}
+
+func (imp *importer) importTranscript(ctx context.Context, v1ID string, doc v1Transcript) error {
+	guildID, err := strconv.ParseInt(doc.GuildID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid guild id %q", doc.GuildID)
+	}
+	if err := imp.ensureServerConfig(ctx, guildID); err != nil {
+		return err
+	}
+
+	payload := convertTranscript(doc)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	openedAt := doc.Metadata.TicketOpenedAt.Unix()
+	closedAt := doc.Metadata.TicketClosedAt.Unix()
+	if doc.Metadata.TicketClosedAt.IsZero() {
+		closedAt = doc.CreatedAt.Unix()
+	}
+	// Two v1 transcripts of a channel can share a close time, so the key uses
+	// the v1 document ID. Channel IDs are numeric and never clash with the v1
+	// directory.
+	storageKey := fmt.Sprintf("transcripts/%d/v1/%s.json", guildID, v1ID)
+	size, err := imp.storage.UploadTranscript(ctx, storageKey, data)
+	if err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+
+	var panelID pgtype.Int4
+	if doc.PanelID != "" {
+		if id, err := imp.queries.GetV1Import(ctx, db.V1ImportPanel, doc.PanelID); err == nil {
+			panelID = pgtype.Int4{Int32: int32(id), Valid: true}
+		}
+	}
+
+	meta := payload.Metadata
+	return pgx.BeginFunc(ctx, imp.pool, func(tx pgx.Tx) error {
+		q := imp.queries.WithTx(tx)
+		// A run that stopped after creating the row but before recording it
+		// left the row behind; reuse it.
+		rowID, err := q.GetTranscriptIDByStorageKey(ctx, guildID, storageKey)
+		if errors.Is(err, pgx.ErrNoRows) {
+			row, err := q.CreateTranscript(ctx, db.CreateTranscriptParams{
+				ServerConfigID:   guildID,
+				TicketID:         pgtype.Text{String: doc.ChannelID, Valid: doc.ChannelID != ""},
+				Username:         pgtype.Text{String: doc.Username, Valid: doc.Username != ""},
+				UserID:           pgtype.Text{String: doc.UserID, Valid: doc.UserID != ""},
+				OpenedAt:         openedAt,
+				ClosedAt:         closedAt,
+				ClosedBy:         meta.ClosedBy.ID,
+				StorageKey:       storageKey,
+				TotalMessages:    pgtype.Int4{Int32: int32(meta.TotalMessages), Valid: true},
+				TotalAttachments: pgtype.Int4{Int32: int32(meta.TotalAttachments), Valid: true},
+				TotalEmbeds:      pgtype.Int4{Int32: int32(meta.TotalEmbeds), Valid: true},
+				CloseReason:      v1CloseReason,
+				PanelID:          panelID,
+				ParticipantIds:   participantIDs(meta.Participants),
+			})
+			if err != nil {
+				return fmt.Errorf("create row: %w", err)
+			}
+			rowID = row.ID
+		} else if err != nil {
+			return fmt.Errorf("look up row: %w", err)
+		}
+
+		if err := q.UpsertTranscriptSearch(ctx, rowID, guildID, transcript.SearchText(payload)); err != nil {
+			return fmt.Errorf("index: %w", err)
+		}
+		if err := q.RecordTranscriptUsage(ctx, db.RecordTranscriptUsageParams{
+			TranscriptID:    rowID,
+			ServerConfigID:  guildID,
+			TranscriptBytes: size,
+			UpdatedAt:       time.Now().Unix(),
+		}); err != nil {
+			return fmt.Errorf("record usage: %w", err)
+		}
+		return q.RecordV1Import(ctx, db.V1ImportTranscript, v1ID, int64(rowID), time.Now().Unix())
+	})
+}
+
+// ensureServerConfig creates a default config for guilds that have panels
+// or transcripts in v1 but no server document, since every v2 row hangs off
+// server_config.
+func (imp *importer) ensureServerConfig(ctx context.Context, guildID int64) error {
+	if imp.known[guildID] {
+		return nil
+	}
+	_, err := imp.queries.GetServerConfig(ctx, guildID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		_, err = imp.queries.UpsertServerConfig(ctx, defaultServerConfigParams(guildID))
+	}
+	if err != nil {
+		return fmt.Errorf("ensure server config: %w", err)
+	}
+	imp.known[guildID] = true
+	return nil
+}
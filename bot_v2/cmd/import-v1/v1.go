@@ -0,0 +1,210 @@
+package main
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// The v1 bot kept everything in MongoDB. These types mirror the documents
+// it wrote, keeping only the fields v2 has a place for.
+
+type v1Server struct {
+	ServerID     string         `bson:"serverId"`
+	TicketConfig v1TicketConfig `bson:"ticketConfig"`
+}
+
+type v1TicketConfig struct {
+	TicketNameStyle   string  `bson:"ticketNameStyle"`
+	TicketTranscript  *string `bson:"ticketTranscript"`
+	MaxTicketsPerUser int     `bson:"maxTicketsPerUser"`
+	TicketPermissions struct {
+		Attachments bool `bson:"attachments"`
+		Links       bool `bson:"links"`
+		Reactions   bool `bson:"reactions"`
+	} `bson:"ticketPermissions"`
+	AutoClose struct {
+		Enabled                  bool       `bson:"enabled"`
+		CloseWhenUserLeaves      bool       `bson:"closeWhenUserLeaves"`
+		SinceOpenWithoutResponse v1Duration `bson:"sinceOpenWithoutResponse"`
+		SinceLastResponse        v1Duration `bson:"sinceLastResponse"`
+	} `bson:"autoClose"`
+	MultiPanels struct {
+		Channel        *string  `bson:"channel"`
+		Panels         []string `bson:"panels"`
+		DropdownConfig struct {
+			Use bool `bson:"use"`
+		} `bson:"dropdownConfig"`
+		MessageEmbedConfig struct {
+			Color        string  `bson:"color"`
+			Title        string  `bson:"title"`
+			Description  string  `bson:"description"`
+			LargeImgURL  *string `bson:"largeImgUrl"`
+			SmallImgURL  *string `bson:"smallImgUrl"`
+			FooterText   *string `bson:"footerText"`
+			FooterImgURL *string `bson:"footerImgUrl"`
+		} `bson:"messageEmbedConfig"`
+	} `bson:"multiPanels"`
+	Staffs struct {
+		Users []string `bson:"users"`
+		Roles []string `bson:"roles"`
+	} `bson:"staffs"`
+}
+
+type v1Duration struct {
+	Days    int `bson:"Days"`
+	Hours   int `bson:"Hours"`
+	Minutes int `bson:"Minutes"`
+}
+
+func (d v1Duration) minutes() int32 {
+	return int32(d.Days*24*60 + d.Hours*60 + d.Minutes)
+}
+
+type v1Panel struct {
+	// Panels were created by the v1 dashboard, which stored _id either as
+	// an ObjectID or as its hex string.
+	ID             bson.RawValue `bson:"_id"`
+	ServerID       string        `bson:"serverId"`
+	Channel        string        `bson:"channel"`
+	MentionOnOpen  []string      `bson:"mentionOnOpen"`
+	TicketCategory *string       `bson:"ticketCategory"`
+	Title          string        `bson:"title"`
+	Content        string        `bson:"content"`
+	Color          string        `bson:"color"`
+	BtnColor       string        `bson:"btnColor"`
+	BtnText        string        `bson:"btnText"`
+	BtnEmoji       *string       `bson:"btnEmoji"`
+	Questions      *struct {
+		AskQuestions bool `bson:"askQuestions"`
+		Questions    []struct {
+			Prompt string `bson:"prompt"`
+		} `bson:"questions"`
+	} `bson:"questions"`
+	LargeImgURL  *string `bson:"largeImgUrl"`
+	SmallImgURL  *string `bson:"smallImgUrl"`
+	WelcomeEmbed *struct {
+		Color        string  `bson:"color"`
+		Title        *string `bson:"title"`
+		Description  *string `bson:"description"`
+		TitleImgURL  *string `bson:"titleImgUrl"`
+		LargeImgURL  *string `bson:"largeImgUrl"`
+		SmallImgURL  *string `bson:"smallImgUrl"`
+		FooterText   *string `bson:"footerText"`
+		FooterImgURL *string `bson:"footerImgUrl"`
+	} `bson:"welcomeEmbed"`
+}
+
+type v1Transcript struct {
+	ID            bson.ObjectID        `bson:"_id"`
+	TicketID      string               `bson:"ticketId"`
+	GuildID       string               `bson:"guildId"`
+	ChannelID     string               `bson:"channelId"`
+	PanelID       string               `bson:"panelId"`
+	UserID        string               `bson:"userId"`
+	Username      string               `bson:"username"`
+	TicketNumber  int                  `bson:"ticketNumber"`
+	Messages      []v1Message          `bson:"messages"`
+	VoiceActivity []v1VoiceActivity    `bson:"voiceActivity"`
+	Metadata      v1TranscriptMetadata `bson:"metadata"`
+	CreatedAt     time.Time            `bson:"createdAt"`
+}
+
+type v1Message struct {
+	ID              string         `bson:"id"`
+	Type            string         `bson:"type"`
+	Author          v1Author       `bson:"author"`
+	Content         *string        `bson:"content"`
+	Timestamp       time.Time      `bson:"timestamp"`
+	Embeds          []v1Embed      `bson:"embeds"`
+	Attachments     []v1Attachment `bson:"attachments"`
+	Edited          bool           `bson:"edited"`
+	EditedTimestamp *time.Time     `bson:"editedTimestamp"`
+	Reactions       []struct {
+		Emoji string `bson:"emoji"`
+		Count int    `bson:"count"`
+	} `bson:"reactions"`
+}
+
+type v1Embed struct {
+	Title       *string `bson:"title"`
+	Description *string `bson:"description"`
+	URL         *string `bson:"url"`
+	Color       *int    `bson:"color"`
+	Fields      []struct {
+		Name   string `bson:"name"`
+		Value  string `bson:"value"`
+		Inline bool   `bson:"inline"`
+	} `bson:"fields"`
+	Image     *v1Image `bson:"image"`
+	Thumbnail *v1Image `bson:"thumbnail"`
+	Footer    *struct {
+		Text    string  `bson:"text"`
+		IconURL *string `bson:"iconUrl"`
+	} `bson:"footer"`
+	Author *struct {
+		Name    string  `bson:"name"`
+		URL     *string `bson:"url"`
+		IconURL *string `bson:"iconUrl"`
+	} `bson:"author"`
+}
+
+type v1Image struct {
+	URL string `bson:"url"`
+}
+
+type v1Attachment struct {
+	ID          string  `bson:"id"`
+	Filename    string  `bson:"filename"`
+	URL         string  `bson:"url"`
+	ProxyURL    string  `bson:"proxyUrl"`
+	Size        int     `bson:"size"`
+	ContentType *string `bson:"contentType"`
+	Width       *int    `bson:"width"`
+	Height      *int    `bson:"height"`
+}
+
+type v1Author struct {
+	ID            string  `bson:"id"`
+	Username      string  `bson:"username"`
+	Discriminator string  `bson:"discriminator"`
+	Avatar        *string `bson:"avatar"`
+	Bot           bool    `bson:"bot"`
+}
+
+type v1VoiceActivity struct {
+	UserID   string     `bson:"userId"`
+	Username string     `bson:"username"`
+	JoinedAt time.Time  `bson:"joinedAt"`
+	LeftAt   *time.Time `bson:"leftAt"`
+	Duration int        `bson:"duration"`
+}
+
+type v1TranscriptMetadata struct {
+	TicketOpenedAt time.Time `bson:"ticketOpenedAt"`
+	TicketClosedAt time.Time `bson:"ticketClosedAt"`
+	ClosedBy       struct {
+		ID       string `bson:"id"`
+		Username string `bson:"username"`
+	} `bson:"closedBy"`
+	TotalMessages    int `bson:"totalMessages"`
+	TotalAttachments int `bson:"totalAttachments"`
+	TotalEmbeds      int `bson:"totalEmbeds"`
+	Participants     []struct {
+		ID           string `bson:"id"`
+		Username     string `bson:"username"`
+		MessageCount int    `bson:"messageCount"`
+	} `bson:"participants"`
+}
+
+// panelID returns the id v1 used to refer to the panel from servers and
+// transcripts.
+func (p v1Panel) panelID() string {
+	if oid, ok := p.ID.ObjectIDOK(); ok {
+		return oid.Hex()
+	}
+	if str, ok := p.ID.StringValueOK(); ok {
+		return str
+	}
+	return p.ID.String()
+}
@@ -0,0 +1,85 @@
+// cmd/reindex/main.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/Sush1sui/FNS_BOT/internal/config"
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const batchSize = 100
+
+// Backfills the transcript search index from stored transcripts. Safe to
+// re-run: only transcripts without a search row are processed.
+func main() {
+	cfg := config.Load()
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DBUrl)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	queries := db.New(pool)
+	var keyring *storage.Keyring
+	if len(cfg.TranscriptKeys) > 0 {
+		keyring, err = storage.NewKeyring(cfg.TranscriptKeyID, cfg.TranscriptKeys)
+		if err != nil {
+			log.Fatalf("Invalid transcript keys: %v\n", err)
+		}
+	}
+	storageClient, err := storage.Open(cfg.Storage, keyring)
+	if err != nil {
+		log.Fatalf("Unable to open storage: %v\n", err)
+	}
+
+	var (
+		afterID int32
+		indexed int
+		failed  int
+	)
+	for {
+		batch, err := queries.ListUnindexedTranscripts(ctx, afterID, batchSize)
+		if err != nil {
+			log.Fatalf("List transcripts failed: %v\n", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, item := range batch {
+			afterID = item.ID
+			if err := indexTranscript(ctx, queries, storageClient, item); err != nil {
+				log.Printf("index transcript %d failed: %v", item.ID, err)
+				failed++
+				continue
+			}
+			indexed++
+		}
+		fmt.Printf("Indexed %d transcripts (%d failed)\n", indexed, failed)
+	}
+
+	fmt.Printf("✅ Reindex finished: %d indexed, %d failed.\n", indexed, failed)
+}
+
+func indexTranscript(ctx context.Context, queries *db.Queries, storageClient storage.Client, item db.UnindexedTranscript) error {
+	data, err := storageClient.DownloadTranscript(ctx, item.StorageKey)
+	if err != nil {
+		return fmt.Errorf("download: %w", err)
+	}
+
+	var payload transcript.Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	return queries.UpsertTranscriptSearch(ctx, item.ID, item.ServerConfigID, transcript.SearchText(payload))
+}
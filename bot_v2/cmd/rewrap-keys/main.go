@@ -0,0 +1,116 @@
+// cmd/rewrap-keys/main.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Sush1sui/FNS_BOT/internal/config"
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const batchSize = 100
+
+// Re-wraps every transcript's data key with TRANSCRIPT_KEY_ID after a key
+// rotation, and encrypts transcripts stored before encryption was enabled.
+// Unredacted originals and archived attachments kept next to a transcript
+// are re-wrapped with it, and export archives after all transcripts. Only
+// the envelope header is rewritten. Safe to re-run: objects already on the
+// active key are skipped. Retire an old key from TRANSCRIPT_KEYS only after a
+// run finishes with no failures.
+func main() {
+	cfg := config.Load()
+	if len(cfg.TranscriptKeys) == 0 {
+		log.Fatal("TRANSCRIPT_KEYS is required to re-wrap transcripts")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, cfg.DBUrl)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	queries := db.New(pool)
+	keyring, err := storage.NewKeyring(cfg.TranscriptKeyID, cfg.TranscriptKeys)
+	if err != nil {
+		log.Fatalf("Invalid transcript keys: %v\n", err)
+	}
+	storageClient, err := storage.Open(cfg.Storage, keyring)
+	if err != nil {
+		log.Fatalf("Unable to open storage: %v\n", err)
+	}
+
+	var (
+		afterID   int32
+		rewrapped int
+		skipped   int
+		failed    int
+	)
+	// count records the outcome of one re-wrap.
+	count := func(what string, changed bool, err error) {
+		switch {
+		case err != nil:
+			log.Printf("rewrap %s failed: %v", what, err)
+			failed++
+		case changed:
+			rewrapped++
+		default:
+			skipped++
+		}
+	}
+	// rewrapObjects re-wraps every object stored under prefix.
+	rewrapObjects := func(prefix string) {
+		keys, err := storageClient.ListObjects(ctx, prefix)
+		if err != nil {
+			log.Printf("list %s failed: %v", prefix, err)
+			failed++
+			return
+		}
+		for _, key := range keys {
+			changed, err := storageClient.RewrapObject(ctx, key)
+			count(key, changed, err)
+		}
+	}
+	for {
+		batch, err := queries.ListTranscriptStorageKeys(ctx, afterID, batchSize)
+		if err != nil {
+			log.Fatalf("List transcripts failed: %v\n", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, item := range batch {
+			afterID = item.ID
+			changed, err := storageClient.RewrapTranscript(ctx, item.StorageKey)
+			if err != nil {
+				log.Printf("rewrap transcript %d failed: %v", item.ID, err)
+				failed++
+				continue
+			}
+			if changed {
+				rewrapped++
+			} else {
+				skipped++
+			}
+
+			// Most transcripts have no original; a missing one is fine.
+			changed, err = storageClient.RewrapTranscript(ctx, transcript.OriginalKey(item.StorageKey))
+			if !storage.IsNotFound(err) {
+				count(fmt.Sprintf("original of transcript %d", item.ID), changed, err)
+			}
+
+			rewrapObjects(transcript.ObjectPrefix(item.StorageKey) + "attachments/")
+		}
+		fmt.Printf("Re-wrapped %d objects (%d current, %d failed)\n", rewrapped, skipped, failed)
+	}
+
+	rewrapObjects("exports/")
+
+	fmt.Printf("✅ Re-wrap to key %q finished: %d objects re-wrapped, %d current, %d failed.\n", keyring.ActiveID(), rewrapped, skipped, failed)
+}
@@ -12,7 +12,7 @@ import (
 
 type Server struct {
 	DB      *db.Queries
-	Storage *storage.Client
+	Storage storage.Client
 	Config  *config.Config
 	Limiter *rateLimiter
 }
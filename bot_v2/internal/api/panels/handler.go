@@ -12,6 +12,7 @@ import (
 	"github.com/Sush1sui/FNS_BOT/internal/bot"
 	"github.com/Sush1sui/FNS_BOT/internal/db"
 	"github.com/Sush1sui/FNS_BOT/internal/utils"
+	"github.com/Sush1sui/FNS_BOT/internal/webhook"
 	"github.com/bwmarrin/discordgo"
 	"github.com/jackc/pgx/v5"
 )
@@ -388,6 +389,7 @@ func (h *Handler) HandleSendPanel(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to send panel"})
 		return
 	}
+	webhook.Emit(serverID, webhook.PanelSent, msg.ID, webhook.PanelData{PanelID: item.ID, ChannelID: item.ChannelID, MessageID: msg.ID})
 
 	writeJSON(w, http.StatusOK, map[string]string{"messageId": msg.ID})
 }
@@ -612,6 +614,7 @@ func (h *Handler) HandleSendMultiPanel(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to send multi panel"})
 		return
 	}
+	webhook.Emit(serverID, webhook.PanelSent, msg.ID, webhook.PanelData{MultiPanelID: item.ID, ChannelID: item.ChannelID, MessageID: msg.ID})
 
 	writeJSON(w, http.StatusOK, map[string]string{"messageId": msg.ID})
 }
@@ -1,21 +1,29 @@
 package api
 
 import (
+	"crypto/subtle"
 	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Sush1sui/FNS_BOT/internal/api/auth"
 	"github.com/Sush1sui/FNS_BOT/internal/api/panels"
 	serverconfig "github.com/Sush1sui/FNS_BOT/internal/api/server-config"
 	"github.com/Sush1sui/FNS_BOT/internal/api/transcripts"
+	"github.com/Sush1sui/FNS_BOT/internal/api/webhooks"
 	"github.com/Sush1sui/FNS_BOT/internal/config"
 	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
 	"github.com/Sush1sui/FNS_BOT/internal/storage"
 )
 
-func NewRouter(queries *db.Queries, storageClient *storage.Client, cfg *config.Config) http.Handler {
+// transcriptCacheBytes bounds the memory cache of hot transcripts. They are
+// cached gzipped, so this holds a few hundred typical transcripts.
+const transcriptCacheBytes = 64 << 20
+
+func NewRouter(queries *db.Queries, storageClient storage.Client, cfg *config.Config) http.Handler {
 	s := &Server{
 		DB:      queries,
 		Storage: storageClient,
@@ -28,7 +36,10 @@ func NewRouter(queries *db.Queries, storageClient *storage.Client, cfg *config.C
 	configHandler := &serverconfig.Handler{DB: queries}
 	authHandler := &auth.Handler{Server: s}
 	panelsHandler := &panels.Handler{DB: queries}
-	transcriptsHandler := &transcripts.Handler{DB: queries, Storage: s.Storage}
+	transcriptsHandler := &transcripts.Handler{DB: queries, Storage: s.Storage, ShareKey: cfg.AccessTokenKey, Cache: transcripts.NewCache(transcriptCacheBytes), StorageQuota: cfg.StorageQuotaBytes}
+	webhooksHandler := &webhooks.Handler{DB: queries}
+	go transcriptsHandler.ResumeExports()
+	transcriptsHandler.StartExportSweeper()
 
 	mux := http.NewServeMux()
 
@@ -41,6 +52,10 @@ func NewRouter(queries *db.Queries, storageClient *storage.Client, cfg *config.C
 		s.handleHealth(w, r)
 	})
 
+	if cfg.MetricsToken != "" {
+		mux.HandleFunc("GET /metrics", s.wrapIPRateLimit("metrics:", 30, time.Minute, wrapBearerToken(cfg.MetricsToken, metrics.Handler)))
+	}
+
 	// Server config routes with auth wrapper
 	mux.HandleFunc("GET /api/config/{server_id}", s.wrapAuthConfig(configHandler.HandleGetServerConfig))
 	mux.HandleFunc("PUT /api/config/{server_id}", s.wrapAuthConfig(configHandler.HandleUpdateServerConfig))
@@ -53,6 +68,10 @@ func NewRouter(queries *db.Queries, storageClient *storage.Client, cfg *config.C
 	// Staff routes
 	mux.HandleFunc("GET /api/servers/{server_id}/staff", s.wrapAuthConfig(configHandler.HandleGetStaff))
 	mux.HandleFunc("PUT /api/servers/{server_id}/staff", s.wrapAuthConfig(configHandler.HandleUpdateStaff))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcript-redaction", s.wrapAuthConfig(configHandler.HandleGetRedaction))
+	mux.HandleFunc("PUT /api/servers/{server_id}/transcript-redaction", s.wrapAuthAdmin(configHandler.HandleUpdateRedaction))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcript-retention", s.wrapAuthConfig(configHandler.HandleGetRetention))
+	mux.HandleFunc("PUT /api/servers/{server_id}/transcript-retention", s.wrapAuthAdmin(configHandler.HandleUpdateRetention))
 
 	// Auth routes
 	mux.HandleFunc("GET /api/auth/login", s.wrapIPRateLimit("auth:login:", 20, time.Minute, authHandler.HandleAuthLogin))
@@ -79,14 +98,62 @@ func NewRouter(queries *db.Queries, storageClient *storage.Client, cfg *config.C
 
 	// Transcript routes
 	mux.HandleFunc("GET /api/servers/{server_id}/transcripts", s.wrapAuthConfig(transcriptsHandler.HandleListTranscripts))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/search", s.wrapAuthConfig(transcriptsHandler.HandleSearchTranscripts))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/purges", s.wrapAuthConfig(transcriptsHandler.HandleListPurges))
 	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/{transcript_id}", s.wrapAuthConfig(transcriptsHandler.HandleGetTranscript))
 	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/{transcript_id}/content", s.wrapAuthConfig(transcriptsHandler.HandleGetTranscriptContent))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/{transcript_id}/html", s.wrapAuthConfig(transcriptsHandler.HandleGetTranscriptHTML))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/{transcript_id}/attachments/{attachment_id}", s.wrapAuthConfig(transcriptsHandler.HandleGetTranscriptAttachment))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/{transcript_id}/original", s.wrapAuthAdmin(transcriptsHandler.HandleGetTranscriptOriginal))
+	mux.HandleFunc("PUT /api/servers/{server_id}/transcripts/{transcript_id}/legal-hold", s.wrapAuthConfig(transcriptsHandler.HandleSetLegalHold))
+	mux.HandleFunc("POST /api/servers/{server_id}/transcripts/{transcript_id}/share", s.wrapAuthConfigUser(transcriptsHandler.HandleCreateShare))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/{transcript_id}/shares", s.wrapAuthConfig(transcriptsHandler.HandleListShares))
+	mux.HandleFunc("DELETE /api/servers/{server_id}/transcripts/{transcript_id}/shares/{share_id}", s.wrapAuthConfigUser(transcriptsHandler.HandleRevokeShare))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcripts/{transcript_id}/shares/{share_id}/events", s.wrapAuthConfig(transcriptsHandler.HandleListShareEvents))
+	mux.HandleFunc("GET /api/servers/{server_id}/usage", s.wrapAuthConfig(transcriptsHandler.HandleGetUsage))
+	if cfg.AdminToken != "" {
+		mux.HandleFunc("PUT /api/admin/servers/{server_id}/storage-quota", s.wrapIPRateLimit("admin:", 30, time.Minute, wrapBearerToken(cfg.AdminToken, transcriptsHandler.HandleSetStorageQuota)))
+	}
+	mux.HandleFunc("POST /api/servers/{server_id}/transcript-exports", s.wrapAuthConfig(transcriptsHandler.HandleCreateExport))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcript-exports/{export_id}", s.wrapAuthConfig(transcriptsHandler.HandleGetExport))
+	mux.HandleFunc("GET /api/servers/{server_id}/transcript-exports/{export_id}/download", s.wrapAuthConfig(transcriptsHandler.HandleDownloadExport))
+
+	// Webhook routes; changing where ticket data is sent is admin only
+	mux.HandleFunc("GET /api/servers/{server_id}/webhooks", s.wrapAuthConfig(webhooksHandler.HandleListWebhooks))
+	mux.HandleFunc("POST /api/servers/{server_id}/webhooks", s.wrapAuthAdmin(webhooksHandler.HandleCreateWebhook))
+	mux.HandleFunc("GET /api/servers/{server_id}/webhooks/{webhook_id}", s.wrapAuthConfig(webhooksHandler.HandleGetWebhook))
+	mux.HandleFunc("PUT /api/servers/{server_id}/webhooks/{webhook_id}", s.wrapAuthAdmin(webhooksHandler.HandleUpdateWebhook))
+	mux.HandleFunc("DELETE /api/servers/{server_id}/webhooks/{webhook_id}", s.wrapAuthAdmin(webhooksHandler.HandleDeleteWebhook))
+	mux.HandleFunc("POST /api/servers/{server_id}/webhooks/{webhook_id}/test", s.wrapAuthAdmin(webhooksHandler.HandleTestWebhook))
+	mux.HandleFunc("GET /api/servers/{server_id}/webhooks/{webhook_id}/deliveries", s.wrapAuthConfig(webhooksHandler.HandleListDeliveries))
+	mux.HandleFunc("POST /api/servers/{server_id}/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver", s.wrapAuthAdmin(webhooksHandler.HandleRedeliver))
+
+	// Opener routes: a user's own transcripts, across servers
+	mux.HandleFunc("GET /api/me/transcripts", s.wrapAuthUser(transcriptsHandler.HandleListMyTranscripts))
+	mux.HandleFunc("GET /api/me/transcripts/{transcript_id}", s.wrapAuthUser(transcriptsHandler.HandleGetMyTranscript))
+	mux.HandleFunc("GET /api/me/transcripts/{transcript_id}/content", s.wrapAuthUser(transcriptsHandler.HandleGetMyTranscriptContent))
+	mux.HandleFunc("GET /api/me/transcripts/{transcript_id}/html", s.wrapAuthUser(transcriptsHandler.HandleGetMyTranscriptHTML))
+	mux.HandleFunc("GET /api/me/transcripts/{transcript_id}/attachments/{attachment_id}", s.wrapAuthUser(transcriptsHandler.HandleGetMyTranscriptAttachment))
+
+	// Public share links
+	mux.HandleFunc("GET /api/share/{token}", s.wrapIPRateLimit("share:", 30, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+		transcriptsHandler.HandleViewShare(w, r, s.clientIP(r))
+	}))
+	mux.HandleFunc("GET /api/share/{token}/attachments/{attachment_id}", s.wrapIPRateLimit("share:attachments:", 240, time.Minute, transcriptsHandler.HandleGetShareAttachment))
 
 	return SecurityHeaders(LimitRequestBody(EnableCORS(mux, cfg.ClientOrigin, true)))
 }
 
 // wrapAuthConfig wraps a handler to require auth + server authorization
 func (s *Server) wrapAuthConfig(next http.HandlerFunc) http.HandlerFunc {
+	return s.wrapAuthConfigUser(func(w http.ResponseWriter, r *http.Request, _ string) {
+		next(w, r)
+	})
+}
+
+// wrapAuthConfigUser is wrapAuthConfig for handlers that need to know who is
+// acting, for example to record it in an audit trail.
+func (s *Server) wrapAuthConfigUser(next func(w http.ResponseWriter, r *http.Request, discordID string)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		serverID := r.PathValue("server_id")
 
@@ -132,13 +199,60 @@ func (s *Server) wrapAuthConfig(next http.HandlerFunc) http.HandlerFunc {
 			if recorder == nil {
 				return
 			}
-			next(recorder, r)
+			next(recorder, r, claims.DiscordID)
 			if err := s.finishIdempotency(r.Context(), ctx, recorder); err != nil {
 				log.Printf("idempotency store failed: %v", err)
 			}
 			return
 		}
 
+		next(w, r, claims.DiscordID)
+	}
+}
+
+// wrapAuthAdmin is wrapAuthConfig for routes that only server admins may use,
+// such as reading unredacted transcripts.
+func (s *Server) wrapAuthAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return s.wrapAuthConfigUser(func(w http.ResponseWriter, r *http.Request, discordID string) {
+		if !hasAdminPermsFromCache(r.PathValue("server_id"), discordID) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin only"})
+			return
+		}
+		next(w, r)
+	})
+}
+
+// wrapAuthUser wraps a handler that only needs a logged-in user, passing it
+// the caller's Discord ID. The handler must scope its data to that ID.
+func (s *Server) wrapAuthUser(next func(w http.ResponseWriter, r *http.Request, discordID string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := s.AuthFromRequest(r)
+		if err != nil || claims.DiscordID == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+
+		if s.Limiter != nil {
+			key := "user:" + claims.UserID
+			if !s.Limiter.Allow(key, 60, time.Minute) {
+				writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate limit"})
+				return
+			}
+		}
+
+		next(w, r, claims.DiscordID)
+	}
+}
+
+// wrapBearerToken requires "Authorization: Bearer <token>", for endpoints
+// meant for infrastructure such as a metrics scraper rather than users.
+func wrapBearerToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
 		next(w, r)
 	}
 }
@@ -0,0 +1,98 @@
+package serverconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5"
+)
+
+// RedactionConfig is the guild's transcript redaction setup. KeepOriginal
+// stores an encrypted unredacted copy that only server admins can read.
+type RedactionConfig struct {
+	Detectors    []string                   `json:"detectors"`
+	Rules        []transcript.RedactionRule `json:"rules"`
+	KeepOriginal bool                       `json:"keepOriginal"`
+	// AvailableDetectors lists the built-in detectors, for the dashboard.
+	AvailableDetectors []string `json:"availableDetectors,omitempty"`
+}
+
+func (h *Handler) HandleGetRedaction(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	cfg := RedactionConfig{
+		Detectors:          []string{},
+		Rules:              []transcript.RedactionRule{},
+		AvailableDetectors: transcript.Detectors,
+	}
+	row, err := h.DB.GetTranscriptRedaction(context.Background(), serverID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("load redaction config failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load redaction config"})
+		return
+	}
+	if err == nil {
+		cfg.Detectors = row.Detectors
+		cfg.KeepOriginal = row.KeepOriginal
+		if err := json.Unmarshal(row.Rules, &cfg.Rules); err != nil {
+			log.Printf("decode redaction rules failed: %v", err)
+		}
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+func (h *Handler) HandleUpdateRedaction(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	var payload RedactionConfig
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if payload.Detectors == nil {
+		payload.Detectors = []string{}
+	}
+	if payload.Rules == nil {
+		payload.Rules = []transcript.RedactionRule{}
+	}
+	// NewRedactor runs every check the bot will run when a ticket closes.
+	if _, err := transcript.NewRedactor(payload.Detectors, payload.Rules); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "validation failed", "fields": map[string]string{"rules": err.Error()}})
+		return
+	}
+	rules, _ := json.Marshal(payload.Rules)
+
+	ctx := context.Background()
+	if err := h.DB.EnsureServerConfig(ctx, serverID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to initialize server config"})
+		return
+	}
+	if err := h.DB.UpsertTranscriptRedaction(ctx, db.UpsertTranscriptRedactionParams{
+		ServerConfigID: serverID,
+		Detectors:      payload.Detectors,
+		Rules:          rules,
+		KeepOriginal:   payload.KeepOriginal,
+		UpdatedAt:      time.Now().Unix(),
+	}); err != nil {
+		log.Printf("save redaction config failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save redaction config"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "saved"})
+}
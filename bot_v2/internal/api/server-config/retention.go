@@ -0,0 +1,73 @@
+package serverconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// RetentionConfig is how many days the guild keeps transcripts; 0 keeps
+// them forever. It has its own endpoint so that saving the rest of the
+// settings can never start or stop purging.
+type RetentionConfig struct {
+	Days int `json:"days"`
+}
+
+func (h *Handler) HandleGetRetention(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	var cfg RetentionConfig
+	row, err := h.DB.GetServerConfig(context.Background(), serverID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("load retention config failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load retention config"})
+		return
+	}
+	if err == nil {
+		cfg.Days = int(row.TranscriptRetentionDays)
+	}
+	writeJSON(w, http.StatusOK, cfg)
+}
+
+func (h *Handler) HandleUpdateRetention(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	var payload RetentionConfig
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	errs := make(utils.ValidationErrors)
+	utils.ValidateIntRange(payload.Days, "days", 0, 3650, errs)
+	if len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "validation failed", "fields": errs})
+		return
+	}
+
+	ctx := context.Background()
+	if err := h.DB.EnsureServerConfig(ctx, serverID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to initialize server config"})
+		return
+	}
+	if err := h.DB.SetTranscriptRetentionDays(ctx, serverID, int32(payload.Days)); err != nil {
+		log.Printf("save retention config failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save retention config"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "saved"})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/Sush1sui/FNS_BOT/internal/bot"
 	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
 	"github.com/Sush1sui/FNS_BOT/internal/utils"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -31,9 +32,28 @@ func validateServerConfigForm(f FormData) utils.ValidationErrors {
 	utils.ValidateIntRange(f.AutoCloseSinceLastMessageDays, "AutoCloseSinceLastMessageDays", 0, 365, errs)
 	utils.ValidateIntRange(f.AutoCloseSinceLastMessageHours, "AutoCloseSinceLastMessageHours", 0, 23, errs)
 	utils.ValidateIntRange(f.AutoCloseSinceLastMessageMins, "AutoCloseSinceLastMessageMins", 0, 59, errs)
+	if f.TranscriptLogFormat != nil && *f.TranscriptLogFormat != "" && !transcript.IsTextFormat(*f.TranscriptLogFormat) {
+		errs["TranscriptLogFormat"] = "TranscriptLogFormat must be one of: txt, md"
+	}
 	return errs
 }
 
+// optionalBool and optionalText map a field left out of the
+// request to NULL, which keeps the stored value.
+func optionalBool(v *bool) pgtype.Bool {
+	if v == nil {
+		return pgtype.Bool{}
+	}
+	return pgtype.Bool{Bool: *v, Valid: true}
+}
+
+func optionalText(v *string) pgtype.Text {
+	if v == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *v, Valid: true}
+}
+
 func (h *Handler) HandleGetServerConfig(w http.ResponseWriter, r *http.Request) {
 	serverIDStr := r.PathValue("server_id")
 	showChannels := r.URL.Query().Get("show_channels") == "true"
@@ -98,6 +118,10 @@ func (h *Handler) HandleUpdateServerConfig(w http.ResponseWriter, r *http.Reques
 		TicketTranscriptCid: pgtype.Text{String: form.TicketTranscripts, Valid: form.TicketTranscripts != ""},
 		MaxTicketPerUser:    int32(form.MaxTicketsPerUser),
 		TicketPermissions:   permsJSON,
+		ModmailEnabled:      optionalBool(form.ModmailEnabled),
+		TranscriptLogHtml:   optionalBool(form.TranscriptLogHTML),
+		TranscriptDmOpener:  optionalBool(form.TranscriptDMOpener),
+		TranscriptLogFormat: optionalText(form.TranscriptLogFormat),
 	})
 	if err != nil {
 		log.Printf("failed to save config: %v", err)
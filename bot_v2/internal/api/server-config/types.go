@@ -48,4 +48,9 @@ type FormData struct {
 	AutoCloseSinceLastMessageDays  int    `json:"AutoCloseSinceLastMessageDays"`
 	AutoCloseSinceLastMessageHours int    `json:"AutoCloseSinceLastMessageHours"`
 	AutoCloseSinceLastMessageMins  int    `json:"AutoCloseSinceLastMessageMins"`
+	// The fields below keep their stored value when left out.
+	ModmailEnabled      *bool   `json:"ModmailEnabled"`
+	TranscriptLogHTML   *bool   `json:"TranscriptLogHTML"`
+	TranscriptDMOpener  *bool   `json:"TranscriptDMOpener"`
+	TranscriptLogFormat *string `json:"TranscriptLogFormat"`
 }
\ No newline at end of file
@@ -0,0 +1,145 @@
+package transcripts
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
+)
+
+// signedURLReuse is how long a presigned link is handed out again before a
+// new one is minted. Links last an hour, so a reused one still has at
+// least half an hour left, and repeat views keep the same URL for the
+// browser to cache against.
+const (
+	signedURLReuse   = 30 * time.Minute
+	maxCachedURLs    = 4096
+	entryBudgetShare = 8 // no entry may take more than 1/8 of the cache
+)
+
+// Cache keeps hot transcripts in memory as stored: decrypted but still
+// gzipped, so a cached transcript costs about what it does in storage.
+// Stored transcripts never change, so entries only leave by eviction. A
+// nil Cache caches nothing.
+type Cache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+	hits     int
+	misses   int
+
+	urls map[string]cachedURL
+}
+
+type cacheEntry struct {
+	key      string
+	data     []byte
+	encoding string
+}
+
+type cachedURL struct {
+	url      string
+	mintedAt time.Time
+}
+
+// NewCache returns a cache holding up to maxBytes of transcripts.
+func NewCache(maxBytes int) *Cache {
+	return &Cache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		urls:     make(map[string]cachedURL),
+	}
+}
+
+func (c *Cache) get(key string) ([]byte, string, bool) {
+	if c == nil {
+		return nil, "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if ok {
+		c.hits++
+		c.order.MoveToFront(el)
+		metrics.Add("transcript_cache_hits_total", "Transcript reads served from the API's memory cache.", 1)
+	} else {
+		c.misses++
+		metrics.Add("transcript_cache_misses_total", "Transcript reads that went to storage.", 1)
+	}
+	c.report()
+	if !ok {
+		return nil, "", false
+	}
+	entry := el.Value.(*cacheEntry)
+	return entry.data, entry.encoding, true
+}
+
+// put stores a transcript, evicting the least recently used ones to make
+// room. Transcripts too large for the budget are not cached.
+func (c *Cache) put(key string, data []byte, encoding string) {
+	if c == nil || len(data) > c.maxBytes/entryBudgetShare {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, data: data, encoding: encoding})
+	c.size += len(data)
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.data)
+	}
+	c.report()
+}
+
+// report publishes the cache's state. The caller holds c.mu.
+func (c *Cache) report() {
+	metrics.Set("transcript_cache_hit_ratio", "Share of transcript reads served from the memory cache.", float64(c.hits)/float64(max(c.hits+c.misses, 1)))
+	metrics.Set("transcript_cache_bytes", "Bytes of transcripts held in the memory cache.", float64(c.size))
+	metrics.Set("transcript_cache_entries", "Transcripts held in the memory cache.", float64(len(c.entries)))
+}
+
+// signedURL returns the link minted for key within signedURLReuse, or mints
+// one with mint and remembers it.
+func (c *Cache) signedURL(key string, mint func(key string) (string, error)) (string, error) {
+	if c == nil {
+		return mint(key)
+	}
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.urls[key]
+	c.mu.Unlock()
+	if ok && now.Sub(cached.mintedAt) < signedURLReuse {
+		return cached.url, nil
+	}
+
+	url, err := mint(key)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.urls) >= maxCachedURLs {
+		for k, u := range c.urls {
+			if now.Sub(u.mintedAt) >= signedURLReuse {
+				delete(c.urls, k)
+			}
+		}
+		if len(c.urls) >= maxCachedURLs {
+			clear(c.urls)
+		}
+	}
+	c.urls[key] = cachedURL{url: url, mintedAt: now}
+	return url, nil
+}
@@ -0,0 +1,135 @@
+package transcripts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
+)
+
+// maxBufferedTranscript is the largest stored transcript read into memory,
+// where it can be cached and served in ranges. Larger ones are streamed.
+const maxBufferedTranscript = 8 << 20
+
+// contentVersion is part of every content ETag. Bump it when a change to
+// the renderers changes what an unchanged transcript looks like.
+const contentVersion = "1"
+
+// storedTranscript opens the transcript at key as stored: decrypted but
+// still compressed, with its encoding. Hot transcripts come from the cache
+// as data. Transcripts over maxBufferedTranscript come back as a stream in
+// body instead, which the caller must close.
+func (h *Handler) storedTranscript(ctx context.Context, key string) (data []byte, body io.ReadCloser, encoding string, err error) {
+	if data, encoding, ok := h.Cache.get(key); ok {
+		return data, nil, encoding, nil
+	}
+
+	body, encoding, err = h.Storage.OpenTranscript(ctx, key)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	data, err = io.ReadAll(io.LimitReader(body, maxBufferedTranscript+1))
+	if err != nil {
+		body.Close()
+		return nil, nil, "", err
+	}
+	if len(data) > maxBufferedTranscript {
+		return nil, streamCloser{Reader: io.MultiReader(bytes.NewReader(data), body), Closer: body}, encoding, nil
+	}
+	body.Close()
+	h.Cache.put(key, data, encoding)
+	return data, nil, encoding, nil
+}
+
+// downloadTranscript is Storage.DownloadTranscript through the cache.
+func (h *Handler) downloadTranscript(ctx context.Context, key string) ([]byte, error) {
+	data, body, encoding, err := h.storedTranscript(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		body = io.NopCloser(bytes.NewReader(data))
+	}
+	defer body.Close()
+
+	decoded, err := storage.DecodeTranscript(body, encoding)
+	if err != nil {
+		return nil, err
+	}
+	defer decoded.Close()
+	return io.ReadAll(decoded)
+}
+
+type streamCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// setContentValidators sets the ETag, Last-Modified and Cache-Control
+// headers for one representation of a transcript, and answers 304 when the
+// client's copy is current. Stored transcripts never change, so the ETag
+// is derived from the storage key without reading the transcript. Clients
+// must still revalidate, since access to a transcript can be revoked.
+func setContentValidators(w http.ResponseWriter, r *http.Request, item db.Transcript, representation string) bool {
+	sum := sha256.Sum256([]byte(contentVersion + "\x00" + item.StorageKey + "\x00" + representation))
+	etag := `"` + hex.EncodeToString(sum[:12]) + `"`
+	modTime := time.Unix(item.ClosedAt, 0).UTC()
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if !notModified(r, etag, modTime) {
+		return false
+	}
+	metrics.Add("transcript_content_not_modified_total", "Transcript content requests answered with 304 Not Modified.", 1)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// none, as for a GET.
+func notModified(r *http.Request, etag string, modTime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modTime.Truncate(time.Second).After(since)
+}
+
+// serveContent sends data with Range support, counting the bytes served.
+// The validators must already be set.
+func serveContent(w http.ResponseWriter, r *http.Request, data []byte) {
+	cw := &servedWriter{ResponseWriter: w}
+	http.ServeContent(cw, r, "", time.Time{}, bytes.NewReader(data))
+	countServed(cw.n)
+}
+
+func countServed(n int64) {
+	metrics.Add("transcript_bytes_served_total", "Bytes of transcript content sent by the API.", float64(n))
+}
+
+type servedWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *servedWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,498 @@
+package transcripts
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxExportTranscripts = 5000
+	maxExportBytes       = 2 << 30
+	exportBatchSize      = 100
+	exportProgressEvery  = 25
+
+	// exportRetention is how long a finished archive can be downloaded
+	// before the sweeper deletes it. Archives do not count toward the
+	// storage quota, so they must not pile up.
+	exportRetention     = 7 * 24 * time.Hour
+	exportSweepInterval = time.Hour
+	exportSweepBatch    = 100
+)
+
+// exportSlots caps how many exports build at once across all servers.
+var exportSlots = make(chan struct{}, 2)
+
+var exportSweepOnce sync.Once
+
+var errExportTooLarge = errors.New("export exceeds the size limit")
+
+// exportFilters selects the transcripts of an export; fields match the
+// listing filters.
+type exportFilters struct {
+	UserID      string `json:"userId,omitempty"`
+	ClosedBy    string `json:"closedBy,omitempty"`
+	PanelID     int32  `json:"panelId,omitempty"`
+	From        int64  `json:"from,omitempty"`
+	To          int64  `json:"to,omitempty"`
+	MinMessages int32  `json:"minMessages,omitempty"`
+	Participant string `json:"participant,omitempty"`
+	CloseReason string `json:"closeReason,omitempty"`
+}
+
+type exportRequest struct {
+	exportFilters
+	IncludeHTML bool `json:"includeHtml"`
+}
+
+type ExportStatus struct {
+	ID          int32  `json:"id"`
+	Status      string `json:"status"`
+	Total       int32  `json:"total"`
+	Processed   int32  `json:"processed"`
+	Bytes       int64  `json:"bytes"`
+	IncludeHTML bool   `json:"includeHtml"`
+	Error       string `json:"error,omitempty"`
+	CreatedAt   int64  `json:"createdAt"`
+	FinishedAt  int64  `json:"finishedAt,omitempty"`
+	ExpiresAt   int64  `json:"expiresAt,omitempty"`
+}
+
+func (f exportFilters) validate() error {
+	for name, id := range map[string]string{"userId": f.UserID, "closedBy": f.ClosedBy, "participant": f.Participant} {
+		if _, err := snowflakeParam(id, name); err != nil {
+			return err
+		}
+	}
+	if f.PanelID < 0 || f.From < 0 || f.To < 0 || f.MinMessages < 0 {
+		return fmt.Errorf("filters must not be negative")
+	}
+	return nil
+}
+
+func (f exportFilters) params(serverID int64) db.ListTranscriptsByClosedAtAscParams {
+	return db.ListTranscriptsByClosedAtAscParams{
+		ServerConfigID: serverID,
+		UserID:         pgtype.Text{String: f.UserID, Valid: f.UserID != ""},
+		ClosedBy:       pgtype.Text{String: f.ClosedBy, Valid: f.ClosedBy != ""},
+		PanelID:        pgtype.Int4{Int32: f.PanelID, Valid: f.PanelID != 0},
+		ClosedFrom:     pgtype.Int8{Int64: f.From, Valid: f.From != 0},
+		ClosedTo:       pgtype.Int8{Int64: f.To, Valid: f.To != 0},
+		MinMessages:    pgtype.Int4{Int32: f.MinMessages, Valid: f.MinMessages != 0},
+		Participant:    pgtype.Text{String: f.Participant, Valid: f.Participant != ""},
+		CloseReason:    pgtype.Text{String: f.CloseReason, Valid: f.CloseReason != ""},
+		RowLimit:       exportBatchSize,
+	}
+}
+
+// HandleCreateExport queues a ZIP export of the transcripts matching the
+// request filters and returns the job.
+func (h *Handler) HandleCreateExport(w http.ResponseWriter, r *http.Request) {
+	serverID, err := parseServerID(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	running, err := h.DB.CountUnfinishedTranscriptExports(ctx, serverID)
+	if err != nil {
+		log.Printf("count exports failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create export"})
+		return
+	}
+	if running > 0 {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "an export is already in progress"})
+		return
+	}
+
+	p := req.params(serverID)
+	total, err := h.DB.CountTranscriptsFiltered(ctx, db.CountTranscriptsFilteredParams{
+		ServerConfigID: p.ServerConfigID,
+		UserID:         p.UserID,
+		ClosedBy:       p.ClosedBy,
+		PanelID:        p.PanelID,
+		ClosedFrom:     p.ClosedFrom,
+		ClosedTo:       p.ClosedTo,
+		MinMessages:    p.MinMessages,
+		Participant:    p.Participant,
+		CloseReason:    p.CloseReason,
+	})
+	if err != nil {
+		log.Printf("count transcripts failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create export"})
+		return
+	}
+	if total == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no transcripts match the filters"})
+		return
+	}
+	if total > maxExportTranscripts {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("export is limited to %d transcripts; narrow the filters", maxExportTranscripts)})
+		return
+	}
+
+	filters, _ := json.Marshal(req.exportFilters)
+	job, err := h.DB.CreateTranscriptExport(ctx, serverID, filters, req.IncludeHTML, int32(total), time.Now().Unix())
+	if errors.Is(err, db.ErrExportInProgress) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "an export is already in progress"})
+		return
+	}
+	if err != nil {
+		log.Printf("create export failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create export"})
+		return
+	}
+
+	go h.runExport(job)
+	writeJSON(w, http.StatusAccepted, formatExport(job))
+}
+
+func (h *Handler) HandleGetExport(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.loadExport(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, formatExport(job))
+}
+
+func (h *Handler) HandleDownloadExport(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.loadExport(w, r)
+	if !ok {
+		return
+	}
+	if job.Status == db.ExportStatusExpired {
+		writeJSON(w, http.StatusGone, map[string]string{"error": "export has expired"})
+		return
+	}
+	if job.Status != db.ExportStatusDone || !job.StorageKey.Valid {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "export is not ready"})
+		return
+	}
+
+	body, _, size, err := h.Storage.DownloadObject(r.Context(), job.StorageKey.String)
+	if err != nil {
+		log.Printf("download export failed: %v", err)
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "export archive not found"})
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"transcripts-%d.zip\"", job.ID))
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("stream export failed: %v", err)
+	}
+}
+
+func (h *Handler) loadExport(w http.ResponseWriter, r *http.Request) (db.TranscriptExport, bool) {
+	serverID, err := parseServerID(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return db.TranscriptExport{}, false
+	}
+	exportID, err := strconv.ParseInt(r.PathValue("export_id"), 10, 32)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid export id"})
+		return db.TranscriptExport{}, false
+	}
+
+	job, err := h.DB.GetTranscriptExport(context.Background(), int32(exportID), serverID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "export not found"})
+			return db.TranscriptExport{}, false
+		}
+		log.Printf("load export failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load export"})
+		return db.TranscriptExport{}, false
+	}
+	return job, true
+}
+
+// ResumeExports restarts jobs left queued or running by a previous process.
+func (h *Handler) ResumeExports() {
+	jobs, err := h.DB.ListUnfinishedTranscriptExports(context.Background())
+	if err != nil {
+		log.Printf("list unfinished exports failed: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		go h.runExport(job)
+	}
+}
+
+// StartExportSweeper deletes expired export archives right away and then
+// every exportSweepInterval. Safe to call more than once.
+func (h *Handler) StartExportSweeper() {
+	exportSweepOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(exportSweepInterval)
+			defer ticker.Stop()
+
+			h.sweepExpiredExports()
+			for range ticker.C {
+				h.sweepExpiredExports()
+			}
+		}()
+	})
+}
+
+func (h *Handler) sweepExpiredExports() {
+	ctx := context.Background()
+	expired := 0
+	for {
+		jobs, err := h.DB.ListExpiredTranscriptExports(ctx, time.Now().Unix(), exportSweepBatch)
+		if err != nil {
+			log.Printf("list expired exports failed: %v", err)
+			break
+		}
+
+		progress := false
+		for _, job := range jobs {
+			if job.StorageKey.Valid {
+				if err := h.Storage.DeleteObject(ctx, job.StorageKey.String); err != nil {
+					log.Printf("delete export %d archive failed: %v", job.ID, err)
+					continue
+				}
+			}
+			if err := h.DB.ExpireTranscriptExport(ctx, job.ID); err != nil {
+				log.Printf("expire export %d failed: %v", job.ID, err)
+				continue
+			}
+			progress = true
+			expired++
+		}
+		// Stop on a short batch, or when every item failed so a broken
+		// object does not spin the loop.
+		if len(jobs) < exportSweepBatch || !progress {
+			break
+		}
+	}
+
+	if expired > 0 {
+		log.Printf("Export sweep: %d archives deleted", expired)
+	}
+}
+
+func (h *Handler) runExport(job db.TranscriptExport) {
+	exportSlots <- struct{}{}
+	defer func() { <-exportSlots }()
+
+	ctx := context.Background()
+	if err := h.DB.StartTranscriptExport(ctx, job.ID); err != nil {
+		log.Printf("start export %d failed: %v", job.ID, err)
+		return
+	}
+
+	key := fmt.Sprintf("exports/%d/%d.zip", job.ServerConfigID, job.ID)
+	processed, size, err := h.buildExport(ctx, job, key)
+	if err != nil {
+		log.Printf("export %d failed: %v", job.ID, err)
+		// Drop whatever part of the archive made it to storage.
+		if err := h.Storage.DeleteObject(ctx, key); err != nil {
+			log.Printf("delete failed export %d archive failed: %v", job.ID, err)
+		}
+		if err := h.DB.FailTranscriptExport(ctx, job.ID, err.Error(), time.Now().Unix()); err != nil {
+			log.Printf("record export failure failed: %v", err)
+		}
+		return
+	}
+	now := time.Now()
+	if err := h.DB.FinishTranscriptExport(ctx, job.ID, processed, size, key, now.Unix(), now.Add(exportRetention).Unix()); err != nil {
+		log.Printf("finish export %d failed: %v", job.ID, err)
+	}
+}
+
+// buildExport streams the ZIP straight into storage through a pipe, so the
+// archive is never held in memory.
+func (h *Handler) buildExport(ctx context.Context, job db.TranscriptExport, key string) (int32, int64, error) {
+	var filters exportFilters
+	if err := json.Unmarshal(job.Filters, &filters); err != nil {
+		return 0, 0, fmt.Errorf("decode filters: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	uploaded := make(chan error, 1)
+	go func() {
+		err := h.Storage.UploadObject(ctx, key, "application/zip", pr, -1)
+		// Unblock the writer if the upload stopped reading early.
+		pr.CloseWithError(err)
+		uploaded <- err
+	}()
+
+	counter := &countingWriter{w: pw, limit: maxExportBytes}
+	zw := zip.NewWriter(counter)
+	processed, err := h.writeExportEntries(ctx, job, filters, zw, counter)
+	if err == nil {
+		err = zw.Close()
+	}
+	pw.CloseWithError(err)
+
+	if uploadErr := <-uploaded; err == nil {
+		err = uploadErr
+	}
+	return processed, counter.n, err
+}
+
+func (h *Handler) writeExportEntries(ctx context.Context, job db.TranscriptExport, filters exportFilters, zw *zip.Writer, counter *countingWriter) (int32, error) {
+	index := [][]string{{"id", "ticket_id", "user_id", "username", "opened_at", "closed_at", "closed_by", "close_reason", "panel_id", "total_messages", "json_file", "html_file"}}
+
+	params := filters.params(job.ServerConfigID)
+	var processed int32
+	for {
+		items, err := h.DB.ListTranscriptsByClosedAtAsc(ctx, params)
+		if err != nil {
+			return processed, err
+		}
+
+		for _, item := range items {
+			if processed >= maxExportTranscripts {
+				break
+			}
+			jsonName, htmlName, err := h.writeExportTranscript(ctx, zw, item, job.IncludeHTML)
+			if err != nil {
+				return processed, err
+			}
+			index = append(index, []string{
+				strconv.Itoa(int(item.ID)),
+				pgTextOrEmpty(item.TicketID),
+				pgTextOrEmpty(item.UserID),
+				pgTextOrEmpty(item.Username),
+				time.Unix(item.OpenedAt, 0).UTC().Format(time.RFC3339),
+				time.Unix(item.ClosedAt, 0).UTC().Format(time.RFC3339),
+				item.ClosedBy,
+				item.CloseReason,
+				strconv.Itoa(pgInt4OrZero(item.PanelID)),
+				strconv.Itoa(pgInt4OrZero(item.TotalMessages)),
+				jsonName,
+				htmlName,
+			})
+
+			processed++
+			if processed%exportProgressEvery == 0 {
+				if err := h.DB.UpdateTranscriptExportProgress(ctx, job.ID, processed, counter.n); err != nil {
+					log.Printf("update export progress failed: %v", err)
+				}
+			}
+		}
+
+		if len(items) < exportBatchSize || processed >= maxExportTranscripts {
+			break
+		}
+		last := items[len(items)-1]
+		params.CursorValue = pgtype.Int8{Int64: last.ClosedAt, Valid: true}
+		params.CursorID = pgtype.Int4{Int32: last.ID, Valid: true}
+	}
+
+	f, err := zw.Create("index.csv")
+	if err != nil {
+		return processed, err
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.WriteAll(index); err != nil {
+		return processed, err
+	}
+	return processed, nil
+}
+
+func (h *Handler) writeExportTranscript(ctx context.Context, zw *zip.Writer, item db.Transcript, includeHTML bool) (string, string, error) {
+	data, err := h.Storage.DownloadTranscript(ctx, item.StorageKey)
+	if err != nil {
+		return "", "", fmt.Errorf("download transcript %d: %w", item.ID, err)
+	}
+
+	jsonName := fmt.Sprintf("transcripts/%d.json", item.ID)
+	f, err := zw.Create(jsonName)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		return "", "", err
+	}
+	if !includeHTML {
+		return jsonName, "", nil
+	}
+
+	var payload transcript.Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", "", fmt.Errorf("decode transcript %d: %w", item.ID, err)
+	}
+	page, err := transcript.RenderHTML(payload, transcript.Options{})
+	if err != nil {
+		return "", "", fmt.Errorf("render transcript %d: %w", item.ID, err)
+	}
+	htmlName := fmt.Sprintf("transcripts/%d.html", item.ID)
+	f, err = zw.Create(htmlName)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := f.Write(page); err != nil {
+		return "", "", err
+	}
+	return jsonName, htmlName, nil
+}
+
+func formatExport(job db.TranscriptExport) ExportStatus {
+	status := ExportStatus{
+		ID:          job.ID,
+		Status:      job.Status,
+		Total:       job.Total,
+		Processed:   job.Processed,
+		Bytes:       job.Bytes,
+		IncludeHTML: job.IncludeHTML,
+		Error:       pgTextOrEmpty(job.Error),
+		CreatedAt:   job.CreatedAt,
+	}
+	if job.FinishedAt.Valid {
+		status.FinishedAt = job.FinishedAt.Int64
+	}
+	if job.ExpiresAt.Valid && job.Status == db.ExportStatusDone {
+		status.ExpiresAt = job.ExpiresAt.Int64
+	}
+	return status
+}
+
+// countingWriter tracks bytes written and fails once limit is passed.
+type countingWriter struct {
+	w     io.Writer
+	n     int64
+	limit int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.n+int64(len(p)) > c.limit {
+		return 0, errExportTooLarge
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
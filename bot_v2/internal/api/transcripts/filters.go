@@ -0,0 +1,203 @@
+package transcripts
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	sortClosedAt = "closed_at"
+	sortOpenedAt = "opened_at"
+	sortMessages = "messages"
+)
+
+// transcriptQuery is a parsed transcript listing request. Its params are
+// shared by every sort variant; the sort decides which query runs.
+type transcriptQuery struct {
+	params db.ListTranscriptsByClosedAtDescParams
+	sort   string
+	desc   bool
+}
+
+// parseTranscriptQuery reads the listing filters, sort and cursor from r.
+func parseTranscriptQuery(r *http.Request, serverID int64, limit int) (transcriptQuery, error) {
+	values := r.URL.Query()
+	q := transcriptQuery{sort: sortClosedAt, desc: true}
+	q.params.ServerConfigID = serverID
+	// One extra row tells whether another page follows.
+	q.params.RowLimit = int32(limit + 1)
+
+	var err error
+	if q.params.UserID, err = snowflakeParam(values.Get("user_id"), "user_id"); err != nil {
+		return q, err
+	}
+	if q.params.ClosedBy, err = snowflakeParam(values.Get("closed_by"), "closed_by"); err != nil {
+		return q, err
+	}
+	if q.params.Participant, err = snowflakeParam(values.Get("participant"), "participant"); err != nil {
+		return q, err
+	}
+	if v := values.Get("panel_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || id <= 0 {
+			return q, fmt.Errorf("invalid panel_id")
+		}
+		q.params.PanelID = pgtype.Int4{Int32: int32(id), Valid: true}
+	}
+	if q.params.ClosedFrom, err = unixParam(values.Get("from"), "from"); err != nil {
+		return q, err
+	}
+	if q.params.ClosedTo, err = unixParam(values.Get("to"), "to"); err != nil {
+		return q, err
+	}
+	if v := values.Get("min_messages"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid min_messages")
+		}
+		q.params.MinMessages = pgtype.Int4{Int32: int32(n), Valid: true}
+	}
+	if v := values.Get("close_reason"); v != "" {
+		q.params.CloseReason = pgtype.Text{String: v, Valid: true}
+	}
+
+	switch v := values.Get("sort"); v {
+	case "", sortClosedAt:
+	case sortOpenedAt, sortMessages:
+		q.sort = v
+	default:
+		return q, fmt.Errorf("invalid sort")
+	}
+	switch values.Get("order") {
+	case "", "desc":
+	case "asc":
+		q.desc = false
+	default:
+		return q, fmt.Errorf("invalid order")
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		sort, order, value, id, err := decodeCursor(v)
+		if err != nil {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		if sort != q.sort || order != q.order() {
+			return q, fmt.Errorf("cursor does not match sort and order")
+		}
+		q.params.CursorValue = pgtype.Int8{Int64: value, Valid: true}
+		q.params.CursorID = pgtype.Int4{Int32: id, Valid: true}
+	}
+	return q, nil
+}
+
+func (q transcriptQuery) countParams() db.CountTranscriptsFilteredParams {
+	p := q.params
+	return db.CountTranscriptsFilteredParams{
+		ServerConfigID: p.ServerConfigID,
+		UserID:         p.UserID,
+		ClosedBy:       p.ClosedBy,
+		PanelID:        p.PanelID,
+		ClosedFrom:     p.ClosedFrom,
+		ClosedTo:       p.ClosedTo,
+		MinMessages:    p.MinMessages,
+		Participant:    p.Participant,
+		CloseReason:    p.CloseReason,
+	}
+}
+
+func (h *Handler) listTranscripts(ctx context.Context, q transcriptQuery) ([]db.Transcript, error) {
+	p := q.params
+	switch {
+	case q.sort == sortOpenedAt && q.desc:
+		return h.DB.ListTranscriptsByOpenedAtDesc(ctx, db.ListTranscriptsByOpenedAtDescParams(p))
+	case q.sort == sortOpenedAt:
+		return h.DB.ListTranscriptsByOpenedAtAsc(ctx, db.ListTranscriptsByOpenedAtAscParams(p))
+	case q.sort == sortMessages && q.desc:
+		return h.DB.ListTranscriptsByMessagesDesc(ctx, db.ListTranscriptsByMessagesDescParams(p))
+	case q.sort == sortMessages:
+		return h.DB.ListTranscriptsByMessagesAsc(ctx, db.ListTranscriptsByMessagesAscParams(p))
+	case q.desc:
+		return h.DB.ListTranscriptsByClosedAtDesc(ctx, p)
+	default:
+		return h.DB.ListTranscriptsByClosedAtAsc(ctx, db.ListTranscriptsByClosedAtAscParams(p))
+	}
+}
+
+// page trims the extra row fetched past the limit and returns the cursor of
+// the following page, or "" when there is none.
+func (q transcriptQuery) page(items []db.Transcript, limit int) ([]db.Transcript, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	last := items[len(items)-1]
+	value := last.ClosedAt
+	switch q.sort {
+	case sortOpenedAt:
+		value = last.OpenedAt
+	case sortMessages:
+		value = int64(last.TotalMessages.Int32)
+	}
+	return items, encodeCursor(q.sort, q.order(), value, last.ID)
+}
+
+func (q transcriptQuery) order() string {
+	if q.desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// encodeCursor records the sort and order with the position, since a value
+// from one sort means nothing in another.
+func encodeCursor(sort, order string, value int64, id int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s.%s.%d.%d", sort, order, value, id)))
+}
+
+func decodeCursor(cursor string) (string, string, int64, int32, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", 0, 0, err
+	}
+	parts := strings.Split(string(raw), ".")
+	if len(parts) != 4 {
+		return "", "", 0, 0, fmt.Errorf("malformed cursor")
+	}
+	value, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", "", 0, 0, err
+	}
+	id, err := strconv.ParseInt(parts[3], 10, 32)
+	if err != nil {
+		return "", "", 0, 0, err
+	}
+	return parts[0], parts[1], value, int32(id), nil
+}
+
+func snowflakeParam(value, name string) (pgtype.Text, error) {
+	if value == "" {
+		return pgtype.Text{}, nil
+	}
+	if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+		return pgtype.Text{}, fmt.Errorf("invalid %s", name)
+	}
+	return pgtype.Text{String: value, Valid: true}, nil
+}
+
+func unixParam(value, name string) (pgtype.Int8, error) {
+	if value == "" {
+		return pgtype.Int8{}, nil
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || unix < 0 {
+		return pgtype.Int8{}, fmt.Errorf("invalid %s", name)
+	}
+	return pgtype.Int8{Int64: unix, Valid: true}, nil
+}
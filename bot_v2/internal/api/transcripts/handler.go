@@ -1,13 +1,20 @@
 package transcripts
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -20,6 +27,8 @@ func writeJSON(w http.ResponseWriter, status int, data any) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// HandleListTranscripts lists transcripts with optional filters and sorting.
+// Pages are keyset based: pass pagination.nextCursor back as ?cursor=.
 func (h *Handler) HandleListTranscripts(w http.ResponseWriter, r *http.Request) {
 	serverID, err := parseServerID(r)
 	if err != nil {
@@ -27,38 +36,35 @@ func (h *Handler) HandleListTranscripts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	page, limit := parsePagination(r)
+	_, limit := parsePagination(r)
+	query, err := parseTranscriptQuery(r, serverID, limit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
 
-	items, err := h.DB.GetTranscriptsByServer(context.Background(), db.GetTranscriptsByServerParams{
-		ServerConfigID: serverID,
-		Limit:          int32(limit),
-		Offset:         int32((page - 1) * limit),
-	})
+	items, err := h.listTranscripts(context.Background(), query)
 	if err != nil {
 		log.Printf("load transcripts failed: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcripts"})
 		return
 	}
 
-	total, err := h.DB.CountTranscriptsByServer(context.Background(), serverID)
+	items, nextCursor := query.page(items, limit)
+
+	total, err := h.DB.CountTranscriptsFiltered(context.Background(), query.countParams())
 	if err != nil {
 		log.Printf("count transcripts failed: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to count transcripts"})
 		return
 	}
 
-	pages := int(total) / limit
-	if int(total)%limit != 0 {
-		pages++
-	}
-
 	writeJSON(w, http.StatusOK, map[string]any{
 		"transcripts": formatTranscriptList(items),
-		"pagination": map[string]int{
-			"page":  page,
-			"limit": limit,
-			"total": int(total),
-			"pages": pages,
+		"pagination": map[string]any{
+			"limit":      limit,
+			"total":      int(total),
+			"nextCursor": nextCursor,
 		},
 	})
 }
@@ -84,11 +90,19 @@ func (h *Handler) HandleGetTranscript(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	presignedURL, err := h.Storage.GeneratePresignedURL(item.StorageKey)
-	if err != nil {
-		log.Printf("generate presigned url failed: %v", err)
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to generate download link"})
-		return
+	// Encrypted blobs are useless to the browser, and some backends have no
+	// direct links; the content endpoint serves those instead.
+	var presignedURL *string
+	if !h.Storage.EncryptsTranscripts() {
+		url, err := h.Cache.signedURL(item.StorageKey, h.Storage.GeneratePresignedURL)
+		if err != nil && !errors.Is(err, storage.ErrSignedURLUnsupported) {
+			log.Printf("generate presigned url failed: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to generate download link"})
+			return
+		}
+		if err == nil {
+			presignedURL = &url
+		}
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -97,12 +111,19 @@ func (h *Handler) HandleGetTranscript(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HandleGetTranscriptContent serves the stored JSON, or a plain-text or
+// Markdown rendering when the Accept header or ?format= asks for one.
 func (h *Handler) HandleGetTranscriptContent(w http.ResponseWriter, r *http.Request) {
 	serverID, transcriptID, err := parseIDs(r)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
 		return
 	}
+	format, ok := negotiateFormat(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported format"})
+		return
+	}
 
 	item, err := h.DB.GetTranscriptByID(context.Background(), db.GetTranscriptByIDParams{
 		ID:             transcriptID,
@@ -118,16 +139,239 @@ func (h *Handler) HandleGetTranscriptContent(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	data, err := h.Storage.DownloadTranscript(context.Background(), item.StorageKey)
+	// The stored gzip is sent as it is to clients that accept it, so that
+	// is a representation of its own.
+	gzipped := format == "" && acceptsEncoding(r, storage.EncodingGzip)
+	representation := format
+	if gzipped {
+		representation = storage.EncodingGzip
+	}
+	w.Header().Set("Vary", "Accept, Accept-Encoding")
+	if setContentValidators(w, r, item, representation) {
+		return
+	}
+
+	if format != "" {
+		payload, ok := h.loadPayload(w, r, item)
+		if !ok {
+			return
+		}
+		writeTextTranscript(w, r, payload, format, item.ID, transcript.Options{
+			AttachmentURL: func(a transcript.Attachment) string {
+				return fmt.Sprintf("/api/servers/%d/transcripts/%d/attachments/%s", serverID, item.ID, a.ID)
+			},
+		})
+		return
+	}
+
+	data, body, encoding, err := h.storedTranscript(r.Context(), item.StorageKey)
 	if err != nil {
 		log.Printf("download transcript failed: %v", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to download transcript"})
 		return
 	}
-
+	if body != nil {
+		defer body.Close()
+	}
 	w.Header().Set("Content-Type", "application/json")
+	if gzipped && encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+
+	// Large transcripts stream straight from storage unless a range is
+	// asked for, which needs the whole representation in memory.
+	if body != nil && r.Header.Get("Range") == "" {
+		var src io.Reader = body
+		if !gzipped || encoding == "" {
+			decoded, err := storage.DecodeTranscript(body, encoding)
+			if err != nil {
+				log.Printf("decode transcript failed: %v", err)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to download transcript"})
+				return
+			}
+			defer decoded.Close()
+			src = decoded
+		}
+		w.WriteHeader(http.StatusOK)
+		n, err := io.Copy(w, src)
+		countServed(n)
+		if err != nil {
+			log.Printf("stream transcript failed: %v", err)
+		}
+		return
+	}
+
+	if body != nil {
+		rest, err := io.ReadAll(body)
+		if err != nil {
+			log.Printf("download transcript failed: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to download transcript"})
+			return
+		}
+		data = rest
+	}
+	if !gzipped || encoding == "" {
+		decoded, err := storage.DecodeTranscript(bytes.NewReader(data), encoding)
+		if err == nil {
+			data, err = io.ReadAll(decoded)
+			decoded.Close()
+		}
+		if err != nil {
+			log.Printf("decode transcript failed: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to download transcript"})
+			return
+		}
+	}
+	serveContent(w, r, data)
+}
+
+// acceptsEncoding reports whether the request's Accept-Encoding allows
+// encoding with a non-zero quality.
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) && strings.TrimSpace(name) != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// transcriptHTMLPolicy lets the rendered page use its inline stylesheet and
+// Discord CDN images while still running no scripts.
+const transcriptHTMLPolicy = "default-src 'none'; style-src 'unsafe-inline'; img-src 'self' https: data:; sandbox allow-popups allow-popups-to-escape-sandbox"
+
+func (h *Handler) HandleGetTranscriptHTML(w http.ResponseWriter, r *http.Request) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+
+	item, err := h.DB.GetTranscriptByID(context.Background(), db.GetTranscriptByIDParams{
+		ID:             transcriptID,
+		ServerConfigID: serverID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transcript not found"})
+			return
+		}
+		log.Printf("load transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcript"})
+		return
+	}
+
+	data, err := h.downloadTranscript(r.Context(), item.StorageKey)
+	if err != nil {
+		log.Printf("download transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to download transcript"})
+		return
+	}
+
+	var payload transcript.Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		log.Printf("decode transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to decode transcript"})
+		return
+	}
+
+	page, err := transcript.RenderHTML(payload, transcript.Options{
+		AttachmentURL: func(a transcript.Attachment) string {
+			return fmt.Sprintf("/api/servers/%d/transcripts/%d/attachments/%s", serverID, item.ID, a.ID)
+		},
+	})
+	if err != nil {
+		log.Printf("render transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to render transcript"})
+		return
+	}
+
+	disposition := "inline"
+	if r.URL.Query().Get("download") == "true" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=\"transcript-%d.html\"", disposition, item.ID))
+	w.Header().Set("Content-Security-Policy", transcriptHTMLPolicy)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(page)
+}
+
+// attachmentPolicy stops archived files from running as pages on the API origin.
+const attachmentPolicy = "default-src 'none'; sandbox"
+
+func (h *Handler) HandleGetTranscriptAttachment(w http.ResponseWriter, r *http.Request) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+	attachmentID := r.PathValue("attachment_id")
+	if _, err := strconv.ParseUint(attachmentID, 10, 64); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid attachment id"})
+		return
+	}
+
+	item, err := h.DB.GetTranscriptByID(context.Background(), db.GetTranscriptByIDParams{
+		ID:             transcriptID,
+		ServerConfigID: serverID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transcript not found"})
+			return
+		}
+		log.Printf("load transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcript"})
+		return
+	}
+
+	h.streamAttachment(w, r, item, attachmentID)
+}
+
+// streamAttachment sends an archived attachment of item to the client.
+func (h *Handler) streamAttachment(w http.ResponseWriter, r *http.Request, item db.Transcript, attachmentID string) {
+	body, contentType, size, err := h.Storage.DownloadObject(r.Context(), transcript.AttachmentKey(item.StorageKey, attachmentID))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "attachment not found"})
+		return
+	}
+	defer body.Close()
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	disposition := "attachment"
+	if isInlineMedia(contentType) {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("Content-Security-Policy", attachmentPolicy)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
 	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(data)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("stream attachment failed: %v", err)
+	}
+}
+
+func isInlineMedia(contentType string) bool {
+	for _, prefix := range []string{"image/", "video/", "audio/"} {
+		if strings.HasPrefix(contentType, prefix) && !strings.HasPrefix(contentType, "image/svg") {
+			return true
+		}
+	}
+	return false
 }
 
 type TranscriptListItem struct {
@@ -141,6 +385,9 @@ type TranscriptListItem struct {
 	TotalMessages  int    `json:"totalMessages"`
 	TotalAttachments int  `json:"totalAttachments"`
 	TotalEmbeds    int    `json:"totalEmbeds"`
+	CloseReason    string `json:"closeReason"`
+	PanelID        int    `json:"panelId,omitempty"`
+	LegalHold      bool   `json:"legalHold"`
 }
 
 type TranscriptDetail struct {
@@ -155,6 +402,9 @@ type TranscriptDetail struct {
 	TotalMessages  int    `json:"totalMessages"`
 	TotalAttachments int  `json:"totalAttachments"`
 	TotalEmbeds    int    `json:"totalEmbeds"`
+	CloseReason    string `json:"closeReason"`
+	PanelID        int    `json:"panelId,omitempty"`
+	LegalHold      bool   `json:"legalHold"`
 }
 
 func formatTranscriptList(items []db.Transcript) []TranscriptListItem {
@@ -171,6 +421,9 @@ func formatTranscriptList(items []db.Transcript) []TranscriptListItem {
 			TotalMessages:  pgInt4OrZero(t.TotalMessages),
 			TotalAttachments: pgInt4OrZero(t.TotalAttachments),
 			TotalEmbeds:    pgInt4OrZero(t.TotalEmbeds),
+			CloseReason:    t.CloseReason,
+			PanelID:        pgInt4OrZero(t.PanelID),
+			LegalHold:      t.LegalHold,
 		}
 	}
 	return result
@@ -189,6 +442,9 @@ func formatTranscript(t db.Transcript) TranscriptDetail {
 		TotalMessages:  pgInt4OrZero(t.TotalMessages),
 		TotalAttachments: pgInt4OrZero(t.TotalAttachments),
 		TotalEmbeds:    pgInt4OrZero(t.TotalEmbeds),
+		CloseReason:    t.CloseReason,
+		PanelID:        pgInt4OrZero(t.PanelID),
+		LegalHold:      t.LegalHold,
 	}
 }
 
@@ -0,0 +1,207 @@
+package transcripts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5"
+)
+
+// Handlers in this file serve the user who opened a ticket rather than a
+// server's staff. They take the caller's Discord ID and only ever return
+// transcripts whose user_id matches it, with staff notes removed.
+
+type OpenerTranscriptItem struct {
+	ID          int32  `json:"id"`
+	ServerID    string `json:"serverId"`
+	TicketID    string `json:"ticketId"`
+	OpenedAt    int64  `json:"openedAt"`
+	ClosedAt    int64  `json:"closedAt"`
+	ClosedBy    string `json:"closedBy"`
+	CloseReason string `json:"closeReason"`
+}
+
+func formatOpenerTranscript(t db.Transcript) OpenerTranscriptItem {
+	return OpenerTranscriptItem{
+		ID:          t.ID,
+		ServerID:    strconv.FormatInt(t.ServerConfigID, 10),
+		TicketID:    pgTextOrEmpty(t.TicketID),
+		OpenedAt:    t.OpenedAt,
+		ClosedAt:    t.ClosedAt,
+		ClosedBy:    t.ClosedBy,
+		CloseReason: t.CloseReason,
+	}
+}
+
+func (h *Handler) HandleListMyTranscripts(w http.ResponseWriter, r *http.Request, userID string) {
+	page, limit := parsePagination(r)
+	ctx := context.Background()
+
+	rows, err := h.DB.ListTranscriptsByOpener(ctx, userID, int32(limit), int32((page-1)*limit))
+	if err != nil {
+		log.Printf("list opener transcripts failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcripts"})
+		return
+	}
+	total, err := h.DB.CountTranscriptsByOpener(ctx, userID)
+	if err != nil {
+		log.Printf("count opener transcripts failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcripts"})
+		return
+	}
+
+	items := make([]OpenerTranscriptItem, len(rows))
+	for i, row := range rows {
+		items[i] = formatOpenerTranscript(row)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"transcripts": items,
+		"page":        page,
+		"limit":       limit,
+		"total":       int(total),
+	})
+}
+
+func (h *Handler) HandleGetMyTranscript(w http.ResponseWriter, r *http.Request, userID string) {
+	item, ok := h.loadOpenerTranscript(w, r, userID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transcript": formatOpenerTranscript(item)})
+}
+
+func (h *Handler) HandleGetMyTranscriptContent(w http.ResponseWriter, r *http.Request, userID string) {
+	format, ok := negotiateFormat(r)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported format"})
+		return
+	}
+	item, ok := h.loadOpenerTranscript(w, r, userID)
+	if !ok {
+		return
+	}
+	w.Header().Set("Vary", "Accept")
+	if setContentValidators(w, r, item, "opener:"+format) {
+		return
+	}
+	payload, ok := h.loadRedactedPayload(w, r, item)
+	if !ok {
+		return
+	}
+	if format != "" {
+		writeTextTranscript(w, r, payload, format, item.ID, transcript.Options{
+			AttachmentURL: func(a transcript.Attachment) string {
+				return fmt.Sprintf("/api/me/transcripts/%d/attachments/%s", item.ID, a.ID)
+			},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
+
+func (h *Handler) HandleGetMyTranscriptHTML(w http.ResponseWriter, r *http.Request, userID string) {
+	item, ok := h.loadOpenerTranscript(w, r, userID)
+	if !ok {
+		return
+	}
+	payload, ok := h.loadRedactedPayload(w, r, item)
+	if !ok {
+		return
+	}
+
+	page, err := transcript.RenderHTML(payload, transcript.Options{
+		AttachmentURL: func(a transcript.Attachment) string {
+			return fmt.Sprintf("/api/me/transcripts/%d/attachments/%s", item.ID, a.ID)
+		},
+	})
+	if err != nil {
+		log.Printf("render transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to render transcript"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"transcript-%d.html\"", item.ID))
+	w.Header().Set("Content-Security-Policy", transcriptHTMLPolicy)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(page)
+}
+
+// HandleGetMyTranscriptAttachment serves an archived attachment, refusing
+// attachments that were posted in staff notes.
+func (h *Handler) HandleGetMyTranscriptAttachment(w http.ResponseWriter, r *http.Request, userID string) {
+	attachmentID := r.PathValue("attachment_id")
+	if _, err := strconv.ParseUint(attachmentID, 10, 64); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid attachment id"})
+		return
+	}
+
+	item, ok := h.loadOpenerTranscript(w, r, userID)
+	if !ok {
+		return
+	}
+	payload, ok := h.loadRedactedPayload(w, r, item)
+	if !ok {
+		return
+	}
+	if !payload.HasAttachment(attachmentID) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "attachment not found"})
+		return
+	}
+
+	h.streamAttachment(w, r, item, attachmentID)
+}
+
+func (h *Handler) loadOpenerTranscript(w http.ResponseWriter, r *http.Request, userID string) (db.Transcript, bool) {
+	transcriptID, err := strconv.ParseInt(r.PathValue("transcript_id"), 10, 32)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid transcript id"})
+		return db.Transcript{}, false
+	}
+
+	item, err := h.DB.GetTranscriptForOpener(context.Background(), int32(transcriptID), userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transcript not found"})
+			return db.Transcript{}, false
+		}
+		log.Printf("load transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcript"})
+		return db.Transcript{}, false
+	}
+	return item, true
+}
+
+// loadRedactedPayload downloads item's transcript with staff notes removed.
+func (h *Handler) loadRedactedPayload(w http.ResponseWriter, r *http.Request, item db.Transcript) (transcript.Payload, bool) {
+	payload, ok := h.loadPayload(w, r, item)
+	if !ok {
+		return transcript.Payload{}, false
+	}
+	return transcript.ForOpener(payload), true
+}
+
+// loadPayload downloads and decodes item's transcript.
+func (h *Handler) loadPayload(w http.ResponseWriter, r *http.Request, item db.Transcript) (transcript.Payload, bool) {
+	data, err := h.downloadTranscript(r.Context(), item.StorageKey)
+	if err != nil {
+		log.Printf("download transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to download transcript"})
+		return transcript.Payload{}, false
+	}
+
+	var payload transcript.Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		log.Printf("decode transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to decode transcript"})
+		return transcript.Payload{}, false
+	}
+	return payload, true
+}
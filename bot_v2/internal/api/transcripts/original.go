@@ -0,0 +1,53 @@
+package transcripts
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5"
+)
+
+// HandleGetTranscriptOriginal serves the unredacted copy of a transcript,
+// kept when the guild's redaction settings ask for it. The route is limited
+// to server admins.
+func (h *Handler) HandleGetTranscriptOriginal(w http.ResponseWriter, r *http.Request) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+
+	item, err := h.DB.GetTranscriptByID(context.Background(), db.GetTranscriptByIDParams{
+		ID:             transcriptID,
+		ServerConfigID: serverID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transcript not found"})
+			return
+		}
+		log.Printf("load transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcript"})
+		return
+	}
+
+	data, err := h.Storage.DownloadTranscript(r.Context(), transcript.OriginalKey(item.StorageKey))
+	if err != nil {
+		if storage.IsNotFound(err) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "original not kept"})
+			return
+		}
+		log.Printf("download transcript original failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to download transcript"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,86 @@
+package transcripts
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type legalHoldPayload struct {
+	LegalHold bool `json:"legalHold"`
+}
+
+type PurgeLogItem struct {
+	TranscriptID   int32  `json:"transcriptId"`
+	TicketID       string `json:"ticketId"`
+	UserID         string `json:"userId"`
+	ClosedAt       int64  `json:"closedAt"`
+	ObjectsDeleted int32  `json:"objectsDeleted"`
+	PurgedAt       int64  `json:"purgedAt"`
+}
+
+// HandleSetLegalHold places or lifts a legal hold, which keeps a transcript
+// out of retention purges.
+func (h *Handler) HandleSetLegalHold(w http.ResponseWriter, r *http.Request) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+
+	var payload legalHoldPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+
+	updated, err := h.DB.SetTranscriptLegalHold(context.Background(), transcriptID, serverID, payload.LegalHold)
+	if err != nil {
+		log.Printf("set legal hold failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update legal hold"})
+		return
+	}
+	if !updated {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "transcript not found or already being purged"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"legalHold": payload.LegalHold})
+}
+
+// HandleListPurges returns the audit log of transcripts removed by the
+// retention policy, newest first.
+func (h *Handler) HandleListPurges(w http.ResponseWriter, r *http.Request) {
+	serverID, err := parseServerID(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	page, limit := parsePagination(r)
+	rows, err := h.DB.GetTranscriptPurgeLog(context.Background(), serverID, int32(limit), int32((page-1)*limit))
+	if err != nil {
+		log.Printf("load purge log failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load purge log"})
+		return
+	}
+
+	items := make([]PurgeLogItem, len(rows))
+	for i, row := range rows {
+		items[i] = PurgeLogItem{
+			TranscriptID:   row.TranscriptID,
+			TicketID:       pgTextOrEmpty(row.TicketID),
+			UserID:         pgTextOrEmpty(row.UserID),
+			ClosedAt:       row.ClosedAt,
+			ObjectsDeleted: row.ObjectsDeleted,
+			PurgedAt:       row.PurgedAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"purges": items,
+		"page":   page,
+		"limit":  limit,
+	})
+}
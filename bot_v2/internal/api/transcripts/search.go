@@ -0,0 +1,92 @@
+package transcripts
+
+import (
+	"context"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+)
+
+const maxSearchQueryLength = 200
+
+type TranscriptSearchItem struct {
+	TranscriptListItem
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// HandleSearchTranscripts runs a web-style full-text query (quoted phrases,
+// OR, -exclusions) over the server's transcripts, best match first.
+func (h *Handler) HandleSearchTranscripts(w http.ResponseWriter, r *http.Request) {
+	serverID, err := parseServerID(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "q is required"})
+		return
+	}
+	if len(query) > maxSearchQueryLength {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "q is too long"})
+		return
+	}
+
+	page, limit := parsePagination(r)
+
+	results, err := h.DB.SearchTranscripts(context.Background(), db.SearchTranscriptsParams{
+		ServerConfigID: serverID,
+		Query:          query,
+		Limit:          int32(limit),
+		Offset:         int32((page - 1) * limit),
+	})
+	if err != nil {
+		log.Printf("search transcripts failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to search transcripts"})
+		return
+	}
+
+	total, err := h.DB.CountTranscriptSearch(context.Background(), serverID, query)
+	if err != nil {
+		log.Printf("count transcript search failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to search transcripts"})
+		return
+	}
+
+	pages := int(total) / limit
+	if int(total)%limit != 0 {
+		pages++
+	}
+
+	items := make([]TranscriptSearchItem, len(results))
+	for i, result := range results {
+		items[i] = TranscriptSearchItem{
+			TranscriptListItem: formatTranscriptList([]db.Transcript{result.Transcript})[0],
+			Rank:               result.Rank,
+			Snippet:            highlightSnippet(result.Snippet),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": items,
+		"pagination": map[string]int{
+			"page":  page,
+			"limit": limit,
+			"total": int(total),
+			"pages": pages,
+		},
+	})
+}
+
+// highlightSnippet escapes a search snippet and turns its highlight markers
+// into <mark> tags, the only markup the result contains.
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, db.SearchHighlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, db.SearchHighlightEnd, "</mark>")
+}
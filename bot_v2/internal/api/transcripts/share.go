@@ -0,0 +1,344 @@
+package transcripts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultShareHours = 72
+	maxShareHours     = 30 * 24
+)
+
+// Share tokens are the share ID and expiry signed with ShareKey, so forged
+// or tampered links are rejected before touching the database. Revocation
+// and view counting live in the transcript_share row.
+const shareTokenPayloadSize = 12
+
+type createSharePayload struct {
+	ExpiresInHours int `json:"expiresInHours"`
+}
+
+type ShareItem struct {
+	ID           int32  `json:"id"`
+	TranscriptID int32  `json:"transcriptId"`
+	CreatedBy    string `json:"createdBy"`
+	CreatedAt    int64  `json:"createdAt"`
+	ExpiresAt    int64  `json:"expiresAt"`
+	RevokedAt    int64  `json:"revokedAt,omitempty"`
+	RevokedBy    string `json:"revokedBy,omitempty"`
+	ViewCount    int32  `json:"viewCount"`
+	LastViewedAt int64  `json:"lastViewedAt,omitempty"`
+	Active       bool   `json:"active"`
+	// Path is the public link relative to the API origin; set while active.
+	Path string `json:"path,omitempty"`
+}
+
+type ShareEventItem struct {
+	ID        int64  `json:"id"`
+	Event     string `json:"event"`
+	Actor     string `json:"actor,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+func (h *Handler) formatShare(s db.TranscriptShare, now int64) ShareItem {
+	item := ShareItem{
+		ID:           s.ID,
+		TranscriptID: s.TranscriptID,
+		CreatedBy:    s.CreatedBy,
+		CreatedAt:    s.CreatedAt,
+		ExpiresAt:    s.ExpiresAt,
+		RevokedAt:    pgInt8OrZero(s.RevokedAt),
+		RevokedBy:    pgTextOrEmpty(s.RevokedBy),
+		ViewCount:    s.ViewCount,
+		LastViewedAt: pgInt8OrZero(s.LastViewedAt),
+		Active:       !s.RevokedAt.Valid && s.ExpiresAt > now,
+	}
+	if item.Active {
+		item.Path = "/api/share/" + h.shareToken(s.ID, s.ExpiresAt)
+	}
+	return item
+}
+
+// HandleCreateShare creates an expiring public link to one transcript.
+func (h *Handler) HandleCreateShare(w http.ResponseWriter, r *http.Request, discordID string) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+	if len(h.ShareKey) == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "sharing is not configured"})
+		return
+	}
+
+	payload := createSharePayload{ExpiresInHours: defaultShareHours}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+		return
+	}
+	if payload.ExpiresInHours < 1 || payload.ExpiresInHours > maxShareHours {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("expiresInHours must be between 1 and %d", maxShareHours)})
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(payload.ExpiresInHours) * time.Hour).Unix()
+	share, err := h.DB.CreateTranscriptShare(context.Background(), transcriptID, serverID, discordID, now.Unix(), expiresAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transcript not found"})
+			return
+		}
+		log.Printf("create transcript share failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create share"})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"share": h.formatShare(share, now.Unix())})
+}
+
+func (h *Handler) HandleListShares(w http.ResponseWriter, r *http.Request) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+
+	rows, err := h.DB.ListTranscriptShares(context.Background(), transcriptID, serverID)
+	if err != nil {
+		log.Printf("list transcript shares failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load shares"})
+		return
+	}
+
+	now := time.Now().Unix()
+	items := make([]ShareItem, len(rows))
+	for i, row := range rows {
+		items[i] = h.formatShare(row, now)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"shares": items})
+}
+
+func (h *Handler) HandleRevokeShare(w http.ResponseWriter, r *http.Request, discordID string) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+	shareID, err := strconv.ParseInt(r.PathValue("share_id"), 10, 32)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid share id"})
+		return
+	}
+
+	revoked, err := h.DB.RevokeTranscriptShare(context.Background(), int32(shareID), transcriptID, serverID, discordID, time.Now().Unix())
+	if err != nil {
+		log.Printf("revoke transcript share failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to revoke share"})
+		return
+	}
+	if !revoked {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "share not found or already revoked"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"revoked": true})
+}
+
+// HandleListShareEvents returns the audit trail of one share, newest first.
+func (h *Handler) HandleListShareEvents(w http.ResponseWriter, r *http.Request) {
+	serverID, transcriptID, err := parseIDs(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ids"})
+		return
+	}
+	shareID, err := strconv.ParseInt(r.PathValue("share_id"), 10, 32)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid share id"})
+		return
+	}
+
+	page, limit := parsePagination(r)
+	rows, err := h.DB.ListTranscriptShareEvents(context.Background(), int32(shareID), transcriptID, serverID, int32(limit), int32((page-1)*limit))
+	if err != nil {
+		log.Printf("list share events failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load share events"})
+		return
+	}
+
+	items := make([]ShareEventItem, len(rows))
+	for i, row := range rows {
+		items[i] = ShareEventItem{
+			ID:        row.ID,
+			Event:     row.Event,
+			Actor:     pgTextOrEmpty(row.Actor),
+			IP:        pgTextOrEmpty(row.IP),
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"events": items,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// HandleViewShare is the public, read-only page behind a share link. Every
+// load counts as a view. Staff notes are hidden as for the ticket opener.
+func (h *Handler) HandleViewShare(w http.ResponseWriter, r *http.Request, clientIP string) {
+	token := r.PathValue("token")
+	shareID, ok := h.parseShareToken(token)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "link is invalid or expired"})
+		return
+	}
+
+	share, err := h.DB.RecordTranscriptShareView(context.Background(), shareID, clientIP, time.Now().Unix())
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "link is invalid or expired"})
+			return
+		}
+		log.Printf("record share view failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcript"})
+		return
+	}
+
+	item, payload, ok := h.loadSharedTranscript(w, r, share)
+	if !ok {
+		return
+	}
+
+	page, err := transcript.RenderHTML(payload, transcript.Options{
+		AttachmentURL: func(a transcript.Attachment) string {
+			return fmt.Sprintf("/api/share/%s/attachments/%s", token, a.ID)
+		},
+	})
+	if err != nil {
+		log.Printf("render transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to render transcript"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"transcript-%d.html\"", item.ID))
+	w.Header().Set("Content-Security-Policy", transcriptHTMLPolicy)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(page)
+}
+
+// HandleGetShareAttachment serves an attachment of a shared transcript.
+// Attachment loads do not count as views.
+func (h *Handler) HandleGetShareAttachment(w http.ResponseWriter, r *http.Request) {
+	shareID, ok := h.parseShareToken(r.PathValue("token"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "link is invalid or expired"})
+		return
+	}
+	attachmentID := r.PathValue("attachment_id")
+	if _, err := strconv.ParseUint(attachmentID, 10, 64); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid attachment id"})
+		return
+	}
+
+	share, err := h.DB.GetActiveTranscriptShare(context.Background(), shareID, time.Now().Unix())
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "link is invalid or expired"})
+			return
+		}
+		log.Printf("load share failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load attachment"})
+		return
+	}
+
+	item, payload, ok := h.loadSharedTranscript(w, r, share)
+	if !ok {
+		return
+	}
+	if !payload.HasAttachment(attachmentID) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "attachment not found"})
+		return
+	}
+
+	h.streamAttachment(w, r, item, attachmentID)
+}
+
+func (h *Handler) loadSharedTranscript(w http.ResponseWriter, r *http.Request, share db.TranscriptShare) (db.Transcript, transcript.Payload, bool) {
+	item, err := h.DB.GetTranscriptByID(context.Background(), db.GetTranscriptByIDParams{
+		ID:             share.TranscriptID,
+		ServerConfigID: share.ServerConfigID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transcript not found"})
+			return db.Transcript{}, transcript.Payload{}, false
+		}
+		log.Printf("load transcript failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load transcript"})
+		return db.Transcript{}, transcript.Payload{}, false
+	}
+
+	payload, ok := h.loadRedactedPayload(w, r, item)
+	return item, payload, ok
+}
+
+func (h *Handler) shareToken(id int32, expiresAt int64) string {
+	payload := make([]byte, shareTokenPayloadSize, shareTokenPayloadSize+sha256.Size)
+	binary.BigEndian.PutUint32(payload, uint32(id))
+	binary.BigEndian.PutUint64(payload[4:], uint64(expiresAt))
+	return base64.RawURLEncoding.EncodeToString(append(payload, h.shareMAC(payload)...))
+}
+
+// parseShareToken checks the token's signature and expiry and returns the
+// share ID it names.
+func (h *Handler) parseShareToken(token string) (int32, bool) {
+	if len(h.ShareKey) == 0 {
+		return 0, false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != shareTokenPayloadSize+sha256.Size {
+		return 0, false
+	}
+	payload, mac := raw[:shareTokenPayloadSize], raw[shareTokenPayloadSize:]
+	if !hmac.Equal(mac, h.shareMAC(payload)) {
+		return 0, false
+	}
+	if int64(binary.BigEndian.Uint64(payload[4:])) <= time.Now().Unix() {
+		return 0, false
+	}
+	return int32(binary.BigEndian.Uint32(payload)), true
+}
+
+func (h *Handler) shareMAC(payload []byte) []byte {
+	mac := hmac.New(sha256.New, h.ShareKey)
+	mac.Write([]byte("transcript-share:"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// pgInt8OrZero mirrors pgInt4OrZero for BIGINT columns.
+func pgInt8OrZero(t pgtype.Int8) int64 {
+	if t.Valid {
+		return t.Int64
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package transcripts
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+)
+
+// contentFormats maps the media types the content endpoints can serve to a
+// transcript format. "" is the stored JSON.
+var contentFormats = map[string]string{
+	"application/json": "",
+	"text/plain":       transcript.FormatText,
+	"text/markdown":    transcript.FormatMarkdown,
+	"text/x-markdown":  transcript.FormatMarkdown,
+}
+
+// negotiateFormat picks the format for a content request. An explicit
+// ?format=json|txt|md wins, for plain download links; otherwise the
+// Accept media type with the highest quality is used, defaulting to JSON.
+func negotiateFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if format == "json" {
+			return "", true
+		}
+		return format, transcript.IsTextFormat(format)
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		format, ok := contentFormats[strings.ToLower(strings.TrimSpace(mediaType))]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				q = v
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best, true
+}
+
+// writeTextTranscript renders payload in a text format and sends it.
+func writeTextTranscript(w http.ResponseWriter, r *http.Request, payload transcript.Payload, format string, transcriptID int32, opts transcript.Options) {
+	body := transcript.RenderText(payload, format, opts)
+
+	disposition := "inline"
+	if r.URL.Query().Get("download") == "true" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Type", transcript.TextContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, transcript.TextFilename(strconv.Itoa(int(transcriptID)), format)))
+	w.Header().Set("Content-Security-Policy", attachmentPolicy)
+	w.Header().Set("Vary", "Accept")
+	serveContent(w, r, body)
+}
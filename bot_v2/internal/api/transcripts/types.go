@@ -1,15 +1,18 @@
 package transcripts
 
 import (
-	"context"
-
 	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
 )
 
 type Handler struct {
 	DB      *db.Queries
-	Storage interface {
-		GeneratePresignedURL(key string) (string, error)
-		DownloadTranscript(ctx context.Context, key string) ([]byte, error)
-	}
+	Storage storage.Client
+	// ShareKey signs public share links; sharing is disabled when empty.
+	ShareKey []byte
+	// Cache keeps hot transcripts in memory; nil disables it.
+	Cache *Cache
+	// StorageQuota is the default per-guild storage quota in bytes; 0 is
+	// unlimited.
+	StorageQuota int64
 }
\ No newline at end of file
@@ -0,0 +1,98 @@
+package transcripts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type StorageUsage struct {
+	TranscriptCount int32 `json:"transcriptCount"`
+	TranscriptBytes int64 `json:"transcriptBytes"`
+	AttachmentBytes int64 `json:"attachmentBytes"`
+	TotalBytes      int64 `json:"totalBytes"`
+	// QuotaBytes is null when the guild has no quota.
+	QuotaBytes *int64 `json:"quotaBytes"`
+	// OverQuota means new transcripts are saved without their messages.
+	OverQuota bool  `json:"overQuota"`
+	UpdatedAt int64 `json:"updatedAt"`
+}
+
+// HandleGetUsage reports how much storage the guild's transcripts and
+// archived attachments use, against its quota.
+func (h *Handler) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+	h.writeUsage(w, serverID)
+}
+
+// HandleSetStorageQuota sets a guild's own storage quota. It is for the bot
+// operator, not guild admins, who could otherwise lift their own cap. A null
+// quotaBytes goes back to the default quota; 0 is unlimited.
+func (h *Handler) HandleSetStorageQuota(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	var payload struct {
+		QuotaBytes *int64 `json:"quotaBytes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	var quota pgtype.Int8
+	if payload.QuotaBytes != nil {
+		if *payload.QuotaBytes < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "quotaBytes must not be negative"})
+			return
+		}
+		quota = pgtype.Int8{Int64: *payload.QuotaBytes, Valid: true}
+	}
+
+	found, err := h.DB.SetGuildStorageQuota(context.Background(), serverID, quota, time.Now().Unix())
+	if err != nil {
+		log.Printf("set storage quota failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to set storage quota"})
+		return
+	}
+	if !found {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "server not found"})
+		return
+	}
+	h.writeUsage(w, serverID)
+}
+
+func (h *Handler) writeUsage(w http.ResponseWriter, serverID int64) {
+	usage, err := h.DB.GetGuildStorageUsage(context.Background(), serverID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("load storage usage failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load storage usage"})
+		return
+	}
+
+	resp := StorageUsage{
+		TranscriptCount: usage.TranscriptCount,
+		TranscriptBytes: usage.TranscriptBytes,
+		AttachmentBytes: usage.AttachmentBytes,
+		TotalBytes:      usage.TotalBytes(),
+		UpdatedAt:       usage.UpdatedAt,
+	}
+	if quota := usage.Quota(h.StorageQuota); quota > 0 {
+		resp.QuotaBytes = &quota
+		resp.OverQuota = resp.TotalBytes >= quota
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,390 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+	"github.com/Sush1sui/FNS_BOT/internal/webhook"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxWebhooksPerServer = 10
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 100
+)
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func validateWebhookPayload(p *WebhookPayload) utils.ValidationErrors {
+	errs := make(utils.ValidationErrors)
+	utils.ValidateRequired(p.URL, "url", errs)
+	utils.ValidateMaxLength(p.URL, "url", 2048, errs)
+	if _, ok := errs["url"]; !ok {
+		if err := webhook.ValidateURL(p.URL); err != nil {
+			errs["url"] = err.Error()
+		}
+	}
+	utils.ValidateMaxLength(p.Description, "description", 200, errs)
+
+	slices.Sort(p.Events)
+	p.Events = slices.Compact(p.Events)
+	if len(p.Events) == 0 {
+		errs["events"] = "events is required"
+	}
+	for _, event := range p.Events {
+		if !webhook.IsEvent(event) {
+			errs["events"] = "unknown event: " + event
+			break
+		}
+	}
+	return errs
+}
+
+func formatWebhook(sub db.WebhookSubscription) Webhook {
+	return Webhook{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		Events:      sub.Events,
+		Description: sub.Description,
+		Enabled:     sub.Enabled,
+		CreatedAt:   sub.CreatedAt,
+		UpdatedAt:   sub.UpdatedAt,
+	}
+}
+
+func formatDelivery(d db.WebhookDelivery) Delivery {
+	item := Delivery{
+		ID:        d.ID,
+		EventID:   d.EventID,
+		Event:     d.Event,
+		Status:    d.Status,
+		Attempts:  d.Attempts,
+		LastError: d.LastError.String,
+		CreatedAt: d.CreatedAt,
+		Payload:   json.RawMessage(d.Payload),
+	}
+	if d.Status == db.WebhookStatusPending {
+		item.NextAttemptAt = &d.NextAttemptAt
+	}
+	if d.ResponseStatus.Valid {
+		item.ResponseStatus = &d.ResponseStatus.Int32
+	}
+	if d.DeliveredAt.Valid {
+		item.DeliveredAt = &d.DeliveredAt.Int64
+	}
+	return item
+}
+
+// HandleListWebhooks lists the server's webhooks, with the events they can
+// subscribe to for the dashboard.
+func (h *Handler) HandleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	rows, err := h.DB.ListWebhookSubscriptions(context.Background(), serverID)
+	if err != nil {
+		log.Printf("list webhooks failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load webhooks"})
+		return
+	}
+
+	items := make([]Webhook, len(rows))
+	for i, row := range rows {
+		items[i] = formatWebhook(row)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"webhooks": items,
+		"events":   webhook.Events,
+	})
+}
+
+// HandleCreateWebhook creates a webhook with a new signing secret. The
+// secret is only ever returned here and when it is rotated.
+func (h *Handler) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return
+	}
+
+	var payload WebhookPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if errs := validateWebhookPayload(&payload); len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "validation failed", "fields": errs})
+		return
+	}
+
+	ctx := context.Background()
+	existing, err := h.DB.ListWebhookSubscriptions(ctx, serverID)
+	if err != nil {
+		log.Printf("list webhooks failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create webhook"})
+		return
+	}
+	if len(existing) >= maxWebhooksPerServer {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "webhook limit reached"})
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		log.Printf("generate webhook secret failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create webhook"})
+		return
+	}
+	if err := h.DB.EnsureServerConfig(ctx, serverID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to initialize server config"})
+		return
+	}
+	sub, err := h.DB.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		ServerConfigID: serverID,
+		URL:            payload.URL,
+		Secret:         secret,
+		Events:         payload.Events,
+		Description:    payload.Description,
+		Enabled:        payload.Enabled == nil || *payload.Enabled,
+		CreatedAt:      time.Now().Unix(),
+	})
+	if err != nil {
+		log.Printf("create webhook failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create webhook"})
+		return
+	}
+
+	item := formatWebhook(sub)
+	item.Secret = sub.Secret
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (h *Handler) HandleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, formatWebhook(sub))
+}
+
+// HandleUpdateWebhook replaces the webhook's URL, events and description, and
+// rotates its secret when asked to.
+func (h *Handler) HandleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var payload WebhookPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json body"})
+		return
+	}
+	if errs := validateWebhookPayload(&payload); len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "validation failed", "fields": errs})
+		return
+	}
+
+	secret := ""
+	if payload.RotateSecret {
+		var err error
+		if secret, err = webhook.NewSecret(); err != nil {
+			log.Printf("generate webhook secret failed: %v", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save webhook"})
+			return
+		}
+	}
+	enabled := sub.Enabled
+	if payload.Enabled != nil {
+		enabled = *payload.Enabled
+	}
+
+	updated, err := h.DB.UpdateWebhookSubscription(context.Background(), db.UpdateWebhookSubscriptionParams{
+		ID:             sub.ID,
+		ServerConfigID: sub.ServerConfigID,
+		URL:            payload.URL,
+		Events:         payload.Events,
+		Description:    payload.Description,
+		Enabled:        enabled,
+		Secret:         secret,
+		UpdatedAt:      time.Now().Unix(),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+			return
+		}
+		log.Printf("update webhook failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save webhook"})
+		return
+	}
+
+	item := formatWebhook(updated)
+	if payload.RotateSecret {
+		item.Secret = updated.Secret
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+// HandleDeleteWebhook deletes the webhook along with its queued deliveries
+// and delivery log.
+func (h *Handler) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	serverID, webhookID, ok := parseWebhookIDs(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.DB.DeleteWebhookSubscription(context.Background(), webhookID, serverID)
+	if err != nil {
+		log.Printf("delete webhook failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete webhook"})
+		return
+	}
+	if !deleted {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// HandleTestWebhook sends a webhook.test event right away and returns the
+// delivery, so the dashboard can show whether the endpoint accepted it.
+func (h *Handler) HandleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := webhook.SendTest(r.Context(), sub)
+	if err != nil {
+		log.Printf("send test webhook failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to send test event"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"delivery": formatDelivery(delivery)})
+}
+
+// HandleListDeliveries returns the webhook's delivery log, newest first.
+// Pass the last ID of a page as before to get the next one.
+func (h *Handler) HandleListDeliveries(w http.ResponseWriter, r *http.Request) {
+	serverID, webhookID, ok := parseWebhookIDs(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxDeliveryLimit {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	var before int64
+	if raw := r.URL.Query().Get("before"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid before"})
+			return
+		}
+		before = parsed
+	}
+
+	ctx := context.Background()
+	if _, err := h.DB.GetWebhookSubscription(ctx, webhookID, serverID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+			return
+		}
+		log.Printf("load webhook failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load deliveries"})
+		return
+	}
+	rows, err := h.DB.ListWebhookDeliveries(ctx, webhookID, serverID, before, int32(limit))
+	if err != nil {
+		log.Printf("list webhook deliveries failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load deliveries"})
+		return
+	}
+
+	items := make([]Delivery, len(rows))
+	for i, row := range rows {
+		items[i] = formatDelivery(row)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": items})
+}
+
+// HandleRedeliver queues a finished delivery to be sent again.
+func (h *Handler) HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	serverID, webhookID, ok := parseWebhookIDs(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(r.PathValue("delivery_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid delivery id"})
+		return
+	}
+
+	delivery, err := h.DB.RedeliverWebhookDelivery(context.Background(), deliveryID, webhookID, serverID, time.Now().Unix())
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "delivery not found or still pending"})
+			return
+		}
+		log.Printf("redeliver webhook failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to queue delivery"})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"delivery": formatDelivery(delivery)})
+}
+
+func (h *Handler) loadWebhook(w http.ResponseWriter, r *http.Request) (db.WebhookSubscription, bool) {
+	serverID, webhookID, ok := parseWebhookIDs(w, r)
+	if !ok {
+		return db.WebhookSubscription{}, false
+	}
+
+	sub, err := h.DB.GetWebhookSubscription(context.Background(), webhookID, serverID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+			return db.WebhookSubscription{}, false
+		}
+		log.Printf("load webhook failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to load webhook"})
+		return db.WebhookSubscription{}, false
+	}
+	return sub, true
+}
+
+func parseWebhookIDs(w http.ResponseWriter, r *http.Request) (int64, int32, bool) {
+	serverID, err := strconv.ParseInt(r.PathValue("server_id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid server id"})
+		return 0, 0, false
+	}
+	webhookID, err := strconv.ParseInt(r.PathValue("webhook_id"), 10, 32)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid webhook id"})
+		return 0, 0, false
+	}
+	return serverID, int32(webhookID), true
+}
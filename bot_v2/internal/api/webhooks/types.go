@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"encoding/json"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+)
+
+type Handler struct {
+	DB *db.Queries
+}
+
+type WebhookPayload struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	// Enabled defaults to true on create and is left as it is on update
+	// when omitted.
+	Enabled *bool `json:"enabled"`
+	// RotateSecret replaces the signing secret on update.
+	RotateSecret bool `json:"rotateSecret"`
+}
+
+type Webhook struct {
+	ID          int32    `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	CreatedAt   int64    `json:"createdAt"`
+	UpdatedAt   int64    `json:"updatedAt"`
+	// Secret is only returned when the webhook is created or its secret is
+	// rotated.
+	Secret string `json:"secret,omitempty"`
+}
+
+type Delivery struct {
+	ID       int64  `json:"id"`
+	EventID  string `json:"eventId"`
+	Event    string `json:"event"`
+	Status   string `json:"status"`
+	Attempts int32  `json:"attempts"`
+	// NextAttemptAt is set while the delivery is pending.
+	NextAttemptAt  *int64          `json:"nextAttemptAt"`
+	ResponseStatus *int32          `json:"responseStatus"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      int64           `json:"createdAt"`
+	DeliveredAt    *int64          `json:"deliveredAt"`
+	Payload        json.RawMessage `json:"payload"`
+}
@@ -19,7 +19,7 @@ func StartBot() {
 		log.Fatalf("Error creating Discord session: %v", e)
 	}
 
-	s.Identify.Intents = discordgo.IntentsAllWithoutPrivileged | discordgo.IntentsGuildPresences | discordgo.IntentsGuildMembers | discordgo.IntentsGuildMessages
+	s.Identify.Intents = discordgo.IntentsAllWithoutPrivileged | discordgo.IntentsGuildPresences | discordgo.IntentsGuildMembers | discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentMessageContent
 
 	deployed := map[string]struct{}{}
 	var deployedMu sync.Mutex
@@ -62,6 +62,10 @@ func StartBot() {
 		})
 
 		deployEvents(sess)
+		tickets.StartVoiceSweeper(sess)
+		tickets.StartReconciler(sess)
+		tickets.StartRetentionPurger()
+		tickets.StartTranscriptOutbox(sess)
 
 		for _, g := range r.Guilds {
 			gid := g.ID
@@ -5,11 +5,19 @@ import (
 	"sync"
 
 	"github.com/Sush1sui/FNS_BOT/internal/bot/events"
+	"github.com/Sush1sui/FNS_BOT/internal/bot/tickets"
 	"github.com/bwmarrin/discordgo"
 )
 
 var EventHandlers = []any{
 	events.OnHelloWorld,
+	tickets.HandleModmailMessage,
+	tickets.HandleVoiceStateUpdate,
+	tickets.HandleChannelDelete,
+	tickets.HandleMessageCapture,
+	tickets.HandleMessageUpdateCapture,
+	tickets.HandleMessageDeleteCapture,
+	tickets.HandleMessageDeleteBulkCapture,
 }
 
 var eventsOnce sync.Once
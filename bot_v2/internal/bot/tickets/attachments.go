@@ -0,0 +1,135 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+)
+
+const (
+	maxArchivedAttachmentSize = 25 << 20
+	maxArchivedTicketSize     = 250 << 20
+	archiveConcurrency        = 4
+)
+
+var archiveHTTPClient = &http.Client{Timeout: 2 * time.Minute}
+
+// archiveAttachments copies the ticket's attachments from the Discord CDN to
+// storage next to the transcript blob, records each stored key and returns
+// the bytes stored. Files over the per-file or per-ticket limit keep only
+// their CDN link.
+func archiveAttachments(ctx context.Context, transcriptKey string, messages []transcript.Message) int64 {
+	if storageClient == nil {
+		return 0
+	}
+
+	pending := make([]*transcript.Attachment, 0)
+	budget := int64(maxArchivedTicketSize)
+	for mi := range messages {
+		for ai := range messages[mi].Attachments {
+			a := &messages[mi].Attachments[ai]
+			if a.ID == "" || a.URL == "" || a.Size > maxArchivedAttachmentSize || int64(a.Size) > budget {
+				continue
+			}
+			budget -= int64(a.Size)
+			pending = append(pending, a)
+		}
+	}
+	if len(pending) == 0 {
+		return 0
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		stored int64
+	)
+	sem := make(chan struct{}, archiveConcurrency)
+	for _, a := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(a *transcript.Attachment) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			key := transcript.AttachmentKey(transcriptKey, a.ID)
+			size, err := archiveAttachment(ctx, key, a)
+			if err != nil {
+				log.Printf("archive attachment %s failed: %v", a.ID, err)
+				return
+			}
+
+			mu.Lock()
+			a.StorageKey = &key
+			stored += size
+			mu.Unlock()
+		}(a)
+	}
+	wg.Wait()
+
+	return stored
+}
+
+var errAttachmentTooLarge = errors.New("attachment too large")
+
+// archiveAttachment copies one attachment and returns its stored size.
+func archiveAttachment(ctx context.Context, key string, a *transcript.Attachment) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := archiveHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("download %s: status %d", a.Filename, resp.StatusCode)
+	}
+	if resp.ContentLength > maxArchivedAttachmentSize {
+		return 0, fmt.Errorf("attachment %s too large: %d bytes", a.Filename, resp.ContentLength)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if a.ContentType != nil && *a.ContentType != "" {
+		contentType = *a.ContentType
+	}
+	size := resp.ContentLength
+	if size <= 0 {
+		size = -1
+	}
+	// Read one byte past the limit so an oversized body without a
+	// Content-Length fails instead of being stored truncated.
+	body := &countingReader{r: io.LimitReader(resp.Body, maxArchivedAttachmentSize+1), limit: maxArchivedAttachmentSize}
+	if err := storageClient.UploadObject(ctx, key, contentType, body, size); err != nil {
+		if body.n > body.limit {
+			err = fmt.Errorf("attachment %s too large: over %d bytes", a.Filename, body.limit)
+		}
+		return 0, err
+	}
+	return body.n, nil
+}
+
+// countingReader counts bytes read and fails once more than limit are read.
+type countingReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.n > c.limit {
+		return n, errAttachmentTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,213 @@
+package tickets
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Ticket messages are captured live into ticket_message_capture so that the
+// transcript keeps deleted messages and edit history, which the close-time
+// fetch cannot see.
+const (
+	captureCreate = "create"
+	captureUpdate = "update"
+	captureDelete = "delete"
+)
+
+func HandleMessageCapture(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m == nil || m.Message == nil || m.GuildID == "" || m.Author == nil {
+		return
+	}
+	captureMessage(s, m.Message, captureCreate, m.Timestamp)
+}
+
+func HandleMessageUpdateCapture(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Embed unfurls also arrive as updates; only edits set EditedTimestamp.
+	if m == nil || m.Message == nil || m.GuildID == "" || m.Author == nil || m.EditedTimestamp == nil {
+		return
+	}
+	captureMessage(s, m.Message, captureUpdate, *m.EditedTimestamp)
+}
+
+func HandleMessageDeleteCapture(s *discordgo.Session, m *discordgo.MessageDelete) {
+	if m == nil || m.Message == nil || m.GuildID == "" {
+		return
+	}
+	recordCapture(s, m.GuildID, m.ChannelID, m.ID, captureDelete, nil, time.Now())
+}
+
+func HandleMessageDeleteBulkCapture(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	if m == nil || m.GuildID == "" {
+		return
+	}
+	now := time.Now()
+	for _, id := range m.Messages {
+		recordCapture(s, m.GuildID, m.ChannelID, id, captureDelete, nil, now)
+	}
+}
+
+func captureMessage(s *discordgo.Session, m *discordgo.Message, event string, at time.Time) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		log.Printf("encode captured message failed: %v", err)
+		return
+	}
+	recordCapture(s, m.GuildID, m.ChannelID, m.ID, event, data, at)
+}
+
+func recordCapture(s *discordgo.Session, guildID, channelID, messageID, event string, data []byte, at time.Time) {
+	if queries == nil || !isTicketChannel(s, channelID) {
+		return
+	}
+	serverID, err := strconv.ParseInt(guildID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	if err := queries.CaptureTicketMessage(context.Background(), db.TicketMessageCapture{
+		ServerConfigID: serverID,
+		ChannelID:      channelID,
+		MessageID:      messageID,
+		Event:          event,
+		Data:           data,
+		OccurredAt:     at.Unix(),
+	}); err != nil {
+		log.Printf("capture ticket message failed: %v", err)
+	}
+}
+
+// messageHistory is what live capture saw of one message.
+type messageHistory struct {
+	versions  []*discordgo.Message // the original and each edit, oldest first
+	deletedAt int64
+}
+
+// loadMessageHistory reads the captured events of a ticket channel by
+// message ID. Capture is best effort, so failures yield an empty history.
+func loadMessageHistory(ctx context.Context, serverID int64, channelID string) map[string]*messageHistory {
+	history := make(map[string]*messageHistory)
+	if queries == nil {
+		return history
+	}
+
+	rows, err := queries.ListTicketMessageCaptures(ctx, serverID, channelID)
+	if err != nil {
+		log.Printf("load captured messages failed: %v", err)
+		return history
+	}
+
+	for _, row := range rows {
+		h, ok := history[row.MessageID]
+		if !ok {
+			h = &messageHistory{}
+			history[row.MessageID] = h
+		}
+		if row.Event == captureDelete {
+			h.deletedAt = row.OccurredAt
+			continue
+		}
+		var m discordgo.Message
+		if err := json.Unmarshal(row.Data, &m); err != nil {
+			log.Printf("decode captured message %s failed: %v", row.MessageID, err)
+			continue
+		}
+		h.versions = append(h.versions, &m)
+	}
+	return history
+}
+
+// mergeCapturedMessages adds the last captured version of every message
+// missing from messages, which were deleted before the close-time fetch,
+// and keeps the result in chronological order.
+func mergeCapturedMessages(messages []*discordgo.Message, history map[string]*messageHistory) []*discordgo.Message {
+	seen := make(map[string]struct{}, len(messages))
+	for _, m := range messages {
+		if m != nil {
+			seen[m.ID] = struct{}{}
+		}
+	}
+
+	merged := append([]*discordgo.Message(nil), messages...)
+	for id, h := range history {
+		if _, ok := seen[id]; ok || len(h.versions) == 0 {
+			continue
+		}
+		merged = append(merged, h.versions[len(h.versions)-1])
+	}
+
+	// Snowflakes grow with time, so ID order is chronological.
+	sort.SliceStable(merged, func(a, b int) bool {
+		return snowflakeLess(merged[a], merged[b])
+	})
+	return merged
+}
+
+func snowflakeLess(a, b *discordgo.Message) bool {
+	if a == nil || b == nil {
+		return b != nil
+	}
+	x, _ := strconv.ParseUint(a.ID, 10, 64)
+	y, _ := strconv.ParseUint(b.ID, 10, 64)
+	return x < y
+}
+
+// applyMessageHistory records edit revisions and deletions on transcript
+// messages.
+func applyMessageHistory(content []transcript.Message, history map[string]*messageHistory) {
+	for i := range content {
+		h, ok := history[content[i].ID]
+		if !ok {
+			continue
+		}
+		if h.deletedAt != 0 {
+			deletedAt := time.Unix(h.deletedAt, 0).UTC().Format(time.RFC3339)
+			content[i].Deleted = true
+			content[i].DeletedAt = &deletedAt
+		}
+		content[i].Revisions = messageRevisions(h.versions, content[i].Content)
+	}
+}
+
+// messageRevisions lists the earlier versions of an edited message. Messages
+// without a captured edit have none.
+func messageRevisions(versions []*discordgo.Message, current *string) []transcript.Revision {
+	edited := false
+	for _, v := range versions {
+		if v.EditedTimestamp != nil {
+			edited = true
+			break
+		}
+	}
+	if !edited {
+		return nil
+	}
+
+	revisions := make([]transcript.Revision, 0, len(versions))
+	for _, v := range versions {
+		if n := len(revisions); n > 0 && revisions[n-1].Content == v.Content {
+			continue
+		}
+		at := v.Timestamp
+		if v.EditedTimestamp != nil {
+			at = *v.EditedTimestamp
+		}
+		revisions = append(revisions, transcript.Revision{Content: v.Content, Timestamp: at.UTC().Format(time.RFC3339)})
+	}
+
+	// The newest version is the message itself.
+	if n := len(revisions); n > 0 && current != nil && revisions[n-1].Content == *current {
+		revisions = revisions[:n-1]
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+	return revisions
+}
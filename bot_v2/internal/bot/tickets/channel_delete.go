@@ -0,0 +1,230 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/webhook"
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleChannelDelete records tickets whose channel was deleted outside the
+// bot. Closing through the bot drops the active_ticket row before deleting
+// the channel, so a row that still exists here means a manual deletion.
+// createTicketChannel only ever creates guild text channels; the bot has no
+// ticket threads, so other channel types and ThreadDelete events never
+// belong to a ticket.
+func HandleChannelDelete(s *discordgo.Session, c *discordgo.ChannelDelete) {
+	if c == nil || c.Channel == nil || queries == nil || c.GuildID == "" {
+		return
+	}
+	if c.Type != discordgo.ChannelTypeGuildText {
+		return
+	}
+
+	serverID, err := strconv.ParseInt(c.GuildID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	info, err := queries.GetActiveTicketByChannel(ctx, serverID, c.ID)
+	if err != nil {
+		return
+	}
+
+	deletedBy := channelDeletedBy(s, c.GuildID, c.ID)
+	panelID, _ := ticketPanelFromTopic(c.Topic)
+	log.Printf("Ticket channel %s in guild %s was deleted outside the bot", c.ID, c.GuildID)
+
+	serverConfig, err := queries.GetServerConfig(ctx, serverID)
+	logChannelID := ""
+	if err == nil && serverConfig.TicketTranscriptCid.Valid {
+		logChannelID = serverConfig.TicketTranscriptCid.String
+	}
+
+	saved := "Not saved"
+	kept := false
+	if entry, err := queries.GetTranscriptOutboxByChannel(ctx, serverID, c.ID); err == nil {
+		// The ticket was already closing, so its transcript is staged in
+		// full. The entry only has to stop trying to delete the channel.
+		if err := queries.KeepTranscriptOutboxChannel(ctx, serverID, c.ID, time.Now().Unix()); err != nil {
+			log.Printf("update transcript outbox failed: %v", err)
+		}
+		saved = "Queued for upload"
+		kept = true
+		if entry.TranscriptID.Valid {
+			saved = fmt.Sprintf("Transcript `%d`", entry.TranscriptID.Int32)
+		}
+	} else if storageClient != nil && logChannelID != "" {
+		// The channel's messages are gone; rebuild them from live capture,
+		// or from the DM side of a modmail ticket when nothing was captured.
+		history := loadMessageHistory(ctx, serverID, c.ID)
+		messages := mergeCapturedMessages(nil, history)
+		if len(messages) == 0 {
+			messages = capturedModmailMessages(s, c.ID, info.CreatedAt)
+		}
+		attributeModmailMessages(s, c.GuildID, c.ID, messages)
+
+		username := ""
+		if u := resolveUser(s, c.GuildID, info.UserID); u != nil {
+			username = u.Username
+		}
+
+		entry, err := stageTranscript(s, transcriptRecord{
+			GuildID:      c.GuildID,
+			ServerID:     serverID,
+			ChannelID:    c.ID,
+			ChannelName:  c.Name,
+			UserID:       info.UserID,
+			Username:     username,
+			OpenedAt:     info.CreatedAt,
+			ClosedAt:     time.Now().Unix(),
+			ClosedBy:     deletedBy,
+			CloseReason:  closeReasonDeleted,
+			PanelID:      panelID,
+			LogChannelID: logChannelID,
+			AttachHTML:   serverConfig.TranscriptLogHtml,
+			AttachFormat: serverConfig.TranscriptLogFormat,
+			DMOpener:     serverConfig.TranscriptDmOpener,
+			History:      history,
+		}, messages, false)
+		if err != nil {
+			log.Printf("stage deleted ticket transcript failed: %v", err)
+		} else if transcriptID, err := processOutboxEntry(s, entry); err != nil {
+			log.Printf("save deleted ticket transcript failed: %v", err)
+			saved = "Partial transcript queued for upload"
+			kept = true
+		} else {
+			saved = fmt.Sprintf("Partial transcript `%d`", transcriptID)
+			kept = true
+		}
+	}
+
+	closeModmailTicket(s, c.GuildID, c.ID)
+	if err := queries.DeleteActiveTicketByChannel(ctx, serverID, c.ID); err != nil {
+		log.Printf("delete active ticket failed: %v", err)
+	}
+	cleanupTicketVoice(s, serverID, c.ID)
+	if err := queries.DeleteTicketTransfers(ctx, serverID, c.ID); err != nil {
+		log.Printf("delete ticket transfers failed: %v", err)
+	}
+	if err := queries.DeleteTicketMessageCaptures(ctx, serverID, c.ID); err != nil {
+		log.Printf("delete captured messages failed: %v", err)
+	}
+
+	webhook.Emit(serverID, webhook.TicketClosed, c.ID, webhook.TicketData{
+		ChannelID:   c.ID,
+		UserID:      info.UserID,
+		PanelID:     panelID,
+		OpenedAt:    info.CreatedAt,
+		ClosedAt:    time.Now().Unix(),
+		ClosedBy:    deletedBy,
+		CloseReason: closeReasonDeleted,
+	})
+
+	sendDeletedTicketWarning(s, logChannelID, c.Channel, info.UserID, deletedBy, saved, kept)
+}
+
+// channelDeletedBy looks up who deleted channelID in the guild audit log.
+// It returns "" when the bot cannot read the audit log.
+func channelDeletedBy(s *discordgo.Session, guildID, channelID string) string {
+	if s == nil {
+		return ""
+	}
+
+	auditLog, err := s.GuildAuditLog(guildID, "", "", int(discordgo.AuditLogActionChannelDelete), 10)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range auditLog.AuditLogEntries {
+		if entry != nil && entry.TargetID == channelID {
+			return entry.UserID
+		}
+	}
+	return ""
+}
+
+// capturedModmailMessages returns the DM side of a modmail ticket, which
+// survives the ticket channel. Other tickets have nothing captured.
+func capturedModmailMessages(s *discordgo.Session, channelID string, openedAt int64) []*discordgo.Message {
+	if s == nil || s.State == nil || s.State.User == nil {
+		return nil
+	}
+
+	ticket, err := queries.GetModmailTicketByChannel(context.Background(), channelID)
+	if err != nil {
+		return nil
+	}
+
+	messages, err := fetchAllMessages(s, ticket.DMChannelID)
+	if err != nil {
+		log.Printf("fetch modmail messages failed: %v", err)
+		return nil
+	}
+
+	botID := s.State.User.ID
+	opened := time.Unix(openedAt, 0)
+	captured := make([]*discordgo.Message, 0, len(messages))
+	for _, m := range messages {
+		if m == nil || m.Author == nil || m.Timestamp.Before(opened) {
+			continue
+		}
+		if m.Author.ID == ticket.UserID {
+			captured = append(captured, m)
+			continue
+		}
+		if m.Author.ID == botID && len(m.Embeds) == 1 && m.Embeds[0].Footer != nil &&
+			strings.HasPrefix(m.Embeds[0].Footer.Text, modmailStaffFooter) {
+			captured = append(captured, m)
+		}
+	}
+	return captured
+}
+
+func sendDeletedTicketWarning(s *discordgo.Session, logChannelID string, channel *discordgo.Channel, openerID, deletedBy, saved string, kept bool) {
+	if s == nil || logChannelID == "" {
+		return
+	}
+
+	deleter := "Unknown (audit log unavailable)"
+	if deletedBy != "" {
+		deleter = fmt.Sprintf("<@%s>\n`%s`", deletedBy, deletedBy)
+	}
+	description := fmt.Sprintf("Ticket channel **#%s** was deleted directly in Discord. Its messages could not be saved; use the Close button to keep full transcripts.", channel.Name)
+	if kept {
+		description = fmt.Sprintf("Ticket channel **#%s** was deleted directly in Discord. A transcript was kept from the messages the bot had captured, but it may be incomplete; use the Close button to keep full transcripts.", channel.Name)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "⚠️ Ticket Deleted Outside the Bot",
+		Description: description,
+		Color:       0xFFA500,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "Opener",
+				Value:  fmt.Sprintf("<@%s>\n`%s`", openerID, openerID),
+				Inline: true,
+			},
+			{
+				Name:   "Deleted By",
+				Value:  deleter,
+				Inline: true,
+			},
+			{
+				Name:   "Transcript",
+				Value:  saved,
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Sushi Tickets • Transcript System",
+		},
+	}
+
+	_, _ = s.ChannelMessageSendEmbed(logChannelID, embed)
+}
@@ -0,0 +1,235 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func handleEscalateTicket(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if queries == nil || i.GuildID == "" || i.ChannelID == "" {
+		respondEphemeral(s, i, "Channel not found.")
+		return
+	}
+	if !isTicketStaff(i) {
+		respondEphemeral(s, i, "Only staff can escalate tickets.")
+		return
+	}
+
+	serverID, err := strconv.ParseInt(i.GuildID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	currentPanel := int32(0)
+	if channel := getChannelFromInteraction(s, i); channel != nil {
+		currentPanel, _ = ticketPanelFromTopic(channel.Topic)
+	}
+
+	panels, err := queries.GetPanelConfigsByServer(context.Background(), serverID)
+	if err != nil {
+		log.Printf("load escalation panels failed: %v", err)
+		respondEphemeral(s, i, "Failed to load panels. Please try again later.")
+		return
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(panels))
+	for _, panel := range panels {
+		if panel.ID == currentPanel {
+			continue
+		}
+		option := discordgo.SelectMenuOption{
+			Label: panel.Title,
+			Value: strconv.Itoa(int(panel.ID)),
+		}
+		if emoji := utils.ParseComponentEmoji(utils.TextOrEmpty(panel.BtnEmoji)); emoji != nil {
+			option.Emoji = emoji
+		}
+		options = append(options, option)
+		if len(options) == 25 {
+			break
+		}
+	}
+	if len(options) == 0 {
+		respondEphemeral(s, i, "There is no other panel to escalate to.")
+		return
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Move this ticket to which panel?",
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    escalatePanelSelectID,
+						Placeholder: "Select a panel...",
+						Options:     options,
+					},
+				}},
+			},
+		},
+	})
+}
+
+func handleEscalatePanelSelect(s *discordgo.Session, i *discordgo.InteractionCreate, panelID int32) {
+	respondDeferred(s, i)
+
+	if queries == nil || i.GuildID == "" || i.ChannelID == "" {
+		editEphemeral(s, i, "Channel not found.")
+		return
+	}
+	if !isTicketStaff(i) {
+		editEphemeral(s, i, "Only staff can escalate tickets.")
+		return
+	}
+
+	if err := escalateTicket(s, i.GuildID, i.ChannelID, i.Member.User, panelID); err != nil {
+		log.Printf("escalate ticket failed: %v", err)
+		editEphemeral(s, i, "Failed to escalate ticket. Please try again later.")
+		return
+	}
+	editEphemeral(s, i, "Ticket escalated.")
+}
+
+// escalateTicket moves a ticket channel into the target panel's category,
+// rebuilds its overwrites for the panel's team and records the move.
+func escalateTicket(s *discordgo.Session, guildID, channelID string, staff *discordgo.User, panelID int32) error {
+	ctx := context.Background()
+	serverID, err := strconv.ParseInt(guildID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	channel, err := s.Channel(channelID)
+	if err != nil {
+		return err
+	}
+	opener := ticketOpenerFromTopic(channel.Topic)
+	if opener == "" {
+		return fmt.Errorf("channel %s is not a ticket", channelID)
+	}
+
+	target, err := queries.GetPanelConfigByID(ctx, serverID, panelID)
+	if err != nil {
+		return err
+	}
+
+	fromPanel := pgtype.Int4{}
+	fromTitle := ""
+	if id, ok := ticketPanelFromTopic(channel.Topic); ok {
+		fromPanel = pgtype.Int4{Int32: id, Valid: true}
+		if panel, err := queries.GetPanelConfigByID(ctx, serverID, id); err == nil {
+			fromTitle = panel.Title
+		}
+	}
+
+	serverConfig, _ := queries.GetServerConfig(ctx, serverID)
+	userPerms := baseUserPerms(parseTicketPermissions(serverConfig.TicketPermissions))
+
+	overwrites, err := buildPermissionOverwrites(ctx, s, guildID, opener, serverID, target.MentionRolesOnOpen, userPerms, staffPerms())
+	if err != nil {
+		return err
+	}
+
+	edit := &discordgo.ChannelEdit{
+		Topic:                fmt.Sprintf("ticket_opener:%s panel:%d", opener, target.ID),
+		PermissionOverwrites: overwrites,
+	}
+	if target.CategoryID.Valid {
+		edit.ParentID = target.CategoryID.String
+	}
+	if _, err := s.ChannelEdit(channelID, edit); err != nil {
+		return err
+	}
+
+	// Keep the linked voice channel in step with the ticket.
+	if voice, err := queries.GetTicketVoiceByTicket(ctx, serverID, channelID); err == nil {
+		voiceEdit := &discordgo.ChannelEdit{
+			PermissionOverwrites: voiceOverwrites(overwrites),
+			ParentID:             edit.ParentID,
+		}
+		if _, err := s.ChannelEdit(voice.VoiceChannelID, voiceEdit); err != nil {
+			log.Printf("update ticket voice channel failed: %v", err)
+		}
+	}
+
+	if err := queries.CreateTicketTransfer(ctx, db.TicketTransfer{
+		ServerConfigID:  serverID,
+		TicketChannelID: channelID,
+		FromPanelID:     fromPanel,
+		FromPanelTitle:  fromTitle,
+		ToPanelID:       target.ID,
+		ToPanelTitle:    target.Title,
+		MovedBy:         staff.ID,
+		MovedAt:         time.Now().Unix(),
+	}); err != nil {
+		log.Printf("record ticket transfer failed: %v", err)
+	}
+
+	mentions := make([]string, 0, len(target.MentionRolesOnOpen))
+	for _, roleID := range target.MentionRolesOnOpen {
+		if roleID == "" {
+			continue
+		}
+		mentions = append(mentions, fmt.Sprintf("<@&%s>", roleID))
+	}
+
+	from := fromTitle
+	if from == "" {
+		from = "another panel"
+	} else {
+		from = "**" + from + "**"
+	}
+	_, _ = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: strings.Join(mentions, " "),
+		Embeds: []*discordgo.MessageEmbed{{
+			Description: fmt.Sprintf("🔀 Ticket escalated from %s to **%s** by %s.", from, target.Title, staff.Mention()),
+			Color:       int(target.EmbedColor),
+		}},
+	})
+	return nil
+}
+
+// loadTransfers converts the ticket's escalation history into transcript entries.
+func loadTransfers(ctx context.Context, s *discordgo.Session, guildID string, serverID int64, ticketChannelID string) []transcript.Transfer {
+	if queries == nil {
+		return nil
+	}
+
+	rows, err := queries.GetTicketTransfers(ctx, serverID, ticketChannelID)
+	if err != nil {
+		log.Printf("load ticket transfers failed: %v", err)
+		return nil
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	items := make([]transcript.Transfer, 0, len(rows))
+	for _, row := range rows {
+		var fromPanelID *int
+		if row.FromPanelID.Valid {
+			fromPanelID = intPtr(int(row.FromPanelID.Int32))
+		}
+		items = append(items, transcript.Transfer{
+			FromPanelID:    fromPanelID,
+			FromPanelTitle: row.FromPanelTitle,
+			ToPanelID:      int(row.ToPanelID),
+			ToPanelTitle:   row.ToPanelTitle,
+			MovedBy:        transcript.ClosedBy{ID: row.MovedBy, Username: usernameOrID(s, guildID, row.MovedBy)},
+			MovedAt:        time.Unix(row.MovedAt, 0).UTC().Format(time.RFC3339),
+		})
+	}
+	return items
+}
@@ -0,0 +1,86 @@
+package tickets
+
+import (
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/bwmarrin/discordgo"
+)
+
+// resolveMentions looks up the names behind every mention in content while
+// the users, roles and channels still exist. Ids that cannot be resolved are
+// left out and render as deleted.
+func resolveMentions(s *discordgo.Session, guildID string, messages []*discordgo.Message, content []transcript.Message) *transcript.Mentions {
+	if s == nil || s.State == nil {
+		return nil
+	}
+	userIDs, roleIDs, channelIDs := transcript.MentionIDs(content)
+	if len(userIDs) == 0 && len(roleIDs) == 0 && len(channelIDs) == 0 {
+		return nil
+	}
+
+	// Discord sends the mentioned users along with each message.
+	known := make(map[string]string)
+	for _, m := range messages {
+		if m == nil {
+			continue
+		}
+		for _, u := range m.Mentions {
+			known[u.ID] = u.Username
+		}
+	}
+
+	mentions := &transcript.Mentions{}
+	if len(userIDs) > 0 {
+		mentions.Users = make(map[string]transcript.MentionedUser)
+		for _, id := range userIDs {
+			name, ok := known[id]
+			if !ok {
+				name = usernameOrID(s, guildID, id)
+			}
+			if name != "" && name != id {
+				mentions.Users[id] = transcript.MentionedUser{Username: name}
+			}
+		}
+	}
+
+	if len(roleIDs) > 0 {
+		mentions.Roles = make(map[string]transcript.MentionedRole)
+		var fetched []*discordgo.Role
+		for _, id := range roleIDs {
+			role, err := s.State.Role(guildID, id)
+			if err != nil {
+				if fetched == nil {
+					if fetched, err = s.GuildRoles(guildID); err != nil {
+						fetched = []*discordgo.Role{}
+					}
+				}
+				role = findRole(fetched, id)
+			}
+			if role != nil {
+				mentions.Roles[id] = transcript.MentionedRole{Name: role.Name, Color: role.Color}
+			}
+		}
+	}
+
+	if len(channelIDs) > 0 {
+		mentions.Channels = make(map[string]transcript.MentionedChannel)
+		for _, id := range channelIDs {
+			channel, err := s.State.Channel(id)
+			if err != nil {
+				if channel, err = s.Channel(id); err != nil {
+					continue
+				}
+			}
+			mentions.Channels[id] = transcript.MentionedChannel{Name: channel.Name}
+		}
+	}
+	return mentions
+}
+
+func findRole(roles []*discordgo.Role, id string) *discordgo.Role {
+	for _, role := range roles {
+		if role.ID == id {
+			return role
+		}
+	}
+	return nil
+}
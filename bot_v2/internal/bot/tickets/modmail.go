@@ -0,0 +1,451 @@
+package tickets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	modmailUserFooter  = "Sent via DM"
+	modmailStaffFooter = "Staff reply"
+
+	maxRelayAttachmentSize = 25 * 1024 * 1024
+	// relayDownloadTimeout bounds each attachment download so a slow CDN
+	// response cannot hold up the relay.
+	relayDownloadTimeout = 30 * time.Second
+)
+
+// HandleModmailMessage relays messages between a user's DM channel and their
+// modmail ticket, and starts the guild/panel picker for new DM conversations.
+func HandleModmailMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m == nil || m.Author == nil || m.Author.Bot || queries == nil {
+		return
+	}
+
+	if m.GuildID == "" {
+		handleModmailDM(s, m.Message)
+		return
+	}
+	handleModmailStaffReply(s, m.Message)
+}
+
+func handleModmailDM(s *discordgo.Session, m *discordgo.Message) {
+	ticket, err := queries.GetModmailTicketByDM(context.Background(), m.ChannelID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("load modmail ticket failed: %v", err)
+			return
+		}
+		sendModmailGuildPicker(s, m.ChannelID, m.Author.ID)
+		return
+	}
+
+	if err := relayModmailMessage(s, ticket.TicketChannelID, m.Author, m, modmailUserFooter); err != nil {
+		log.Printf("relay modmail message to ticket failed: %v", err)
+		_, _ = s.ChannelMessageSend(m.ChannelID, "⚠️ Your message could not be delivered to staff. Please try again.")
+		return
+	}
+	_ = s.MessageReactionAdd(m.ChannelID, m.ID, "✅")
+}
+
+func handleModmailStaffReply(s *discordgo.Session, m *discordgo.Message) {
+	if !isTicketChannel(s, m.ChannelID) {
+		return
+	}
+
+	ticket, err := queries.GetModmailTicketByChannel(context.Background(), m.ChannelID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("load modmail ticket failed: %v", err)
+		}
+		return
+	}
+	// Staff notes stay in the ticket channel.
+	if m.Author.ID == ticket.UserID || transcript.IsNote(m.Content) {
+		return
+	}
+
+	footer := modmailStaffFooter
+	if s.State != nil {
+		if g, err := s.State.Guild(m.GuildID); err == nil {
+			footer = fmt.Sprintf("%s • %s", modmailStaffFooter, g.Name)
+		}
+	}
+
+	if err := relayModmailMessage(s, ticket.DMChannelID, m.Author, m, footer); err != nil {
+		log.Printf("relay modmail reply to dm failed: %v", err)
+		_ = s.MessageReactionAdd(m.ChannelID, m.ID, "⚠️")
+	}
+}
+
+func sendModmailGuildPicker(s *discordgo.Session, dmChannelID, userID string) {
+	options := modmailGuildOptions(s, userID)
+	if len(options) == 0 {
+		_, _ = s.ChannelMessageSend(dmChannelID, "None of the servers we share accept tickets by DM. Please open a ticket from the server's panel channel instead.")
+		return
+	}
+
+	_, _ = s.ChannelMessageSendComplex(dmChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "Sushi Tickets",
+			Description: "Which server do you need help with? Pick one below to open a ticket. Once it is open, everything you send here is forwarded to the staff team.",
+			Color:       0xFF5A36,
+		}},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    modmailGuildSelectID,
+					Placeholder: "Select a server...",
+					Options:     options,
+				},
+			}},
+		},
+	})
+}
+
+func modmailGuildOptions(s *discordgo.Session, userID string) []discordgo.SelectMenuOption {
+	options := make([]discordgo.SelectMenuOption, 0)
+	if s == nil || s.State == nil {
+		return options
+	}
+
+	serverIDs, err := queries.GetModmailEnabledServers(context.Background())
+	if err != nil {
+		log.Printf("load modmail servers failed: %v", err)
+		return options
+	}
+
+	for _, id := range serverIDs {
+		guildID := strconv.FormatInt(id, 10)
+		guild, err := s.State.Guild(guildID)
+		if err != nil || !isGuildMember(s, guildID, userID) {
+			continue
+		}
+		options = append(options, discordgo.SelectMenuOption{Label: guild.Name, Value: guildID})
+		// Select menus cap out at 25 options.
+		if len(options) == 25 {
+			break
+		}
+	}
+	return options
+}
+
+func handleModmailGuildSelect(s *discordgo.Session, i *discordgo.InteractionCreate, guildID string) {
+	user := interactionUser(i)
+	if user == nil || !modmailAllowed(s, guildID, user.ID) {
+		respondEphemeral(s, i, "That server does not accept tickets by DM.")
+		return
+	}
+
+	serverID, err := strconv.ParseInt(guildID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	panels, err := queries.GetPanelConfigsByServer(context.Background(), serverID)
+	if err != nil {
+		log.Printf("load modmail panels failed: %v", err)
+		respondEphemeral(s, i, "Failed to load panels. Please try again later.")
+		return
+	}
+	if len(panels) == 0 {
+		respondEphemeral(s, i, "That server has no ticket panels yet.")
+		return
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(panels))
+	for _, panel := range panels {
+		option := discordgo.SelectMenuOption{
+			Label: panel.Title,
+			Value: strconv.Itoa(int(panel.ID)),
+		}
+		if emoji := utils.ParseComponentEmoji(utils.TextOrEmpty(panel.BtnEmoji)); emoji != nil {
+			option.Emoji = emoji
+		}
+		options = append(options, option)
+		if len(options) == 25 {
+			break
+		}
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    modmailPanelPrefix + guildID,
+						Placeholder: "What do you need help with?",
+						Options:     options,
+					},
+				}},
+			},
+		},
+	})
+}
+
+func handleModmailPanelSelect(s *discordgo.Session, i *discordgo.InteractionCreate, guildID string, panelID int32) {
+	user := interactionUser(i)
+	if user == nil || !modmailAllowed(s, guildID, user.ID) {
+		respondEphemeral(s, i, "That server does not accept tickets by DM.")
+		return
+	}
+
+	questions, err := loadPanelQuestions(panelID)
+	if err != nil {
+		log.Printf("load questions failed: %v", err)
+		respondEphemeral(s, i, "Failed to load panel. Please try again later.")
+		return
+	}
+
+	if len(questions) > 0 {
+		showQuestionsModal(s, i, fmt.Sprintf("%s%s_%d", modmailModalPrefix, guildID, panelID), questions)
+		return
+	}
+
+	respondDeferred(s, i)
+	openModmailTicket(s, i, guildID, panelID, nil)
+}
+
+func openModmailTicket(s *discordgo.Session, i *discordgo.InteractionCreate, guildID string, panelID int32, qna []QnA) {
+	user := interactionUser(i)
+	if user == nil {
+		return
+	}
+
+	ctx := context.Background()
+	if existing, err := queries.GetModmailTicketByDM(ctx, i.ChannelID); err == nil {
+		editEphemeral(s, i, fmt.Sprintf("You already have an open ticket (<#%s>). Messages you send here go to it.", existing.TicketChannelID))
+		return
+	}
+
+	serverID, err := strconv.ParseInt(guildID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	channel, err := createTicketChannel(s, guildID, user, i.ID, panelID, qna)
+	if err != nil {
+		log.Printf("open modmail ticket failed: %v", err)
+		if err == errMaxTickets {
+			editEphemeral(s, i, "You reached max open tickets. Please close existing tickets.")
+			return
+		}
+		editEphemeral(s, i, "Failed to create ticket. Please try again later.")
+		return
+	}
+
+	if err := queries.CreateModmailTicket(ctx, db.ModmailTicket{
+		DMChannelID:     i.ChannelID,
+		UserID:          user.ID,
+		ServerConfigID:  serverID,
+		TicketChannelID: channel.ID,
+		CreatedAt:       time.Now().Unix(),
+	}); err != nil {
+		log.Printf("create modmail ticket failed: %v", err)
+	}
+
+	_, _ = s.ChannelMessageSendComplex(channel.ID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Description: fmt.Sprintf("📨 This ticket was opened by DM. Messages sent here are forwarded to %s, and their replies appear here.", user.Mention()),
+			Color:       0xFF5A36,
+		}},
+	})
+
+	guildName := guildID
+	if s.State != nil {
+		if g, err := s.State.Guild(guildID); err == nil {
+			guildName = g.Name
+		}
+	}
+	editEphemeral(s, i, fmt.Sprintf("Ticket opened in **%s**! Send your messages here and they will be forwarded to the staff team.", guildName))
+}
+
+// closeModmailTicket notifies the DM side of a closed ticket and drops the
+// DM-to-channel mapping. It is a no-op for regular tickets.
+func closeModmailTicket(s *discordgo.Session, guildID, channelID string) {
+	if queries == nil {
+		return
+	}
+
+	ctx := context.Background()
+	ticket, err := queries.GetModmailTicketByChannel(ctx, channelID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("load modmail ticket failed: %v", err)
+		}
+		return
+	}
+
+	if err := queries.DeleteModmailTicketByChannel(ctx, channelID); err != nil {
+		log.Printf("delete modmail ticket failed: %v", err)
+	}
+
+	guildName := "the server"
+	if s != nil && s.State != nil {
+		if g, err := s.State.Guild(guildID); err == nil {
+			guildName = "**" + g.Name + "**"
+		}
+	}
+	if s != nil {
+		_, _ = s.ChannelMessageSend(ticket.DMChannelID, fmt.Sprintf("🔒 Your ticket in %s has been closed. Send another message here if you need more help.", guildName))
+	}
+}
+
+// attributeModmailMessages rewrites the bot's relay messages so transcripts
+// show the DM side as written by the opener instead of the bot.
+func attributeModmailMessages(s *discordgo.Session, guildID, channelID string, messages []*discordgo.Message) {
+	if queries == nil || s == nil || s.State == nil || s.State.User == nil {
+		return
+	}
+
+	ticket, err := queries.GetModmailTicketByChannel(context.Background(), channelID)
+	if err != nil {
+		return
+	}
+	opener := resolveUser(s, guildID, ticket.UserID)
+	if opener == nil {
+		return
+	}
+
+	botID := s.State.User.ID
+	for idx, m := range messages {
+		if m == nil || m.Author == nil || m.Author.ID != botID || len(m.Embeds) != 1 {
+			continue
+		}
+		embed := m.Embeds[0]
+		if embed.Footer == nil || embed.Footer.Text != modmailUserFooter {
+			continue
+		}
+
+		relayed := *m
+		relayed.Author = opener
+		relayed.Content = embed.Description
+		relayed.Embeds = nil
+		messages[idx] = &relayed
+	}
+}
+
+func relayModmailMessage(s *discordgo.Session, channelID string, author *discordgo.User, m *discordgo.Message, footer string) error {
+	description := m.Content
+	files := make([]*discordgo.File, 0, len(m.Attachments))
+	for _, a := range m.Attachments {
+		file, err := downloadRelayAttachment(a)
+		if err != nil {
+			log.Printf("download modmail attachment failed: %v", err)
+			description += fmt.Sprintf("\n📎 [%s](%s)", a.Filename, a.URL)
+			continue
+		}
+		files = append(files, file)
+	}
+	if strings.TrimSpace(description) == "" && len(files) == 0 {
+		return nil
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    author.Username,
+			IconURL: author.AvatarURL(""),
+		},
+		Description: description,
+		Color:       0xFF5A36,
+		Footer:      &discordgo.MessageEmbedFooter{Text: footer},
+		Timestamp:   m.Timestamp.Format(time.RFC3339),
+	}
+
+	_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Files:  files,
+	})
+	return err
+}
+
+func downloadRelayAttachment(a *discordgo.MessageAttachment) (*discordgo.File, error) {
+	if a == nil {
+		return nil, fmt.Errorf("missing attachment")
+	}
+	if a.Size > maxRelayAttachmentSize {
+		return nil, fmt.Errorf("attachment %s too large: %d bytes", a.Filename, a.Size)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), relayDownloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := archiveHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("download %s: status %d", a.Filename, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRelayAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRelayAttachmentSize {
+		return nil, fmt.Errorf("attachment %s too large", a.Filename)
+	}
+
+	return &discordgo.File{
+		Name:        a.Filename,
+		ContentType: a.ContentType,
+		Reader:      bytes.NewReader(data),
+	}, nil
+}
+
+func modmailAllowed(s *discordgo.Session, guildID, userID string) bool {
+	serverID, err := strconv.ParseInt(guildID, 10, 64)
+	if err != nil {
+		return false
+	}
+	cfg, err := queries.GetServerConfig(context.Background(), serverID)
+	if err != nil || !cfg.ModmailEnabled {
+		return false
+	}
+	return isGuildMember(s, guildID, userID)
+}
+
+func isGuildMember(s *discordgo.Session, guildID, userID string) bool {
+	if s == nil || guildID == "" || userID == "" {
+		return false
+	}
+	if s.State != nil {
+		if _, err := s.State.Member(guildID, userID); err == nil {
+			return true
+		}
+	}
+	_, err := s.GuildMember(guildID, userID)
+	return err == nil
+}
+
+func parseModmailModalID(customID string) (string, int32, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(customID, modmailModalPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", 0, false
+	}
+	panelID, ok := parsePanelID(parts[1], "")
+	if !ok {
+		return "", 0, false
+	}
+	return parts[0], panelID, true
+}
@@ -0,0 +1,220 @@
+package tickets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	outboxInterval     = 15 * time.Second
+	outboxBatchSize    = 20
+	outboxBaseDelay    = 30 * time.Second
+	outboxMaxDelay     = 30 * time.Minute
+	outboxAlertAttempt = 5
+)
+
+var (
+	outboxOnce sync.Once
+	// outboxBusy holds the ids of entries being processed, so the close
+	// button and the worker never save the same transcript twice at once.
+	outboxBusy sync.Map
+
+	errOutboxBusy = errors.New("transcript outbox entry is already being processed")
+)
+
+// StartTranscriptOutbox retries staged transcripts right away and then every
+// outboxInterval. Safe to call more than once.
+func StartTranscriptOutbox(s *discordgo.Session) {
+	if queries == nil || storageClient == nil {
+		return
+	}
+	outboxOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(outboxInterval)
+			defer ticker.Stop()
+
+			drainTranscriptOutbox(s)
+			for range ticker.C {
+				drainTranscriptOutbox(s)
+			}
+		}()
+	})
+}
+
+func drainTranscriptOutbox(s *discordgo.Session) {
+	entries, err := queries.ListDueTranscriptOutbox(context.Background(), time.Now().Unix(), outboxBatchSize)
+	if err != nil {
+		log.Printf("list transcript outbox failed: %v", err)
+		return
+	}
+
+	failed := 0
+	for _, entry := range entries {
+		if _, err := processOutboxEntry(s, entry); err != nil && !errors.Is(err, errOutboxBusy) {
+			log.Printf("transcript outbox entry %d failed: %v", entry.ID, err)
+			failed++
+		}
+	}
+	metrics.Add("transcript_outbox_failures_total", "Transcript outbox attempts that failed and will be retried.", float64(failed))
+}
+
+// processOutboxEntry stores the entry's transcript if that has not happened
+// yet, then deletes the ticket channel when the entry asks for it. A failed
+// step is scheduled for a retry with backoff, and staff are warned once the
+// failures pile up. It returns the saved transcript id.
+func processOutboxEntry(s *discordgo.Session, entry db.TranscriptOutbox) (int32, error) {
+	if _, busy := outboxBusy.LoadOrStore(entry.ID, struct{}{}); busy {
+		return 0, errOutboxBusy
+	}
+	defer outboxBusy.Delete(entry.ID)
+
+	transcriptID := entry.TranscriptID.Int32
+	if entry.Status == db.OutboxStatusPending {
+		id, err := saveStagedTranscript(s, entry)
+		if err != nil {
+			retryOutboxEntry(s, entry, false, err)
+			return 0, err
+		}
+		transcriptID = id
+		entry.Attempts = 0
+	}
+
+	if entry.DeleteChannel {
+		guildID := strconv.FormatInt(entry.ServerConfigID, 10)
+		if err := finishTicketClose(s, guildID, entry.ServerConfigID, entry.ChannelID); err != nil {
+			err = fmt.Errorf("delete ticket channel: %w", err)
+			retryOutboxEntry(s, entry, true, err)
+			return transcriptID, err
+		}
+	}
+
+	if err := queries.DeleteTranscriptOutbox(context.Background(), entry.ID); err != nil {
+		log.Printf("delete transcript outbox entry %d failed: %v", entry.ID, err)
+	}
+	return transcriptID, nil
+}
+
+// retryOutboxEntry schedules the next attempt. stored reports whether the
+// transcript itself is already saved and only the channel cleanup failed.
+func retryOutboxEntry(s *discordgo.Session, entry db.TranscriptOutbox, stored bool, cause error) {
+	next := time.Now().Add(outboxBackoff(entry.Attempts)).Unix()
+	attempts, err := queries.RetryTranscriptOutbox(context.Background(), entry.ID, next, cause.Error())
+	if err != nil {
+		log.Printf("reschedule transcript outbox entry %d failed: %v", entry.ID, err)
+		return
+	}
+	if attempts >= outboxAlertAttempt && !entry.Alerted {
+		alertOutboxFailure(s, entry, attempts, stored, cause)
+	}
+}
+
+// outboxBackoff doubles the delay after every failed attempt, up to
+// outboxMaxDelay.
+func outboxBackoff(attempts int32) time.Duration {
+	delay := outboxBaseDelay
+	for n := int32(0); n < attempts && delay < outboxMaxDelay; n++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxDelay)
+}
+
+// alertOutboxFailure tells staff that closing a ticket keeps failing. The
+// warning goes to the ticket channel while it exists, and to the transcript
+// log channel otherwise.
+func alertOutboxFailure(s *discordgo.Session, entry db.TranscriptOutbox, attempts int32, stored bool, cause error) {
+	if s == nil {
+		return
+	}
+
+	var rec transcriptRecord
+	_ = json.Unmarshal(entry.Record, &rec)
+
+	title := "⚠️ Transcript Not Saved Yet"
+	description := fmt.Sprintf("The transcript for **#%s** failed to save %d times and is still being retried.", rec.ChannelName, attempts)
+	targetID := rec.LogChannelID
+	if stored {
+		title = "⚠️ Ticket Channel Not Deleted"
+		description = fmt.Sprintf("The transcript for **#%s** is saved, but deleting the channel failed %d times. It is still being retried; you can also delete the channel by hand.", rec.ChannelName, attempts)
+		targetID = entry.ChannelID
+	} else if entry.DeleteChannel {
+		description += " This channel stays open until it is saved. Messages sent from now on are not included."
+		targetID = entry.ChannelID
+	}
+	if targetID == "" {
+		return
+	}
+
+	_, err := s.ChannelMessageSendEmbed(targetID, &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       0xED4245,
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "Last Error",
+				Value: fmt.Sprintf("`%s`", truncate(cause.Error(), 1000)),
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Sushi Tickets • Transcript System",
+		},
+	})
+	if err != nil {
+		log.Printf("send transcript outbox alert failed: %v", err)
+		return
+	}
+	if err := queries.MarkTranscriptOutboxAlerted(context.Background(), entry.ID); err != nil {
+		log.Printf("mark transcript outbox alerted failed: %v", err)
+	}
+}
+
+// finishTicketClose clears a closed ticket's state and deletes its channel.
+// A channel that is already gone counts as deleted.
+func finishTicketClose(s *discordgo.Session, guildID string, serverID int64, channelID string) error {
+	closingTickets.Store(channelID, struct{}{})
+	defer closingTickets.Delete(channelID)
+
+	closeModmailTicket(s, guildID, channelID)
+
+	if queries != nil && serverID != 0 {
+		ctx := context.Background()
+		if err := queries.DeleteActiveTicketByChannel(ctx, serverID, channelID); err != nil {
+			log.Printf("delete active ticket failed: %v", err)
+		}
+		cleanupTicketVoice(s, serverID, channelID)
+		if err := queries.DeleteTicketTransfers(ctx, serverID, channelID); err != nil {
+			log.Printf("delete ticket transfers failed: %v", err)
+		}
+		if err := queries.DeleteTicketMessageCaptures(ctx, serverID, channelID); err != nil {
+			log.Printf("delete captured messages failed: %v", err)
+		}
+	}
+
+	if _, err := s.ChannelDelete(channelID); err != nil {
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,70 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultStorageQuota applies to guilds without a quota of their own. 0 is
+// unlimited.
+var defaultStorageQuota int64
+
+// SetStorageQuota sets the storage quota, in bytes, for guilds without one
+// of their own. 0 leaves them unlimited.
+func SetStorageQuota(bytes int64) {
+	defaultStorageQuota = bytes
+}
+
+// overStorageQuota reports whether the guild has used up its storage quota,
+// with its usage and quota for the warning. Usage that cannot be loaded
+// counts as under quota, so a database hiccup never costs a transcript.
+func overStorageQuota(ctx context.Context, serverID int64) (bool, int64, int64) {
+	usage, err := queries.GetGuildStorageUsage(ctx, serverID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("load storage usage for %d failed: %v", serverID, err)
+		return false, 0, 0
+	}
+	quota := usage.Quota(defaultStorageQuota)
+	used := usage.TotalBytes()
+	return quota > 0 && used >= quota, used, quota
+}
+
+// warnStorageQuota tells staff in the transcript log channel that a ticket
+// was saved without its messages.
+func warnStorageQuota(s *discordgo.Session, rec transcriptRecord, used, quota int64) {
+	if s == nil || rec.LogChannelID == "" {
+		return
+	}
+	_, err := s.ChannelMessageSendEmbed(rec.LogChannelID, &discordgo.MessageEmbed{
+		Title: "⚠️ Transcript Storage Full",
+		Description: fmt.Sprintf("This server uses %s of its %s transcript storage. The transcript for **#%s** was saved without messages or attachments. Shorten the retention period or ask the bot owner for more space.",
+			formatBytes(used), formatBytes(quota), rec.ChannelName),
+		Color:     0xFEE75C,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Sushi Tickets • Transcript System",
+		},
+	})
+	if err != nil {
+		log.Printf("send storage quota warning failed: %v", err)
+	}
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
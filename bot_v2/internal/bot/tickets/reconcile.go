@@ -0,0 +1,184 @@
+package tickets
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
+	"github.com/bwmarrin/discordgo"
+)
+
+const reconcileInterval = 30 * time.Minute
+
+var reconcileOnce sync.Once
+
+// closingTickets holds the channels finishTicketClose is working on. Their
+// active_ticket row is already gone while the channel still exists, so the
+// reconciler must not adopt them.
+var closingTickets sync.Map
+
+type reconcileResult struct {
+	Active  int
+	Removed int
+	Adopted int
+	Flagged int
+}
+
+// StartReconciler reconciles active tickets against Discord right away and
+// then every reconcileInterval. Safe to call more than once.
+func StartReconciler(s *discordgo.Session) {
+	if queries == nil {
+		return
+	}
+	reconcileOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(reconcileInterval)
+			defer ticker.Stop()
+
+			reconcileAllGuilds(s)
+			for range ticker.C {
+				reconcileAllGuilds(s)
+			}
+		}()
+	})
+}
+
+func reconcileAllGuilds(s *discordgo.Session) {
+	if s == nil || s.State == nil {
+		return
+	}
+
+	s.State.RLock()
+	guildIDs := make([]string, 0, len(s.State.Guilds))
+	for _, g := range s.State.Guilds {
+		guildIDs = append(guildIDs, g.ID)
+	}
+	s.State.RUnlock()
+
+	total := reconcileResult{}
+	checked := 0
+	for _, guildID := range guildIDs {
+		result, err := reconcileGuild(s, guildID)
+		if err != nil {
+			log.Printf("reconcile guild %s failed: %v", guildID, err)
+			continue
+		}
+		checked++
+		total.Active += result.Active
+		total.Removed += result.Removed
+		total.Adopted += result.Adopted
+		total.Flagged += result.Flagged
+	}
+
+	log.Printf("Ticket reconcile: %d guilds, %d active, %d orphans removed, %d adopted, %d flagged", checked, total.Active, total.Removed, total.Adopted, total.Flagged)
+
+	metrics.Add("tickets_reconcile_runs_total", "Completed active ticket reconcile passes.", 1)
+	metrics.Add("tickets_reconcile_removed_total", "Active ticket rows removed because the channel is gone.", float64(total.Removed))
+	metrics.Add("tickets_reconcile_adopted_total", "Ticket channels adopted into active_ticket.", float64(total.Adopted))
+	metrics.Set("tickets_reconcile_flagged", "Ticket channels without a row whose opener left the guild.", float64(total.Flagged))
+	metrics.Set("tickets_active", "Active tickets after the last reconcile pass.", float64(total.Active))
+	metrics.Set("tickets_reconcile_last_run_timestamp_seconds", "Unix time of the last reconcile pass.", float64(time.Now().Unix()))
+}
+
+// reconcileGuild drops active_ticket rows whose channel no longer exists and
+// adopts ticket channels that have no row. Channels whose opener left the
+// guild are only flagged so staff can close them by hand.
+func reconcileGuild(s *discordgo.Session, guildID string) (reconcileResult, error) {
+	result := reconcileResult{}
+	ctx := context.Background()
+
+	serverID, err := strconv.ParseInt(guildID, 10, 64)
+	if err != nil {
+		return result, err
+	}
+	if _, err := queries.GetServerConfig(ctx, serverID); err != nil {
+		// Guild never configured; active_ticket rows require a config row.
+		return result, nil
+	}
+
+	// Rows are loaded before the channel list: a ticket's channel is created
+	// before its row, so every loaded row's channel is in the list unless it
+	// was really deleted.
+	rows, err := queries.GetActiveTicketsByServer(ctx, serverID)
+	if err != nil {
+		return result, err
+	}
+	// The REST list is authoritative; the state cache may still be loading.
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return result, err
+	}
+
+	existing := make(map[string]*discordgo.Channel, len(channels))
+	for _, ch := range channels {
+		existing[ch.ID] = ch
+	}
+
+	tracked := make(map[string]struct{}, len(rows))
+	for _, row := range rows {
+		if _, ok := existing[row.ChannelID]; ok {
+			tracked[row.ChannelID] = struct{}{}
+			result.Active++
+			continue
+		}
+		if err := queries.DeleteActiveTicketByChannel(ctx, serverID, row.ChannelID); err != nil {
+			log.Printf("delete orphaned active ticket failed: %v", err)
+			continue
+		}
+		if err := queries.DeleteTicketMessageCaptures(ctx, serverID, row.ChannelID); err != nil {
+			log.Printf("delete orphaned captured messages failed: %v", err)
+		}
+		result.Removed++
+	}
+
+	for _, ch := range channels {
+		if ch.Type != discordgo.ChannelTypeGuildText {
+			continue
+		}
+		if _, ok := tracked[ch.ID]; ok {
+			continue
+		}
+		opener := ticketOpenerFromTopic(ch.Topic)
+		if opener == "" {
+			continue
+		}
+		if ticketClosing(ctx, s, serverID, ch.ID) {
+			continue
+		}
+		if !isGuildMember(s, guildID, opener) {
+			log.Printf("Ticket reconcile: channel %s in guild %s has no active ticket and opener %s left", ch.ID, guildID, opener)
+			result.Flagged++
+			continue
+		}
+
+		createdAt, err := discordgo.SnowflakeTimestamp(ch.ID)
+		if err != nil {
+			createdAt = time.Now()
+		}
+		if err := queries.CreateActiveTicket(ctx, serverID, opener, ch.ID, createdAt.Unix()); err != nil {
+			log.Printf("adopt ticket channel failed: %v", err)
+			continue
+		}
+		result.Adopted++
+		result.Active++
+	}
+
+	return result, nil
+}
+
+// ticketClosing reports whether a ticket channel without a row is on its way
+// out rather than untracked: it is being closed right now, its transcript is
+// waiting in the outbox to delete it, or it was deleted since the snapshot.
+func ticketClosing(ctx context.Context, s *discordgo.Session, serverID int64, channelID string) bool {
+	if _, ok := closingTickets.Load(channelID); ok {
+		return true
+	}
+	if _, err := queries.GetTranscriptOutboxByChannel(ctx, serverID, channelID); err == nil {
+		return true
+	}
+	_, err := s.Channel(channelID)
+	return err != nil
+}
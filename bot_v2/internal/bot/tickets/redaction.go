@@ -0,0 +1,41 @@
+package tickets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/jackc/pgx/v5"
+)
+
+// loadRedaction returns the guild's redactor, nil when it has no rules, and
+// whether it keeps the unredacted original. Originals are only kept while
+// transcripts are encrypted at rest.
+func loadRedaction(ctx context.Context, serverID int64) (*transcript.Redactor, bool, error) {
+	cfg, err := queries.GetTranscriptRedaction(ctx, serverID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var rules []transcript.RedactionRule
+	if err := json.Unmarshal(cfg.Rules, &rules); err != nil {
+		return nil, false, fmt.Errorf("decode redaction rules: %w", err)
+	}
+	redactor, err := transcript.NewRedactor(cfg.Detectors, rules)
+	if err != nil {
+		return nil, false, err
+	}
+
+	keepOriginal := cfg.KeepOriginal && redactor != nil
+	if keepOriginal && !storageClient.EncryptsTranscripts() {
+		log.Printf("server %d keeps unredacted transcripts, but transcript encryption is off; the original is not kept", serverID)
+		keepOriginal = false
+	}
+	return redactor, keepOriginal, nil
+}
@@ -0,0 +1,108 @@
+package tickets
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+)
+
+const (
+	retentionInterval  = time.Hour
+	retentionBatchSize = 100
+)
+
+var retentionOnce sync.Once
+
+// StartRetentionPurger deletes transcripts past their guild's retention
+// period right away and then every retentionInterval. Safe to call more than
+// once.
+func StartRetentionPurger() {
+	if queries == nil || storageClient == nil {
+		return
+	}
+	retentionOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(retentionInterval)
+			defer ticker.Stop()
+
+			purgeExpiredTranscripts()
+			for range ticker.C {
+				purgeExpiredTranscripts()
+			}
+		}()
+	})
+}
+
+func purgeExpiredTranscripts() {
+	ctx := context.Background()
+	purged, failed := 0, 0
+	for {
+		items, err := queries.ListExpiredTranscripts(ctx, time.Now().Unix(), retentionBatchSize)
+		if err != nil {
+			log.Printf("list expired transcripts failed: %v", err)
+			break
+		}
+
+		progress := false
+		for _, item := range items {
+			done, err := purgeTranscript(ctx, item)
+			if err != nil {
+				log.Printf("purge transcript %d failed: %v", item.ID, err)
+				failed++
+				continue
+			}
+			progress = true
+			if done {
+				purged++
+			}
+		}
+		// Stop on a short batch, or when every item failed so a broken
+		// object does not spin the loop.
+		if len(items) < retentionBatchSize || !progress {
+			break
+		}
+	}
+
+	if purged > 0 || failed > 0 {
+		log.Printf("Transcript retention: %d purged, %d failed", purged, failed)
+	}
+	metrics.Add("transcripts_purged_total", "Transcripts deleted by the retention policy.", float64(purged))
+	metrics.Add("transcripts_purge_failures_total", "Transcript purges that failed and will be retried.", float64(failed))
+}
+
+// purgeTranscript deletes one transcript in a crash-safe order: the purge is
+// recorded first, storage objects are deleted next, and the row and its audit
+// entry go last. A crash at any step leaves the purge row, so the next run
+// finishes the job and no row ever points at deleted objects for long. It
+// reports false when the transcript was skipped.
+func purgeTranscript(ctx context.Context, item db.ExpiredTranscript) (bool, error) {
+	if !item.Purging {
+		started, err := queries.BeginTranscriptPurge(ctx, item.ID, time.Now().Unix())
+		if err != nil {
+			return false, err
+		}
+		if !started {
+			// Put on legal hold or deleted since it was listed.
+			return false, nil
+		}
+	}
+
+	deleted, err := storageClient.DeletePrefix(ctx, transcript.ObjectPrefix(item.StorageKey))
+	if err != nil {
+		return false, err
+	}
+	if err := storageClient.DeleteObject(ctx, item.StorageKey); err != nil {
+		return false, err
+	}
+	deleted++
+
+	if err := queries.FinishTranscriptPurge(ctx, item.ID, int32(deleted), time.Now().Unix()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -6,12 +6,12 @@ import (
 )
 
 var queries *db.Queries
-var storageClient *storage.Client
+var storageClient storage.Client
 
 func SetQueries(q *db.Queries) {
 	queries = q
 }
 
-func SetStorage(c *storage.Client) {
+func SetStorage(c storage.Client) {
 	storageClient = c
 }
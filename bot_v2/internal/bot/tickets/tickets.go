@@ -1,8 +1,10 @@
 package tickets
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -10,7 +12,10 @@ import (
 	"time"
 
 	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/Sush1sui/FNS_BOT/internal/webhook"
 	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -38,6 +43,34 @@ func HandleComponentInteraction(s *discordgo.Session, i *discordgo.InteractionCr
 		handlePanelOpen(s, i, int32(panelID))
 	case data.CustomID == closeTicketID:
 		handleCloseTicket(s, i)
+	case data.CustomID == ticketVoiceID:
+		handleTicketVoice(s, i)
+	case data.CustomID == escalateTicketID:
+		handleEscalateTicket(s, i)
+	case data.CustomID == escalatePanelSelectID:
+		if len(data.Values) == 0 {
+			return
+		}
+		panelID, ok := parsePanelID(data.Values[0], "")
+		if !ok {
+			return
+		}
+		handleEscalatePanelSelect(s, i, panelID)
+	case data.CustomID == modmailGuildSelectID:
+		if len(data.Values) == 0 {
+			return
+		}
+		handleModmailGuildSelect(s, i, data.Values[0])
+	case strings.HasPrefix(data.CustomID, modmailPanelPrefix):
+		guildID := strings.TrimPrefix(data.CustomID, modmailPanelPrefix)
+		if guildID == "" || len(data.Values) == 0 {
+			return
+		}
+		panelID, ok := parsePanelID(data.Values[0], "")
+		if !ok {
+			return
+		}
+		handleModmailPanelSelect(s, i, guildID, panelID)
 	}
 }
 
@@ -47,6 +80,15 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 
 	data := i.ModalSubmitData()
+	if strings.HasPrefix(data.CustomID, modmailModalPrefix) {
+		guildID, panelID, ok := parseModmailModalID(data.CustomID)
+		if !ok {
+			return
+		}
+		respondDeferred(s, i)
+		openModmailTicket(s, i, guildID, panelID, modalQnA(data))
+		return
+	}
 	if !strings.HasPrefix(data.CustomID, panelModalPrefix) {
 		return
 	}
@@ -56,15 +98,7 @@ func HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	questions, answers := extractModalAnswers(data)
-	qna := make([]QnA, 0, len(questions))
-	for idx, q := range questions {
-		ans := ""
-		if idx < len(answers) {
-			ans = answers[idx]
-		}
-		qna = append(qna, QnA{Question: q, Answer: ans})
-	}
+	qna := modalQnA(data)
 
 	respondDeferred(s, i)
 	if err := openTicket(s, i, panelID, qna); err != nil {
@@ -87,7 +121,7 @@ func handlePanelOpen(s *discordgo.Session, i *discordgo.InteractionCreate, panel
 	}
 
 	if len(questions) > 0 {
-		showQuestionsModal(s, i, panelID, questions)
+		showQuestionsModal(s, i, fmt.Sprintf("%s%d", panelModalPrefix, panelID), questions)
 		return
 	}
 
@@ -104,38 +138,54 @@ func handlePanelOpen(s *discordgo.Session, i *discordgo.InteractionCreate, panel
 }
 
 func openTicket(s *discordgo.Session, i *discordgo.InteractionCreate, panelID int32, qna []QnA) error {
-	if queries == nil {
-		return fmt.Errorf("queries not set")
-	}
 	if i.GuildID == "" || i.Member == nil || i.Member.User == nil {
 		return fmt.Errorf("missing guild/member")
 	}
 
-	ctx := context.Background()
-	serverID, err := strconv.ParseInt(i.GuildID, 10, 64)
+	channel, err := createTicketChannel(s, i.GuildID, i.Member.User, i.ID, panelID, qna)
 	if err != nil {
 		return err
 	}
 
+	editEphemeral(s, i, fmt.Sprintf("Ticket created! Please check <#%s>", channel.ID))
+	return nil
+}
+
+// createTicketChannel creates the ticket channel for user in guildID and
+// registers it as an active ticket. nonce feeds the numbered channel name.
+func createTicketChannel(s *discordgo.Session, guildID string, user *discordgo.User, nonce string, panelID int32, qna []QnA) (*discordgo.Channel, error) {
+	if queries == nil {
+		return nil, fmt.Errorf("queries not set")
+	}
+	if guildID == "" || user == nil {
+		return nil, fmt.Errorf("missing guild/member")
+	}
+
+	ctx := context.Background()
+	serverID, err := strconv.ParseInt(guildID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
 	panel, err := queries.GetPanelConfigByID(ctx, serverID, panelID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	welcomeMsg, hasWelcome, err := queries.GetPanelWelcome(ctx, panelID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	serverConfig, _ := queries.GetServerConfig(ctx, serverID)
 	if serverConfig.MaxTicketPerUser > 0 {
-		count, err := queries.CountActiveTicketsByUser(ctx, serverID, i.Member.User.ID)
+		count, err := queries.CountActiveTicketsByUser(ctx, serverID, user.ID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if count >= int64(serverConfig.MaxTicketPerUser) {
 			if s != nil && s.State != nil {
-				channels, err := queries.GetActiveTicketChannelsByUser(ctx, serverID, i.Member.User.ID)
+				channels, err := queries.GetActiveTicketChannelsByUser(ctx, serverID, user.ID)
 				if err == nil {
 					for _, channelID := range channels {
 						if _, err := s.State.Channel(channelID); err == nil {
@@ -145,14 +195,14 @@ func openTicket(s *discordgo.Session, i *discordgo.InteractionCreate, panelID in
 							log.Printf("delete stale active ticket failed: %v", err)
 						}
 					}
-					count, err = queries.CountActiveTicketsByUser(ctx, serverID, i.Member.User.ID)
+					count, err = queries.CountActiveTicketsByUser(ctx, serverID, user.ID)
 					if err != nil {
-						return err
+						return nil, err
 					}
 				}
 			}
 			if count >= int64(serverConfig.MaxTicketPerUser) {
-				return errMaxTickets
+				return nil, errMaxTickets
 			}
 		}
 	}
@@ -165,15 +215,15 @@ func openTicket(s *discordgo.Session, i *discordgo.InteractionCreate, panelID in
 		parentID = panel.CategoryID.String
 	}
 
-	channelName := buildTicketChannelName(serverConfig.TicketNameStyle, i.Member.User.Username, i.ID)
-	channelTopic := fmt.Sprintf("ticket_opener:%s panel:%d", i.Member.User.ID, panelID)
+	channelName := buildTicketChannelName(serverConfig.TicketNameStyle, user.Username, nonce)
+	channelTopic := fmt.Sprintf("ticket_opener:%s panel:%d", user.ID, panelID)
 
-	overwrites, err := buildPermissionOverwrites(ctx, s, i, serverID, panel.MentionRolesOnOpen, userPerms, staffPerms)
+	overwrites, err := buildPermissionOverwrites(ctx, s, guildID, user.ID, serverID, panel.MentionRolesOnOpen, userPerms, staffPerms)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	channel, err := s.GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
+	channel, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
 		Name:                 channelName,
 		Type:                 discordgo.ChannelTypeGuildText,
 		ParentID:             parentID,
@@ -181,30 +231,35 @@ func openTicket(s *discordgo.Session, i *discordgo.InteractionCreate, panelID in
 		PermissionOverwrites: overwrites,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := queries.CreateActiveTicket(ctx, serverID, i.Member.User.ID, channel.ID, time.Now().Unix()); err != nil {
+	openedAt := time.Now().Unix()
+	if err := queries.CreateActiveTicket(ctx, serverID, user.ID, channel.ID, openedAt); err != nil {
 		log.Printf("create active ticket failed: %v", err)
 	}
+	webhook.Emit(serverID, webhook.TicketOpened, channel.ID, webhook.TicketData{
+		ChannelID: channel.ID,
+		UserID:    user.ID,
+		PanelID:   panelID,
+		OpenedAt:  openedAt,
+	})
 
-	SendWelcomeMessage(s, channel.ID, i.Member.User, panel.MentionRolesOnOpen, welcomeMsg, hasWelcome, qna)
-	editEphemeral(s, i, fmt.Sprintf("Ticket created! Please check <#%s>", channel.ID))
-	return nil
+	SendWelcomeMessage(s, channel.ID, user, panel.MentionRolesOnOpen, welcomeMsg, hasWelcome, qna)
+	return channel, nil
 }
 
-func buildPermissionOverwrites(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, serverID int64, mentionRoles []string, userPerms, staffPerms int64) ([]*discordgo.PermissionOverwrite, error) {
+func buildPermissionOverwrites(ctx context.Context, s *discordgo.Session, guildID, userID string, serverID int64, mentionRoles []string, userPerms, staffPerms int64) ([]*discordgo.PermissionOverwrite, error) {
 	overwrites := make([]*discordgo.PermissionOverwrite, 0, 6)
 	added := map[string]struct{}{}
 
 	overwrites = append(overwrites, &discordgo.PermissionOverwrite{
-		ID:   i.GuildID,
+		ID:   guildID,
 		Type: discordgo.PermissionOverwriteTypeRole,
 		Deny: discordgo.PermissionViewChannel,
 	})
-	added[i.GuildID] = struct{}{}
+	added[guildID] = struct{}{}
 
-	userID := i.Member.User.ID
 	overwrites = append(overwrites, &discordgo.PermissionOverwrite{
 		ID:    userID,
 		Type:  discordgo.PermissionOverwriteTypeMember,
@@ -290,45 +345,93 @@ func handleCloseTicket(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
-	saveTranscriptOnClose(s, i)
+	serverID, _ := strconv.ParseInt(i.GuildID, 10, 64)
+	closed := closedTicketData(s, i, serverID, closeReasonClosed)
 
-	if queries != nil {
-		serverID, err := strconv.ParseInt(i.GuildID, 10, 64)
-		if err == nil {
-			if err := queries.DeleteActiveTicketByChannel(context.Background(), serverID, channelID); err != nil {
-				log.Printf("delete active ticket failed: %v", err)
-			}
+	// The channel is deleted only once its transcript is stored, so a
+	// failed upload never loses the conversation.
+	entry, err := saveTranscriptOnClose(s, i)
+	if err != nil {
+		log.Printf("stage transcript failed: %v", err)
+		editEphemeral(s, i, "Failed to save the transcript, so the ticket was left open. Please try again.")
+		return
+	}
+	if entry == nil {
+		if err := finishTicketClose(s, i.GuildID, serverID, channelID); err != nil {
+			editEphemeral(s, i, "Failed to close ticket.")
+			return
 		}
+		webhook.Emit(serverID, webhook.TicketClosed, channelID, closed)
+		return
 	}
+	// The ticket is closed from here on, even while its transcript waits
+	// in the outbox.
+	webhook.Emit(serverID, webhook.TicketClosed, channelID, closed)
 
-	if _, err := s.ChannelDelete(channelID); err != nil {
-		editEphemeral(s, i, "Failed to close ticket.")
-		return
+	if _, err := processOutboxEntry(s, *entry); err != nil {
+		if errors.Is(err, errOutboxBusy) {
+			editEphemeral(s, i, "This ticket's transcript is already being saved.")
+			return
+		}
+		log.Printf("save transcript failed: %v", err)
+		editEphemeral(s, i, "The transcript could not be stored yet. It will be retried, and this channel is deleted once it is saved.")
+	}
+}
+
+// closedTicketData describes the interaction's ticket for its ticket.closed
+// webhook. It reads the opener from the active ticket, so it must run
+// before the ticket is closed.
+func closedTicketData(s *discordgo.Session, i *discordgo.InteractionCreate, serverID int64, reason string) webhook.TicketData {
+	data := webhook.TicketData{
+		ChannelID:   i.ChannelID,
+		ClosedAt:    time.Now().Unix(),
+		CloseReason: reason,
+	}
+	if i.Member != nil && i.Member.User != nil {
+		data.ClosedBy = i.Member.User.ID
+	}
+	if queries != nil {
+		if info, err := queries.GetActiveTicketByChannel(context.Background(), serverID, i.ChannelID); err == nil {
+			data.UserID = info.UserID
+			data.OpenedAt = info.CreatedAt
+		}
+	}
+	if ch := getChannelFromInteraction(s, i); ch != nil {
+		data.PanelID, _ = ticketPanelFromTopic(ch.Topic)
 	}
+	return data
 }
 
-func saveTranscriptOnClose(s *discordgo.Session, i *discordgo.InteractionCreate) {
+// saveTranscriptOnClose stages the ticket's transcript in the outbox. It
+// returns nil without an error when the server keeps no transcripts, and the
+// existing entry when the ticket is already closing.
+func saveTranscriptOnClose(s *discordgo.Session, i *discordgo.InteractionCreate) (*db.TranscriptOutbox, error) {
 	if queries == nil || storageClient == nil {
-		return
+		return nil, nil
 	}
 	if i == nil || i.GuildID == "" || i.ChannelID == "" {
-		return
+		return nil, nil
 	}
 
 	serverID, err := strconv.ParseInt(i.GuildID, 10, 64)
 	if err != nil {
-		return
+		return nil, nil
 	}
 
 	serverConfig, err := queries.GetServerConfig(context.Background(), serverID)
 	if err != nil || !serverConfig.TicketTranscriptCid.Valid || serverConfig.TicketTranscriptCid.String == "" {
-		return
+		return nil, nil
+	}
+
+	if entry, err := queries.GetTranscriptOutboxByChannel(context.Background(), serverID, i.ChannelID); err == nil {
+		return &entry, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("load outbox entry: %w", err)
 	}
 
 	messages, err := fetchAllMessages(s, i.ChannelID)
 	if err != nil {
-		log.Printf("fetch transcript messages failed: %v", err)
-		return
+		return nil, fmt.Errorf("fetch transcript messages: %w", err)
 	}
 
 	openedAt := int64(0)
@@ -355,72 +458,265 @@ func saveTranscriptOnClose(s *discordgo.Session, i *discordgo.InteractionCreate)
 	if i.Member != nil && i.Member.User != nil {
 		closedBy = i.Member.User.ID
 	}
-	closedAt := time.Now().Unix()
 
-	content, totalAttachments, totalEmbeds, participants := buildTranscriptContent(messages)
+	channelName := ""
+	var panelID int32
+	if ch := getChannelFromInteraction(s, i); ch != nil {
+		channelName = ch.Name
+		panelID, _ = ticketPanelFromTopic(ch.Topic)
+	} else {
+		channelName = i.ChannelID
+	}
+
+	history := loadMessageHistory(context.Background(), serverID, i.ChannelID)
+	messages = mergeCapturedMessages(messages, history)
+	attributeModmailMessages(s, i.GuildID, i.ChannelID, messages)
+
+	entry, err := stageTranscript(s, transcriptRecord{
+		GuildID:      i.GuildID,
+		ServerID:     serverID,
+		ChannelID:    i.ChannelID,
+		ChannelName:  channelName,
+		UserID:       userID,
+		Username:     username,
+		OpenedAt:     openedAt,
+		ClosedAt:     time.Now().Unix(),
+		ClosedBy:     closedBy,
+		CloseReason:  closeReasonClosed,
+		PanelID:      panelID,
+		LogChannelID: serverConfig.TicketTranscriptCid.String,
+		AttachHTML:   serverConfig.TranscriptLogHtml,
+		AttachFormat: serverConfig.TranscriptLogFormat,
+		DMOpener:     serverConfig.TranscriptDmOpener,
+		History:      history,
+	}, messages, true)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
 
-	payload := transcriptPayload{
-		TicketID: i.ChannelID,
-		Username: username,
-		UserID:   userID,
-		Messages: content,
-		Metadata: transcriptMetadata{
-			TicketOpenedAt:   time.Unix(openedAt, 0).UTC().Format(time.RFC3339),
-			TicketClosedAt:   time.Unix(closedAt, 0).UTC().Format(time.RFC3339),
-			ClosedBy:         transcriptClosedBy{ID: closedBy, Username: usernameOrID(s, i.GuildID, closedBy)},
-			TotalMessages:    len(content),
+// transcriptRecord describes a finished ticket for stageTranscript. It is
+// stored with the outbox entry.
+type transcriptRecord struct {
+	GuildID      string
+	ServerID     int64
+	ChannelID    string
+	ChannelName  string
+	UserID       string
+	Username     string
+	OpenedAt     int64
+	ClosedAt     int64
+	ClosedBy     string
+	CloseReason  string
+	PanelID      int32
+	LogChannelID string
+	AttachHTML   bool
+	// AttachFormat is a text format to attach to the log message as well,
+	// or "" for none.
+	AttachFormat string
+	DMOpener     bool
+	// AttachmentBytes is the size of the archived attachments, counted
+	// against the guild's storage once the transcript is saved.
+	AttachmentBytes int64
+	History         map[string]*messageHistory `json:"-"`
+}
+
+// stageTranscript builds the transcript payload from messages and stages it
+// in the outbox, where processOutboxEntry picks it up. deleteChannel makes the
+// entry delete the ticket channel once the transcript is stored. The guild's
+// redaction rules are applied before anything is staged.
+func stageTranscript(s *discordgo.Session, rec transcriptRecord, messages []*discordgo.Message, deleteChannel bool) (db.TranscriptOutbox, error) {
+	ctx := context.Background()
+	content, totalAttachments, totalEmbeds, participants := buildTranscriptContent(messages)
+	applyMessageHistory(content, rec.History)
+	totalMessages := len(content)
+	storageKey := fmt.Sprintf("transcripts/%d/%s/%d.json", rec.ServerID, rec.ChannelID, rec.ClosedAt)
+
+	// A guild over its storage quota keeps only the transcript's metadata.
+	overQuota, used, quota := overStorageQuota(ctx, rec.ServerID)
+	var mentions *transcript.Mentions
+	if overQuota {
+		warnStorageQuota(s, rec, used, quota)
+		content = []transcript.Message{}
+	} else {
+		// Archive attachments before the channel goes away and the CDN links die.
+		rec.AttachmentBytes = archiveAttachments(ctx, storageKey, content)
+		mentions = resolveMentions(s, rec.GuildID, messages, content)
+	}
+
+	payload := transcript.Payload{
+		SchemaVersion: transcript.SchemaVersion,
+		TicketID:      rec.ChannelID,
+		Username:      rec.Username,
+		UserID:        rec.UserID,
+		Messages:      content,
+		Mentions:      mentions,
+		Metadata: transcript.Metadata{
+			TicketOpenedAt:   time.Unix(rec.OpenedAt, 0).UTC().Format(time.RFC3339),
+			TicketClosedAt:   time.Unix(rec.ClosedAt, 0).UTC().Format(time.RFC3339),
+			ClosedBy:         transcript.ClosedBy{ID: rec.ClosedBy, Username: usernameOrID(s, rec.GuildID, rec.ClosedBy)},
+			CloseReason:      rec.CloseReason,
+			TotalMessages:    totalMessages,
 			TotalAttachments: totalAttachments,
 			TotalEmbeds:      totalEmbeds,
 			Participants:     participants,
+			VoiceActivity:    loadVoiceActivity(ctx, rec.ServerID, rec.ChannelID, rec.ClosedAt),
+			Transfers:        loadTransfers(ctx, s, rec.GuildID, rec.ServerID, rec.ChannelID),
+			MetadataOnly:     overQuota,
 		},
 	}
 
+	redactor, keepOriginal, err := loadRedaction(ctx, rec.ServerID)
+	if err != nil {
+		return db.TranscriptOutbox{}, fmt.Errorf("load redaction rules: %w", err)
+	}
+	var original []byte
+	if keepOriginal && !overQuota {
+		if original, err = json.Marshal(payload); err != nil {
+			return db.TranscriptOutbox{}, fmt.Errorf("marshal original transcript: %w", err)
+		}
+	}
+	payload.Metadata.Redactions = redactor.Redact(payload.Messages)
+	payload.Metadata.OriginalKept = original != nil
+
 	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("marshal transcript failed: %v", err)
-		return
+		return db.TranscriptOutbox{}, fmt.Errorf("marshal transcript: %w", err)
+	}
+	record, err := json.Marshal(rec)
+	if err != nil {
+		return db.TranscriptOutbox{}, fmt.Errorf("marshal transcript record: %w", err)
+	}
+
+	entry, err := queries.CreateTranscriptOutbox(ctx, db.CreateTranscriptOutboxParams{
+		ServerConfigID: rec.ServerID,
+		ChannelID:      rec.ChannelID,
+		Record:         record,
+		Payload:        data,
+		Original:       original,
+		StorageKey:     storageKey,
+		DeleteChannel:  deleteChannel,
+		CreatedAt:      time.Now().Unix(),
+	})
+	if err != nil {
+		return db.TranscriptOutbox{}, fmt.Errorf("stage transcript: %w", err)
 	}
+	return entry, nil
+}
 
-	storageKey := fmt.Sprintf("transcripts/%d/%s/%d.json", serverID, i.ChannelID, closedAt)
-	if err := storageClient.UploadTranscript(context.Background(), storageKey, data); err != nil {
-		log.Printf("upload transcript failed: %v", err)
-		return
+// saveStagedTranscript uploads a staged payload, saves its transcript row and
+// posts the transcript log. The upload overwrites the same key and an
+// existing row is reused, so repeating it after a crash is safe.
+func saveStagedTranscript(s *discordgo.Session, entry db.TranscriptOutbox) (int32, error) {
+	ctx := context.Background()
+
+	var rec transcriptRecord
+	if err := json.Unmarshal(entry.Record, &rec); err != nil {
+		return 0, fmt.Errorf("decode transcript record: %w", err)
+	}
+	var payload transcript.Payload
+	if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+		return 0, fmt.Errorf("decode transcript payload: %w", err)
 	}
 
-	row, err := queries.CreateTranscript(context.Background(), db.CreateTranscriptParams{
-		ServerConfigID:   serverID,
-		TicketID:         pgtype.Text{String: i.ChannelID, Valid: true},
-		Username:         pgtype.Text{String: username, Valid: username != ""},
-		UserID:           pgtype.Text{String: userID, Valid: userID != ""},
-		OpenedAt:         openedAt,
-		ClosedAt:         closedAt,
-		ClosedBy:         closedBy,
-		StorageKey:       storageKey,
-		TotalMessages:    pgtype.Int4{Int32: int32(len(content)), Valid: true},
-		TotalAttachments: pgtype.Int4{Int32: int32(totalAttachments), Valid: true},
-		TotalEmbeds:      pgtype.Int4{Int32: int32(totalEmbeds), Valid: true},
-	})
+	transcriptBytes, err := storageClient.UploadTranscript(ctx, entry.StorageKey, entry.Payload)
 	if err != nil {
-		log.Printf("create transcript row failed: %v", err)
-		return
+		return 0, fmt.Errorf("upload transcript: %w", err)
 	}
+	if len(entry.Original) > 0 {
+		originalBytes, err := storageClient.UploadTranscript(ctx, transcript.OriginalKey(entry.StorageKey), entry.Original)
+		if err != nil {
+			return 0, fmt.Errorf("upload original transcript: %w", err)
+		}
+		transcriptBytes += originalBytes
+	}
+
+	meta := payload.Metadata
+	rowID, err := queries.GetTranscriptIDByStorageKey(ctx, rec.ServerID, entry.StorageKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		row, err := queries.CreateTranscript(ctx, db.CreateTranscriptParams{
+			ServerConfigID:   rec.ServerID,
+			TicketID:         pgtype.Text{String: rec.ChannelID, Valid: true},
+			Username:         pgtype.Text{String: rec.Username, Valid: rec.Username != ""},
+			UserID:           pgtype.Text{String: rec.UserID, Valid: rec.UserID != ""},
+			OpenedAt:         rec.OpenedAt,
+			ClosedAt:         rec.ClosedAt,
+			ClosedBy:         rec.ClosedBy,
+			StorageKey:       entry.StorageKey,
+			TotalMessages:    pgtype.Int4{Int32: int32(meta.TotalMessages), Valid: true},
+			TotalAttachments: pgtype.Int4{Int32: int32(meta.TotalAttachments), Valid: true},
+			TotalEmbeds:      pgtype.Int4{Int32: int32(meta.TotalEmbeds), Valid: true},
+			CloseReason:      rec.CloseReason,
+			PanelID:          pgtype.Int4{Int32: rec.PanelID, Valid: rec.PanelID != 0},
+			ParticipantIds:   participantIDs(meta.Participants),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("create transcript row: %w", err)
+		}
+		rowID = row.ID
+	} else if err != nil {
+		return 0, fmt.Errorf("look up transcript row: %w", err)
+	}
+	if err := queries.UpsertTranscriptSearch(ctx, rowID, rec.ServerID, transcript.SearchText(payload)); err != nil {
+		log.Printf("index transcript %d failed: %v", rowID, err)
+	}
+	if err := queries.RecordTranscriptUsage(ctx, db.RecordTranscriptUsageParams{
+		TranscriptID:    rowID,
+		ServerConfigID:  rec.ServerID,
+		TranscriptBytes: transcriptBytes,
+		AttachmentBytes: rec.AttachmentBytes,
+		UpdatedAt:       time.Now().Unix(),
+	}); err != nil {
+		return 0, fmt.Errorf("record storage usage: %w", err)
+	}
+	if err := queries.MarkTranscriptOutboxStored(ctx, entry.ID, rowID); err != nil {
+		return 0, fmt.Errorf("mark transcript stored: %w", err)
+	}
+	webhook.Emit(rec.ServerID, webhook.TranscriptSaved, strconv.Itoa(int(rowID)), webhook.TranscriptData{
+		TranscriptID:     rowID,
+		ChannelID:        rec.ChannelID,
+		UserID:           rec.UserID,
+		ClosedBy:         rec.ClosedBy,
+		CloseReason:      rec.CloseReason,
+		TotalMessages:    meta.TotalMessages,
+		TotalAttachments: meta.TotalAttachments,
+	})
 
-	channelName := ""
-	if ch := getChannelFromInteraction(s, i); ch != nil {
-		channelName = ch.Name
-	} else {
-		channelName = i.ChannelID
+	var attachments []*discordgo.File
+	if rec.AttachHTML {
+		if page, err := transcript.RenderHTML(payload, transcript.Options{}); err != nil {
+			log.Printf("render transcript html failed: %v", err)
+		} else {
+			attachments = append(attachments, &discordgo.File{
+				Name:        fmt.Sprintf("transcript-%s.html", rec.ChannelName),
+				ContentType: "text/html",
+				Reader:      bytes.NewReader(page),
+			})
+		}
+	}
+	if transcript.IsTextFormat(rec.AttachFormat) {
+		attachments = append(attachments, &discordgo.File{
+			Name:        transcript.TextFilename(rec.ChannelName, rec.AttachFormat),
+			ContentType: transcript.TextContentType(rec.AttachFormat),
+			Reader:      bytes.NewReader(transcript.RenderText(payload, rec.AttachFormat, transcript.Options{})),
+		})
 	}
 
 	SendTranscriptLog(
 		s,
-		serverConfig.TicketTranscriptCid.String,
-		serverID,
-		row.ID,
-		channelName,
-		userID,
-		closedBy,
-		len(content),
-		totalAttachments,
+		rec.LogChannelID,
+		rec.ServerID,
+		rowID,
+		rec.ChannelName,
+		rec.UserID,
+		rec.ClosedBy,
+		meta.TotalMessages,
+		meta.TotalAttachments,
+		attachments,
 	)
+	if rec.DMOpener {
+		sendOpenerTranscriptLink(s, rec.GuildID, rec.UserID, rec.ChannelName, rowID)
+	}
+	return rowID, nil
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/Sush1sui/FNS_BOT/internal/config"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -24,9 +25,9 @@ func usernameOrID(s *discordgo.Session, guildID, userID string) string {
 	return userID
 }
 
-func buildTranscriptContent(messages []*discordgo.Message) ([]transcriptMessage, int, int, []transcriptParticipant) {
-	items := make([]transcriptMessage, 0, len(messages))
-	participants := make(map[string]*transcriptParticipant)
+func buildTranscriptContent(messages []*discordgo.Message) ([]transcript.Message, int, int, []transcript.Participant) {
+	items := make([]transcript.Message, 0, len(messages))
+	participants := make(map[string]*transcript.Participant)
 	attachmentsTotal := 0
 	embedsTotal := 0
 
@@ -38,14 +39,17 @@ func buildTranscriptContent(messages []*discordgo.Message) ([]transcriptMessage,
 		attachmentsTotal += len(m.Attachments)
 		embedsTotal += len(m.Embeds)
 
-		msgType := "message"
+		msgType := messageTypeName(m.Type)
 		content := m.Content
+		if (msgType == transcript.MessageTypeDefault || msgType == transcript.MessageTypeReply) && !m.Author.Bot && transcript.IsNote(content) {
+			msgType = transcript.MessageTypeNote
+		}
 
 		timestamp := m.Timestamp.Format(time.RFC3339)
-		item := transcriptMessage{
+		item := transcript.Message{
 			ID:   m.ID,
 			Type: msgType,
-			Author: transcriptAuthor{
+			Author: transcript.Author{
 				ID:            m.Author.ID,
 				Username:      m.Author.Username,
 				Discriminator: m.Author.Discriminator,
@@ -62,38 +66,38 @@ func buildTranscriptContent(messages []*discordgo.Message) ([]transcriptMessage,
 		}
 
 		if len(m.Embeds) > 0 {
-			item.Embeds = make([]transcriptEmbed, 0, len(m.Embeds))
+			item.Embeds = make([]transcript.Embed, 0, len(m.Embeds))
 			for _, e := range m.Embeds {
-				embed := transcriptEmbed{
+				embed := transcript.Embed{
 					Title:       strPtr(e.Title),
 					Description: strPtr(e.Description),
 					URL:         strPtr(e.URL),
 					Color:       intPtr(e.Color),
-					Fields:      make([]transcriptEmbedField, 0, len(e.Fields)),
+					Fields:      make([]transcript.EmbedField, 0, len(e.Fields)),
 				}
 				for _, f := range e.Fields {
-					embed.Fields = append(embed.Fields, transcriptEmbedField{Name: f.Name, Value: f.Value, Inline: f.Inline})
+					embed.Fields = append(embed.Fields, transcript.EmbedField{Name: f.Name, Value: f.Value, Inline: f.Inline})
 				}
 				if e.Image != nil {
-					embed.Image = &transcriptImage{URL: e.Image.URL}
+					embed.Image = &transcript.Image{URL: e.Image.URL}
 				}
 				if e.Thumbnail != nil {
-					embed.Thumbnail = &transcriptImage{URL: e.Thumbnail.URL}
+					embed.Thumbnail = &transcript.Image{URL: e.Thumbnail.URL}
 				}
 				if e.Footer != nil {
-					embed.Footer = &transcriptFooter{Text: e.Footer.Text, IconURL: strPtr(e.Footer.IconURL)}
+					embed.Footer = &transcript.Footer{Text: e.Footer.Text, IconURL: strPtr(e.Footer.IconURL)}
 				}
 				if e.Author != nil {
-					embed.Author = &transcriptEmbedAuthor{Name: e.Author.Name, URL: strPtr(e.Author.URL), IconURL: strPtr(e.Author.IconURL)}
+					embed.Author = &transcript.EmbedAuthor{Name: e.Author.Name, URL: strPtr(e.Author.URL), IconURL: strPtr(e.Author.IconURL)}
 				}
 				item.Embeds = append(item.Embeds, embed)
 			}
 		}
 
 		if len(m.Attachments) > 0 {
-			item.Attachments = make([]transcriptAttachment, 0, len(m.Attachments))
+			item.Attachments = make([]transcript.Attachment, 0, len(m.Attachments))
 			for _, a := range m.Attachments {
-				item.Attachments = append(item.Attachments, transcriptAttachment{
+				item.Attachments = append(item.Attachments, transcript.Attachment{
 					ID:          a.ID,
 					Filename:    a.Filename,
 					URL:         a.URL,
@@ -107,22 +111,31 @@ func buildTranscriptContent(messages []*discordgo.Message) ([]transcriptMessage,
 		}
 
 		if len(m.Reactions) > 0 {
-			item.Reactions = make([]transcriptReaction, 0, len(m.Reactions))
+			item.Reactions = make([]transcript.Reaction, 0, len(m.Reactions))
 			for _, r := range m.Reactions {
 				emoji := r.Emoji.Name
-				item.Reactions = append(item.Reactions, transcriptReaction{Emoji: emoji, Count: r.Count})
+				item.Reactions = append(item.Reactions, transcript.Reaction{Emoji: emoji, Count: r.Count})
+			}
+		}
+
+		item.Reference = messageReference(m)
+		if len(m.StickerItems) > 0 {
+			item.Stickers = make([]transcript.Sticker, 0, len(m.StickerItems))
+			for _, st := range m.StickerItems {
+				item.Stickers = append(item.Stickers, transcript.Sticker{ID: st.ID, Name: st.Name, Format: stickerFormatName(st.FormatType)})
 			}
 		}
+		item.Components = componentRows(m.Components)
 
 		items = append(items, item)
 		if p, ok := participants[m.Author.ID]; ok {
 			p.MessageCount++
 		} else {
-			participants[m.Author.ID] = &transcriptParticipant{ID: m.Author.ID, Username: m.Author.Username, MessageCount: 1}
+			participants[m.Author.ID] = &transcript.Participant{ID: m.Author.ID, Username: m.Author.Username, MessageCount: 1}
 		}
 	}
 
-	list := make([]transcriptParticipant, 0, len(participants))
+	list := make([]transcript.Participant, 0, len(participants))
 	for _, p := range participants {
 		list = append(list, *p)
 	}
@@ -130,6 +143,150 @@ func buildTranscriptContent(messages []*discordgo.Message) ([]transcriptMessage,
 	return items, attachmentsTotal, embedsTotal, list
 }
 
+// messageTypeName maps a Discord message type to the transcript type name.
+func messageTypeName(t discordgo.MessageType) string {
+	switch t {
+	case discordgo.MessageTypeDefault:
+		return transcript.MessageTypeDefault
+	case discordgo.MessageTypeReply:
+		return transcript.MessageTypeReply
+	case discordgo.MessageTypeChatInputCommand:
+		return transcript.MessageTypeCommand
+	case discordgo.MessageTypeContextMenuCommand:
+		return transcript.MessageTypeContextCommand
+	case discordgo.MessageTypeChannelPinnedMessage:
+		return transcript.MessageTypePin
+	case discordgo.MessageTypeGuildMemberJoin:
+		return transcript.MessageTypeMemberJoin
+	case discordgo.MessageTypeUserPremiumGuildSubscription,
+		discordgo.MessageTypeUserPremiumGuildSubscriptionTierOne,
+		discordgo.MessageTypeUserPremiumGuildSubscriptionTierTwo,
+		discordgo.MessageTypeUserPremiumGuildSubscriptionTierThree:
+		return transcript.MessageTypeBoost
+	case discordgo.MessageTypeChannelFollowAdd:
+		return transcript.MessageTypeFollowAdd
+	case discordgo.MessageTypeThreadCreated:
+		return transcript.MessageTypeThreadCreated
+	case discordgo.MessageTypeThreadStarterMessage:
+		return transcript.MessageTypeThreadStarter
+	case discordgo.MessageTypeChannelNameChange:
+		return transcript.MessageTypeChannelNameChange
+	case discordgo.MessageTypeChannelIconChange:
+		return transcript.MessageTypeChannelIconChange
+	case discordgo.MessageTypeRecipientAdd:
+		return transcript.MessageTypeRecipientAdd
+	case discordgo.MessageTypeRecipientRemove:
+		return transcript.MessageTypeRecipientRemove
+	case discordgo.MessageTypeCall:
+		return transcript.MessageTypeCall
+	case discordgo.MessageTypeGuildDiscoveryDisqualified:
+		return transcript.MessageTypeDiscoveryDisqualified
+	case discordgo.MessageTypeGuildDiscoveryRequalified:
+		return transcript.MessageTypeDiscoveryRequalified
+	}
+	return fmt.Sprintf("type_%d", t)
+}
+
+// messageReference returns the message a reply points at, with the quoted
+// author and content when Discord included them. Crosspost and forward
+// references are left out.
+func messageReference(m *discordgo.Message) *transcript.Reference {
+	if m.Type != discordgo.MessageTypeReply || m.MessageReference == nil {
+		return nil
+	}
+	ref := &transcript.Reference{
+		MessageID: m.MessageReference.MessageID,
+		ChannelID: m.MessageReference.ChannelID,
+	}
+	if quoted := m.ReferencedMessage; quoted != nil && quoted.Author != nil {
+		ref.Author = &transcript.Author{
+			ID:            quoted.Author.ID,
+			Username:      quoted.Author.Username,
+			Discriminator: quoted.Author.Discriminator,
+			Avatar:        strPtr(quoted.Author.Avatar),
+			Bot:           quoted.Author.Bot,
+		}
+		ref.Content = &quoted.Content
+	}
+	return ref
+}
+
+func stickerFormatName(format discordgo.StickerFormat) string {
+	switch format {
+	case discordgo.StickerFormatTypePNG:
+		return "png"
+	case discordgo.StickerFormatTypeAPNG:
+		return "apng"
+	case discordgo.StickerFormatTypeLottie:
+		return "lottie"
+	case discordgo.StickerFormatTypeGIF:
+		return "gif"
+	}
+	return "unknown"
+}
+
+// componentRows keeps the buttons and select menus of a message, one slice
+// per action row. Other component types have nothing to show in a transcript.
+func componentRows(components []discordgo.MessageComponent) [][]transcript.Component {
+	rows := make([][]transcript.Component, 0)
+	for _, c := range components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		items := make([]transcript.Component, 0, len(row.Components))
+		for _, child := range row.Components {
+			switch v := child.(type) {
+			case *discordgo.Button:
+				items = append(items, transcript.Component{
+					Type:     "button",
+					Label:    v.Label,
+					Style:    int(v.Style),
+					URL:      strPtr(v.URL),
+					Emoji:    componentEmoji(v.Emoji),
+					Disabled: v.Disabled,
+				})
+			case *discordgo.SelectMenu:
+				options := make([]string, 0, len(v.Options))
+				for _, o := range v.Options {
+					options = append(options, o.Label)
+				}
+				items = append(items, transcript.Component{
+					Type:        "select",
+					Placeholder: v.Placeholder,
+					Disabled:    v.Disabled,
+					Options:     options,
+				})
+			}
+		}
+		if len(items) > 0 {
+			rows = append(rows, items)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return rows
+}
+
+func componentEmoji(e *discordgo.ComponentEmoji) string {
+	if e == nil {
+		return ""
+	}
+	if e.ID != "" {
+		return ":" + e.Name + ":"
+	}
+	return e.Name
+}
+
+func participantIDs(participants []transcript.Participant) []string {
+	ids := make([]string, 0, len(participants))
+	for _, p := range participants {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
 func strPtr(value string) *string {
 	if value == "" {
 		return nil
@@ -179,14 +336,18 @@ func canCloseTicket(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
 	}
 
 	channel := getChannelFromInteraction(s, i)
-	if channel != nil && strings.Contains(channel.Topic, "ticket_opener:") {
-		parts := strings.Split(channel.Topic, "ticket_opener:")
-		if len(parts) > 1 {
-			opener := strings.Fields(parts[1])
-			if len(opener) > 0 && opener[0] == i.Member.User.ID {
-				return true
-			}
-		}
+	if channel != nil && ticketOpenerFromTopic(channel.Topic) == i.Member.User.ID {
+		return true
+	}
+
+	return isTicketStaff(i)
+}
+
+// isTicketStaff reports whether the interacting member is an authorized
+// staff member, either directly or through one of their roles.
+func isTicketStaff(i *discordgo.InteractionCreate) bool {
+	if i == nil || i.Member == nil || i.Member.User == nil {
+		return false
 	}
 
 	serverID, err := strconv.ParseInt(i.GuildID, 10, 64)
@@ -217,7 +378,34 @@ func canCloseTicket(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
 	return false
 }
 
-func showQuestionsModal(s *discordgo.Session, i *discordgo.InteractionCreate, panelID int32, questions []string) {
+// ticketOpenerFromTopic returns the opener ID stored in a ticket channel
+// topic, or "" when the topic does not belong to a ticket.
+func ticketOpenerFromTopic(topic string) string {
+	parts := strings.SplitN(topic, "ticket_opener:", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	opener := strings.Fields(parts[1])
+	if len(opener) == 0 {
+		return ""
+	}
+	return opener[0]
+}
+
+// ticketPanelFromTopic returns the panel ID stored in a ticket channel topic.
+func ticketPanelFromTopic(topic string) (int32, bool) {
+	parts := strings.SplitN(topic, "panel:", 2)
+	if len(parts) < 2 {
+		return 0, false
+	}
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		return 0, false
+	}
+	return parsePanelID(fields[0], "")
+}
+
+func showQuestionsModal(s *discordgo.Session, i *discordgo.InteractionCreate, customID string, questions []string) {
 	inputs := make([]discordgo.MessageComponent, 0, 5)
 	limit := len(questions)
 	if limit > 5 {
@@ -241,7 +429,7 @@ func showQuestionsModal(s *discordgo.Session, i *discordgo.InteractionCreate, pa
 	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
-			CustomID:   fmt.Sprintf("%s%d", panelModalPrefix, panelID),
+			CustomID:   customID,
 			Title:      "Ticket Questions",
 			Components: inputs,
 		},
@@ -316,6 +504,53 @@ func getChannelFromInteraction(s *discordgo.Session, i *discordgo.InteractionCre
 	return ch
 }
 
+// interactionUser returns the invoking user for both guild and DM interactions.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i == nil {
+		return nil
+	}
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// resolveUser looks a user up in the guild member cache before falling back
+// to the API.
+func resolveUser(s *discordgo.Session, guildID, userID string) *discordgo.User {
+	if s == nil || userID == "" {
+		return nil
+	}
+	if s.State != nil {
+		if m, err := s.State.Member(guildID, userID); err == nil && m.User != nil {
+			return m.User
+		}
+	}
+	u, err := s.User(userID)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// isTicketChannel reports whether channelID is a ticket channel, based on
+// the opener marker the bot writes into the channel topic.
+func isTicketChannel(s *discordgo.Session, channelID string) bool {
+	if s == nil || channelID == "" {
+		return false
+	}
+	if s.State != nil {
+		if ch, err := s.State.Channel(channelID); err == nil {
+			return ticketOpenerFromTopic(ch.Topic) != ""
+		}
+	}
+	ch, err := s.Channel(channelID)
+	if err != nil {
+		return false
+	}
+	return ticketOpenerFromTopic(ch.Topic) != ""
+}
+
 func staffPerms() int64 {
 	return discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionReadMessageHistory | discordgo.PermissionAddReactions | discordgo.PermissionAttachFiles | discordgo.PermissionEmbedLinks
 }
@@ -361,6 +596,19 @@ func editEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, message
 	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &message})
 }
 
+func modalQnA(data discordgo.ModalSubmitInteractionData) []QnA {
+	questions, answers := extractModalAnswers(data)
+	qna := make([]QnA, 0, len(questions))
+	for idx, q := range questions {
+		ans := ""
+		if idx < len(answers) {
+			ans = answers[idx]
+		}
+		qna = append(qna, QnA{Question: q, Answer: ans})
+	}
+	return qna
+}
+
 func extractModalAnswers(data discordgo.ModalSubmitInteractionData) ([]string, []string) {
 	questions := make([]string, 0, 5)
 	answers := make([]string, 0, 5)
@@ -403,6 +651,7 @@ func SendTranscriptLog(
 	closedByID string,
 	totalMessages int,
 	totalAttachments int,
+	attachments []*discordgo.File,
 ) {
 	if s == nil || logChannelID == "" {
 		return
@@ -455,6 +704,43 @@ func SendTranscriptLog(
 			discordgo.ActionsRow{Components: []discordgo.MessageComponent{button}},
 		},
 	}
+	if len(attachments) > 0 {
+		msg.Files = attachments
+	}
 
 	_, _ = s.ChannelMessageSendComplex(logChannelID, msg)
 }
+
+// sendOpenerTranscriptLink DMs the ticket opener a link to their copy of the
+// transcript, which hides staff notes. Users with closed DMs are skipped.
+func sendOpenerTranscriptLink(s *discordgo.Session, guildID, openerID, ticketChannelName string, rowID int32) {
+	if s == nil || openerID == "" {
+		return
+	}
+
+	dm, err := s.UserChannelCreate(openerID)
+	if err != nil {
+		return
+	}
+
+	guildName := "the server"
+	if s.State != nil {
+		if g, err := s.State.Guild(guildID); err == nil {
+			guildName = "**" + g.Name + "**"
+		}
+	}
+
+	_, _ = s.ChannelMessageSendComplex(dm.ID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("📄 Your ticket **#%s** in %s was closed. You can read its transcript on the dashboard.", ticketChannelName, guildName),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label: "View Transcript",
+					Style: discordgo.LinkButton,
+					URL:   fmt.Sprintf("%s/me/transcripts/%d", config.Load().ClientOrigin, rowID),
+					Emoji: &discordgo.ComponentEmoji{Name: "🌐"},
+				},
+			}},
+		},
+	})
+}
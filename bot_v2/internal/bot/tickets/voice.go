@@ -0,0 +1,284 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	voiceIdleTimeout   = 15 * time.Minute
+	voiceSweepInterval = time.Minute
+)
+
+var voiceSweeperOnce sync.Once
+
+func handleTicketVoice(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	respondDeferred(s, i)
+
+	if queries == nil || i.GuildID == "" || i.ChannelID == "" {
+		editEphemeral(s, i, "Channel not found.")
+		return
+	}
+	if !canCloseTicket(s, i) {
+		editEphemeral(s, i, "Not allowed to start voice for this ticket.")
+		return
+	}
+
+	serverID, err := strconv.ParseInt(i.GuildID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	existing, err := queries.GetTicketVoiceByTicket(ctx, serverID, i.ChannelID)
+	if err == nil {
+		if _, err := s.Channel(existing.VoiceChannelID); err == nil {
+			editEphemeral(s, i, fmt.Sprintf("Voice channel is ready: <#%s>", existing.VoiceChannelID))
+			return
+		}
+		// The channel is gone (deleted by hand); forget it and make a new one.
+		_ = queries.DeleteTicketVoiceChannel(ctx, existing.VoiceChannelID)
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("load ticket voice failed: %v", err)
+		editEphemeral(s, i, "Failed to create voice channel.")
+		return
+	}
+
+	ticketChannel := getChannelFromInteraction(s, i)
+	if ticketChannel == nil {
+		editEphemeral(s, i, "Channel not found.")
+		return
+	}
+
+	voice, err := s.GuildChannelCreateComplex(i.GuildID, discordgo.GuildChannelCreateData{
+		Name:                 "🔊 " + ticketChannel.Name,
+		Type:                 discordgo.ChannelTypeGuildVoice,
+		ParentID:             ticketChannel.ParentID,
+		PermissionOverwrites: voiceOverwrites(ticketChannel.PermissionOverwrites),
+	})
+	if err != nil {
+		log.Printf("create ticket voice channel failed: %v", err)
+		editEphemeral(s, i, "Failed to create voice channel.")
+		return
+	}
+
+	if err := queries.CreateTicketVoiceChannel(ctx, voice.ID, serverID, i.ChannelID, time.Now().Unix()); err != nil {
+		log.Printf("save ticket voice channel failed: %v", err)
+		_, _ = s.ChannelDelete(voice.ID)
+		editEphemeral(s, i, "Failed to create voice channel.")
+		return
+	}
+
+	_, _ = s.ChannelMessageSend(i.ChannelID, fmt.Sprintf("🔊 Voice channel <#%s> created. It is removed when the ticket closes or after %d minutes without anyone in it.", voice.ID, int(voiceIdleTimeout.Minutes())))
+	editEphemeral(s, i, fmt.Sprintf("Voice channel is ready: <#%s>", voice.ID))
+}
+
+// voiceOverwrites mirrors the ticket's overwrites onto its voice channel,
+// letting everyone who can see the ticket connect and speak.
+func voiceOverwrites(ticket []*discordgo.PermissionOverwrite) []*discordgo.PermissionOverwrite {
+	overwrites := make([]*discordgo.PermissionOverwrite, 0, len(ticket))
+	for _, o := range ticket {
+		if o == nil {
+			continue
+		}
+		copied := *o
+		if copied.Allow&discordgo.PermissionViewChannel != 0 {
+			copied.Allow |= discordgo.PermissionVoiceConnect | discordgo.PermissionVoiceSpeak
+		}
+		overwrites = append(overwrites, &copied)
+	}
+	return overwrites
+}
+
+// HandleVoiceStateUpdate logs joins and leaves of ticket voice channels.
+func HandleVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	if v == nil || v.VoiceState == nil || queries == nil || v.GuildID == "" || v.UserID == "" {
+		return
+	}
+
+	serverID, err := strconv.ParseInt(v.GuildID, 10, 64)
+	if err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	// BeforeUpdate is not always cached, so close any open session outside
+	// the channel the user is in now instead of trusting the previous state.
+	left, err := queries.CloseTicketVoiceActivity(ctx, serverID, v.UserID, v.ChannelID, now)
+	if err != nil {
+		log.Printf("close voice activity failed: %v", err)
+	}
+	for _, voiceChannelID := range left {
+		_ = queries.TouchTicketVoiceChannel(ctx, voiceChannelID, now)
+	}
+
+	if v.ChannelID == "" {
+		return
+	}
+
+	voice, err := queries.GetTicketVoiceByVoiceChannel(ctx, v.ChannelID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("load ticket voice failed: %v", err)
+		}
+		return
+	}
+
+	username := v.UserID
+	if v.Member != nil && v.Member.User != nil {
+		username = v.Member.User.Username
+	} else if u := resolveUser(s, v.GuildID, v.UserID); u != nil {
+		username = u.Username
+	}
+
+	if err := queries.OpenTicketVoiceActivity(ctx, serverID, voice.TicketChannelID, voice.VoiceChannelID, v.UserID, username, now); err != nil {
+		log.Printf("open voice activity failed: %v", err)
+	}
+	_ = queries.TouchTicketVoiceChannel(ctx, voice.VoiceChannelID, now)
+}
+
+// StartVoiceSweeper periodically deletes ticket voice channels that have
+// been empty for voiceIdleTimeout. Safe to call more than once.
+func StartVoiceSweeper(s *discordgo.Session) {
+	if queries == nil {
+		return
+	}
+	voiceSweeperOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(voiceSweepInterval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				sweepIdleVoiceChannels(s)
+			}
+		}()
+	})
+}
+
+func sweepIdleVoiceChannels(s *discordgo.Session) {
+	ctx := context.Background()
+	now := time.Now()
+
+	idle, err := queries.GetIdleTicketVoiceChannels(ctx, now.Add(-voiceIdleTimeout).Unix())
+	if err != nil {
+		log.Printf("load idle voice channels failed: %v", err)
+		return
+	}
+
+	for _, voice := range idle {
+		guildID := strconv.FormatInt(voice.ServerConfigID, 10)
+		if voiceChannelOccupied(s, guildID, voice.VoiceChannelID) {
+			_ = queries.TouchTicketVoiceChannel(ctx, voice.VoiceChannelID, now.Unix())
+			continue
+		}
+
+		if _, err := s.ChannelDelete(voice.VoiceChannelID); err != nil {
+			log.Printf("delete idle voice channel %s failed: %v", voice.VoiceChannelID, err)
+		}
+		if err := queries.DeleteTicketVoiceChannel(ctx, voice.VoiceChannelID); err != nil {
+			log.Printf("forget idle voice channel failed: %v", err)
+			continue
+		}
+		_, _ = s.ChannelMessageSend(voice.TicketChannelID, "🔇 The ticket voice channel was removed after inactivity. Use the Voice button to open a new one.")
+	}
+}
+
+func voiceChannelOccupied(s *discordgo.Session, guildID, voiceChannelID string) bool {
+	if s == nil || s.State == nil {
+		return false
+	}
+
+	// State.Guild takes the state lock itself, so it must not be called
+	// with the lock held. The voice states are copied under a second,
+	// separate read lock.
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return false
+	}
+	s.State.RLock()
+	voiceStates := append([]*discordgo.VoiceState(nil), guild.VoiceStates...)
+	s.State.RUnlock()
+
+	for _, vs := range voiceStates {
+		if vs != nil && vs.ChannelID == voiceChannelID {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanupTicketVoice deletes the ticket's voice channel and its activity log.
+// Call it after the transcript has been saved.
+func cleanupTicketVoice(s *discordgo.Session, serverID int64, ticketChannelID string) {
+	if queries == nil {
+		return
+	}
+
+	ctx := context.Background()
+	voice, err := queries.GetTicketVoiceByTicket(ctx, serverID, ticketChannelID)
+	if err == nil {
+		if s != nil {
+			if _, err := s.ChannelDelete(voice.VoiceChannelID); err != nil {
+				log.Printf("delete ticket voice channel failed: %v", err)
+			}
+		}
+		if err := queries.DeleteTicketVoiceChannel(ctx, voice.VoiceChannelID); err != nil {
+			log.Printf("forget ticket voice channel failed: %v", err)
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("load ticket voice failed: %v", err)
+	}
+
+	if err := queries.DeleteTicketVoiceActivity(ctx, serverID, ticketChannelID); err != nil {
+		log.Printf("delete voice activity failed: %v", err)
+	}
+}
+
+// loadVoiceActivity converts the ticket's voice log into transcript entries,
+// closing sessions that are still open at closedAt.
+func loadVoiceActivity(ctx context.Context, serverID int64, ticketChannelID string, closedAt int64) []transcript.VoiceActivity {
+	if queries == nil {
+		return nil
+	}
+
+	rows, err := queries.GetTicketVoiceActivity(ctx, serverID, ticketChannelID)
+	if err != nil {
+		log.Printf("load voice activity failed: %v", err)
+		return nil
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	items := make([]transcript.VoiceActivity, 0, len(rows))
+	for _, row := range rows {
+		leftAt := closedAt
+		if row.LeftAt.Valid {
+			leftAt = row.LeftAt.Int64
+		}
+		left := time.Unix(leftAt, 0).UTC().Format(time.RFC3339)
+		duration := leftAt - row.JoinedAt
+		if duration < 0 {
+			duration = 0
+		}
+		items = append(items, transcript.VoiceActivity{
+			UserID:   row.UserID,
+			Username: row.Username,
+			JoinedAt: time.Unix(row.JoinedAt, 0).UTC().Format(time.RFC3339),
+			LeftAt:   &left,
+			Duration: int(duration),
+		})
+	}
+	return items
+}
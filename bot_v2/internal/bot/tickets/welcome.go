@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/transcript"
 	"github.com/Sush1sui/FNS_BOT/internal/utils"
 	"github.com/bwmarrin/discordgo"
 )
@@ -42,11 +43,29 @@ func SendWelcomeMessage(
 		},
 	}
 
+	voiceButton := discordgo.Button{
+		Label:    "Voice",
+		Style:    discordgo.SecondaryButton,
+		CustomID: ticketVoiceID,
+		Emoji: &discordgo.ComponentEmoji{
+			Name: "🔊",
+		},
+	}
+
+	escalateButton := discordgo.Button{
+		Label:    "Escalate",
+		Style:    discordgo.SecondaryButton,
+		CustomID: escalateTicketID,
+		Emoji: &discordgo.ComponentEmoji{
+			Name: "🔀",
+		},
+	}
+
 	message := &discordgo.MessageSend{
 		Content: strings.Join(mentions, " "),
 		Embeds:  []*discordgo.MessageEmbed{embed},
 		Components: []discordgo.MessageComponent{
-			discordgo.ActionsRow{Components: []discordgo.MessageComponent{closeButton}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{closeButton, voiceButton, escalateButton}},
 		},
 	}
 
@@ -119,7 +138,7 @@ func sendQnAMessage(s *discordgo.Session, channelID string, user *discordgo.User
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:  "📋 Ticket Responses",
+		Title:  transcript.QnATitle,
 		Color:  color,
 		Fields: fields,
 	}
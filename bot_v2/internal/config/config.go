@@ -4,8 +4,10 @@ import (
 	"encoding/base64"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/Sush1sui/FNS_BOT/internal/storage"
 	"github.com/joho/godotenv"
 )
 
@@ -21,6 +23,24 @@ type Config struct {
 	CookieSecure        bool
 	TrustedProxies      []string
 	AccessTokenKey      []byte
+
+	// Master keys for transcript envelope encryption, by key ID. Empty
+	// leaves transcripts unencrypted.
+	TranscriptKeys  map[string][]byte
+	TranscriptKeyID string
+
+	// Storage selects where transcripts and attachments are kept.
+	Storage storage.Config
+	// StorageQuotaBytes is each guild's storage quota unless it has its own;
+	// 0 is unlimited.
+	StorageQuotaBytes int64
+
+	// MetricsToken is the bearer token GET /metrics requires. Empty leaves
+	// the endpoint off.
+	MetricsToken string
+	// AdminToken is the bearer token the bot operator's /api/admin routes
+	// require, such as setting a guild's storage quota. Empty leaves them off.
+	AdminToken string
 }
 
 func Load() *Config {
@@ -87,6 +107,58 @@ func Load() *Config {
 		trustedProxies = []string{"127.0.0.1", "::1"}
 	}
 
+	// TRANSCRIPT_KEYS is a comma-separated list of id:base64key pairs. Keep
+	// retired keys listed until cmd/rewrap-keys has moved every transcript to
+	// TRANSCRIPT_KEY_ID.
+	transcriptKeys := map[string][]byte{}
+	for _, entry := range strings.Split(os.Getenv("TRANSCRIPT_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if !ok || id == "" || err != nil || len(key) != 32 {
+			log.Fatal("TRANSCRIPT_KEYS must be id:base64 pairs for 32-byte keys")
+		}
+		transcriptKeys[id] = key
+	}
+	transcriptKeyID := os.Getenv("TRANSCRIPT_KEY_ID")
+	if len(transcriptKeys) > 0 {
+		if _, ok := transcriptKeys[transcriptKeyID]; !ok {
+			log.Fatal("TRANSCRIPT_KEY_ID must name one of TRANSCRIPT_KEYS")
+		}
+	}
+
+	// STORAGE_BACKEND is azure (the default), local or s3. Each backend
+	// reads its own settings; storage.Open reports any that are missing.
+	storageConfig := storage.Config{
+		Backend: os.Getenv("STORAGE_BACKEND"),
+
+		AzureConnectionString: os.Getenv("AZURE_STORAGE_CONNECTION_STRING"),
+		AzureContainer:        os.Getenv("AZURE_STORAGE_CONTAINER"),
+
+		LocalDir: os.Getenv("STORAGE_LOCAL_DIR"),
+
+		S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+		S3Region:          os.Getenv("S3_REGION"),
+		S3Bucket:          os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3UseSSL:          os.Getenv("S3_USE_SSL") != "false",
+	}
+
+	// GUILD_STORAGE_QUOTA_MB caps each guild's transcript and attachment
+	// storage. Guilds over it get metadata-only transcripts.
+	var storageQuotaBytes int64
+	if quota := os.Getenv("GUILD_STORAGE_QUOTA_MB"); quota != "" {
+		mb, err := strconv.ParseInt(quota, 10, 64)
+		if err != nil || mb < 0 {
+			log.Fatal("GUILD_STORAGE_QUOTA_MB must be a whole number of megabytes")
+		}
+		storageQuotaBytes = mb << 20
+	}
+
 	return &Config{
 		Port:     port,
 		DBUrl:    dbUrl,
@@ -99,5 +171,14 @@ func Load() *Config {
 		CookieSecure:        cookieSecure,
 		TrustedProxies:      trustedProxies,
 		AccessTokenKey:      accessTokenKey,
+
+		TranscriptKeys:  transcriptKeys,
+		TranscriptKeyID: transcriptKeyID,
+
+		Storage:           storageConfig,
+		StorageQuotaBytes: storageQuotaBytes,
+
+		MetricsToken: os.Getenv("METRICS_TOKEN"),
+		AdminToken:   os.Getenv("ADMIN_API_TOKEN"),
 	}
 }
@@ -85,3 +85,36 @@ func (q *Queries) GetActiveTicketByChannel(ctx context.Context, serverConfigID i
 	}
 	return info, nil
 }
+
+const getActiveTicketsByServer = `
+SELECT user_id, channel_id, created_at
+FROM active_ticket
+WHERE server_config_id = $1
+`
+
+type ActiveTicket struct {
+	UserID    string
+	ChannelID string
+	CreatedAt int64
+}
+
+func (q *Queries) GetActiveTicketsByServer(ctx context.Context, serverConfigID int64) ([]ActiveTicket, error) {
+	rows, err := q.db.Query(ctx, getActiveTicketsByServer, serverConfigID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]ActiveTicket, 0)
+	for rows.Next() {
+		var i ActiveTicket
+		if err := rows.Scan(&i.UserID, &i.ChannelID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
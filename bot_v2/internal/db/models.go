@@ -64,13 +64,18 @@ type QuestionsConfig struct {
 }
 
 type ServerConfig struct {
-	ID                  int64
-	TicketNameStyle     string
-	TicketTranscriptCid pgtype.Text
-	MaxTicketPerUser    int32
-	TicketPermissions   []byte
-	MaxPanel            pgtype.Int4
-	MaxMultiPanel       pgtype.Int4
+	ID                      int64
+	TicketNameStyle         string
+	TicketTranscriptCid     pgtype.Text
+	MaxTicketPerUser        int32
+	TicketPermissions       []byte
+	MaxPanel                pgtype.Int4
+	MaxMultiPanel           pgtype.Int4
+	ModmailEnabled          bool
+	TranscriptLogHtml       bool
+	TranscriptRetentionDays int32
+	TranscriptDmOpener      bool
+	TranscriptLogFormat     string
 }
 
 type Transcript struct {
@@ -86,6 +91,10 @@ type Transcript struct {
 	TotalMessages    pgtype.Int4
 	TotalAttachments pgtype.Int4
 	TotalEmbeds      pgtype.Int4
+	CloseReason      string
+	PanelID          pgtype.Int4
+	ParticipantIds   []string
+	LegalHold        bool
 }
 
 type WelcomeMsgConfig struct {
@@ -0,0 +1,109 @@
+package db
+
+import "context"
+
+type ModmailTicket struct {
+	DMChannelID     string
+	UserID          string
+	ServerConfigID  int64
+	TicketChannelID string
+	CreatedAt       int64
+}
+
+const getModmailEnabledServers = `
+SELECT id
+FROM server_config
+WHERE modmail_enabled = true
+`
+
+func (q *Queries) GetModmailEnabledServers(ctx context.Context) ([]int64, error) {
+	rows, err := q.db.Query(ctx, getModmailEnabledServers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createModmailTicket = `
+INSERT INTO modmail_ticket (dm_channel_id, user_id, server_config_id, ticket_channel_id, created_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (dm_channel_id) DO UPDATE SET
+	user_id = EXCLUDED.user_id,
+	server_config_id = EXCLUDED.server_config_id,
+	ticket_channel_id = EXCLUDED.ticket_channel_id,
+	created_at = EXCLUDED.created_at
+`
+
+func (q *Queries) CreateModmailTicket(ctx context.Context, arg ModmailTicket) error {
+	_, err := q.db.Exec(ctx, createModmailTicket,
+		arg.DMChannelID,
+		arg.UserID,
+		arg.ServerConfigID,
+		arg.TicketChannelID,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const getModmailTicketByDM = `
+SELECT dm_channel_id, user_id, server_config_id, ticket_channel_id, created_at
+FROM modmail_ticket
+WHERE dm_channel_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetModmailTicketByDM(ctx context.Context, dmChannelID string) (ModmailTicket, error) {
+	row := q.db.QueryRow(ctx, getModmailTicketByDM, dmChannelID)
+	var i ModmailTicket
+	err := row.Scan(
+		&i.DMChannelID,
+		&i.UserID,
+		&i.ServerConfigID,
+		&i.TicketChannelID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getModmailTicketByChannel = `
+SELECT dm_channel_id, user_id, server_config_id, ticket_channel_id, created_at
+FROM modmail_ticket
+WHERE ticket_channel_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetModmailTicketByChannel(ctx context.Context, ticketChannelID string) (ModmailTicket, error) {
+	row := q.db.QueryRow(ctx, getModmailTicketByChannel, ticketChannelID)
+	var i ModmailTicket
+	err := row.Scan(
+		&i.DMChannelID,
+		&i.UserID,
+		&i.ServerConfigID,
+		&i.TicketChannelID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteModmailTicketByChannel = `
+DELETE FROM modmail_ticket
+WHERE ticket_channel_id = $1
+`
+
+func (q *Queries) DeleteModmailTicketByChannel(ctx context.Context, ticketChannelID string) error {
+	_, err := q.db.Exec(ctx, deleteModmailTicketByChannel, ticketChannelID)
+	return err
+}
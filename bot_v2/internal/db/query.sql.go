@@ -29,12 +29,44 @@ func (q *Queries) ClearAuthorizedRoles(ctx context.Context, serverConfigID int64
 	return err
 }
 
-const countTranscriptsByServer = `-- name: CountTranscriptsByServer :one
-SELECT COUNT(*) FROM transcript WHERE server_config_id = $1
+const countTranscriptsFiltered = `-- name: CountTranscriptsFiltered :one
+SELECT COUNT(*)
+FROM transcript
+WHERE server_config_id = $1
+  AND ($2::text IS NULL OR user_id = $2)
+  AND ($3::text IS NULL OR closed_by = $3)
+  AND ($4::int IS NULL OR panel_id = $4)
+  AND ($5::bigint IS NULL OR closed_at >= $5)
+  AND ($6::bigint IS NULL OR closed_at < $6)
+  AND ($7::int IS NULL OR COALESCE(total_messages, 0) >= $7)
+  AND ($8::text IS NULL OR participant_ids @> ARRAY[$8::text])
+  AND ($9::text IS NULL OR close_reason = $9)
 `
 
-func (q *Queries) CountTranscriptsByServer(ctx context.Context, serverConfigID int64) (int64, error) {
-	row := q.db.QueryRow(ctx, countTranscriptsByServer, serverConfigID)
+type CountTranscriptsFilteredParams struct {
+	ServerConfigID int64
+	UserID         pgtype.Text
+	ClosedBy       pgtype.Text
+	PanelID        pgtype.Int4
+	ClosedFrom     pgtype.Int8
+	ClosedTo       pgtype.Int8
+	MinMessages    pgtype.Int4
+	Participant    pgtype.Text
+	CloseReason    pgtype.Text
+}
+
+func (q *Queries) CountTranscriptsFiltered(ctx context.Context, arg CountTranscriptsFilteredParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTranscriptsFiltered,
+		arg.ServerConfigID,
+		arg.UserID,
+		arg.ClosedBy,
+		arg.PanelID,
+		arg.ClosedFrom,
+		arg.ClosedTo,
+		arg.MinMessages,
+		arg.Participant,
+		arg.CloseReason,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -44,10 +76,11 @@ const createTranscript = `-- name: CreateTranscript :one
 INSERT INTO transcript (
     server_config_id, ticket_id, username, user_id,
     opened_at, closed_at, closed_by, storage_key,
-    total_messages, total_attachments, total_embeds
+    total_messages, total_attachments, total_embeds, close_reason,
+    panel_id, participant_ids
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
-) RETURNING id, server_config_id, ticket_id, username, user_id, opened_at, closed_at, closed_by, storage_key, total_messages, total_attachments, total_embeds
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+) RETURNING id, server_config_id, ticket_id, username, user_id, opened_at, closed_at, closed_by, storage_key, total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
 `
 
 type CreateTranscriptParams struct {
@@ -62,6 +95,9 @@ type CreateTranscriptParams struct {
 	TotalMessages    pgtype.Int4
 	TotalAttachments pgtype.Int4
 	TotalEmbeds      pgtype.Int4
+	CloseReason      string
+	PanelID          pgtype.Int4
+	ParticipantIds   []string
 }
 
 func (q *Queries) CreateTranscript(ctx context.Context, arg CreateTranscriptParams) (Transcript, error) {
@@ -77,6 +113,9 @@ func (q *Queries) CreateTranscript(ctx context.Context, arg CreateTranscriptPara
 		arg.TotalMessages,
 		arg.TotalAttachments,
 		arg.TotalEmbeds,
+		arg.CloseReason,
+		arg.PanelID,
+		arg.ParticipantIds,
 	)
 	var i Transcript
 	err := row.Scan(
@@ -92,6 +131,10 @@ func (q *Queries) CreateTranscript(ctx context.Context, arg CreateTranscriptPara
 		&i.TotalMessages,
 		&i.TotalAttachments,
 		&i.TotalEmbeds,
+		&i.CloseReason,
+		&i.PanelID,
+		&i.ParticipantIds,
+		&i.LegalHold,
 	)
 	return i, err
 }
@@ -193,7 +236,7 @@ func (q *Queries) GetAutoCloseConfig(ctx context.Context, serverConfigID int64)
 }
 
 const getServerConfig = `-- name: GetServerConfig :one
-SELECT id, ticket_name_style, ticket_transcript_cid, max_ticket_per_user, ticket_permissions, max_panel, max_multi_panel FROM server_config 
+SELECT id, ticket_name_style, ticket_transcript_cid, max_ticket_per_user, ticket_permissions, max_panel, max_multi_panel, modmail_enabled, transcript_log_html, transcript_retention_days, transcript_dm_opener, transcript_log_format FROM server_config 
 WHERE id = $1 LIMIT 1
 `
 
@@ -208,6 +251,11 @@ func (q *Queries) GetServerConfig(ctx context.Context, id int64) (ServerConfig,
 		&i.TicketPermissions,
 		&i.MaxPanel,
 		&i.MaxMultiPanel,
+		&i.ModmailEnabled,
+		&i.TranscriptLogHtml,
+		&i.TranscriptRetentionDays,
+		&i.TranscriptDmOpener,
+		&i.TranscriptLogFormat,
 	)
 	return i, err
 }
@@ -261,7 +309,7 @@ func (q *Queries) GetServersByRole(ctx context.Context, roleID string) ([]int64,
 }
 
 const getTranscriptByID = `-- name: GetTranscriptByID :one
-SELECT id, server_config_id, ticket_id, username, user_id, opened_at, closed_at, closed_by, storage_key, total_messages, total_attachments, total_embeds FROM transcript WHERE id = $1 AND server_config_id = $2
+SELECT id, server_config_id, ticket_id, username, user_id, opened_at, closed_at, closed_by, storage_key, total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold FROM transcript WHERE id = $1 AND server_config_id = $2
 `
 
 type GetTranscriptByIDParams struct {
@@ -285,28 +333,79 @@ func (q *Queries) GetTranscriptByID(ctx context.Context, arg GetTranscriptByIDPa
 		&i.TotalMessages,
 		&i.TotalAttachments,
 		&i.TotalEmbeds,
+		&i.CloseReason,
+		&i.PanelID,
+		&i.ParticipantIds,
+		&i.LegalHold,
 	)
 	return i, err
 }
 
-const getTranscriptsByServer = `-- name: GetTranscriptsByServer :many
+const isMemberAuthorized = `-- name: IsMemberAuthorized :one
+SELECT EXISTS(SELECT 1 FROM authorized_members WHERE server_config_id = $1 AND member_id = $2)
+`
+
+type IsMemberAuthorizedParams struct {
+	ServerConfigID int64
+	MemberID       string
+}
+
+func (q *Queries) IsMemberAuthorized(ctx context.Context, arg IsMemberAuthorizedParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isMemberAuthorized, arg.ServerConfigID, arg.MemberID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listTranscriptsByClosedAtDesc = `-- name: ListTranscriptsByClosedAtDesc :many
 SELECT id, server_config_id, ticket_id, username, user_id,
        opened_at, closed_at, closed_by, storage_key,
-       total_messages, total_attachments, total_embeds
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
 FROM transcript
 WHERE server_config_id = $1
-ORDER BY closed_at DESC
-LIMIT $2 OFFSET $3
+  AND ($2::text IS NULL OR user_id = $2)
+  AND ($3::text IS NULL OR closed_by = $3)
+  AND ($4::int IS NULL OR panel_id = $4)
+  AND ($5::bigint IS NULL OR closed_at >= $5)
+  AND ($6::bigint IS NULL OR closed_at < $6)
+  AND ($7::int IS NULL OR COALESCE(total_messages, 0) >= $7)
+  AND ($8::text IS NULL OR participant_ids @> ARRAY[$8::text])
+  AND ($9::text IS NULL OR close_reason = $9)
+  AND ($10::int IS NULL OR (closed_at, id) < ($11::bigint, $10::int))
+ORDER BY closed_at DESC, id DESC
+LIMIT $12
 `
 
-type GetTranscriptsByServerParams struct {
+type ListTranscriptsByClosedAtDescParams struct {
 	ServerConfigID int64
-	Limit          int32
-	Offset         int32
+	UserID         pgtype.Text
+	ClosedBy       pgtype.Text
+	PanelID        pgtype.Int4
+	ClosedFrom     pgtype.Int8
+	ClosedTo       pgtype.Int8
+	MinMessages    pgtype.Int4
+	Participant    pgtype.Text
+	CloseReason    pgtype.Text
+	CursorID       pgtype.Int4
+	CursorValue    pgtype.Int8
+	RowLimit       int32
 }
 
-func (q *Queries) GetTranscriptsByServer(ctx context.Context, arg GetTranscriptsByServerParams) ([]Transcript, error) {
-	rows, err := q.db.Query(ctx, getTranscriptsByServer, arg.ServerConfigID, arg.Limit, arg.Offset)
+func (q *Queries) ListTranscriptsByClosedAtDesc(ctx context.Context, arg ListTranscriptsByClosedAtDescParams) ([]Transcript, error) {
+	rows, err := q.db.Query(ctx, listTranscriptsByClosedAtDesc,
+		arg.ServerConfigID,
+		arg.UserID,
+		arg.ClosedBy,
+		arg.PanelID,
+		arg.ClosedFrom,
+		arg.ClosedTo,
+		arg.MinMessages,
+		arg.Participant,
+		arg.CloseReason,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -327,6 +426,10 @@ func (q *Queries) GetTranscriptsByServer(ctx context.Context, arg GetTranscripts
 			&i.TotalMessages,
 			&i.TotalAttachments,
 			&i.TotalEmbeds,
+			&i.CloseReason,
+			&i.PanelID,
+			&i.ParticipantIds,
+			&i.LegalHold,
 		); err != nil {
 			return nil, err
 		}
@@ -338,20 +441,424 @@ func (q *Queries) GetTranscriptsByServer(ctx context.Context, arg GetTranscripts
 	return items, nil
 }
 
-const isMemberAuthorized = `-- name: IsMemberAuthorized :one
-SELECT EXISTS(SELECT 1 FROM authorized_members WHERE server_config_id = $1 AND member_id = $2)
+const listTranscriptsByClosedAtAsc = `-- name: ListTranscriptsByClosedAtAsc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = $1
+  AND ($2::text IS NULL OR user_id = $2)
+  AND ($3::text IS NULL OR closed_by = $3)
+  AND ($4::int IS NULL OR panel_id = $4)
+  AND ($5::bigint IS NULL OR closed_at >= $5)
+  AND ($6::bigint IS NULL OR closed_at < $6)
+  AND ($7::int IS NULL OR COALESCE(total_messages, 0) >= $7)
+  AND ($8::text IS NULL OR participant_ids @> ARRAY[$8::text])
+  AND ($9::text IS NULL OR close_reason = $9)
+  AND ($10::int IS NULL OR (closed_at, id) > ($11::bigint, $10::int))
+ORDER BY closed_at ASC, id ASC
+LIMIT $12
 `
 
-type IsMemberAuthorizedParams struct {
+type ListTranscriptsByClosedAtAscParams struct {
 	ServerConfigID int64
-	MemberID       string
+	UserID         pgtype.Text
+	ClosedBy       pgtype.Text
+	PanelID        pgtype.Int4
+	ClosedFrom     pgtype.Int8
+	ClosedTo       pgtype.Int8
+	MinMessages    pgtype.Int4
+	Participant    pgtype.Text
+	CloseReason    pgtype.Text
+	CursorID       pgtype.Int4
+	CursorValue    pgtype.Int8
+	RowLimit       int32
 }
 
-func (q *Queries) IsMemberAuthorized(ctx context.Context, arg IsMemberAuthorizedParams) (bool, error) {
-	row := q.db.QueryRow(ctx, isMemberAuthorized, arg.ServerConfigID, arg.MemberID)
-	var exists bool
-	err := row.Scan(&exists)
-	return exists, err
+func (q *Queries) ListTranscriptsByClosedAtAsc(ctx context.Context, arg ListTranscriptsByClosedAtAscParams) ([]Transcript, error) {
+	rows, err := q.db.Query(ctx, listTranscriptsByClosedAtAsc,
+		arg.ServerConfigID,
+		arg.UserID,
+		arg.ClosedBy,
+		arg.PanelID,
+		arg.ClosedFrom,
+		arg.ClosedTo,
+		arg.MinMessages,
+		arg.Participant,
+		arg.CloseReason,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transcript
+	for rows.Next() {
+		var i Transcript
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TicketID,
+			&i.Username,
+			&i.UserID,
+			&i.OpenedAt,
+			&i.ClosedAt,
+			&i.ClosedBy,
+			&i.StorageKey,
+			&i.TotalMessages,
+			&i.TotalAttachments,
+			&i.TotalEmbeds,
+			&i.CloseReason,
+			&i.PanelID,
+			&i.ParticipantIds,
+			&i.LegalHold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptsByOpenedAtDesc = `-- name: ListTranscriptsByOpenedAtDesc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = $1
+  AND ($2::text IS NULL OR user_id = $2)
+  AND ($3::text IS NULL OR closed_by = $3)
+  AND ($4::int IS NULL OR panel_id = $4)
+  AND ($5::bigint IS NULL OR closed_at >= $5)
+  AND ($6::bigint IS NULL OR closed_at < $6)
+  AND ($7::int IS NULL OR COALESCE(total_messages, 0) >= $7)
+  AND ($8::text IS NULL OR participant_ids @> ARRAY[$8::text])
+  AND ($9::text IS NULL OR close_reason = $9)
+  AND ($10::int IS NULL OR (opened_at, id) < ($11::bigint, $10::int))
+ORDER BY opened_at DESC, id DESC
+LIMIT $12
+`
+
+type ListTranscriptsByOpenedAtDescParams struct {
+	ServerConfigID int64
+	UserID         pgtype.Text
+	ClosedBy       pgtype.Text
+	PanelID        pgtype.Int4
+	ClosedFrom     pgtype.Int8
+	ClosedTo       pgtype.Int8
+	MinMessages    pgtype.Int4
+	Participant    pgtype.Text
+	CloseReason    pgtype.Text
+	CursorID       pgtype.Int4
+	CursorValue    pgtype.Int8
+	RowLimit       int32
+}
+
+func (q *Queries) ListTranscriptsByOpenedAtDesc(ctx context.Context, arg ListTranscriptsByOpenedAtDescParams) ([]Transcript, error) {
+	rows, err := q.db.Query(ctx, listTranscriptsByOpenedAtDesc,
+		arg.ServerConfigID,
+		arg.UserID,
+		arg.ClosedBy,
+		arg.PanelID,
+		arg.ClosedFrom,
+		arg.ClosedTo,
+		arg.MinMessages,
+		arg.Participant,
+		arg.CloseReason,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transcript
+	for rows.Next() {
+		var i Transcript
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TicketID,
+			&i.Username,
+			&i.UserID,
+			&i.OpenedAt,
+			&i.ClosedAt,
+			&i.ClosedBy,
+			&i.StorageKey,
+			&i.TotalMessages,
+			&i.TotalAttachments,
+			&i.TotalEmbeds,
+			&i.CloseReason,
+			&i.PanelID,
+			&i.ParticipantIds,
+			&i.LegalHold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptsByOpenedAtAsc = `-- name: ListTranscriptsByOpenedAtAsc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = $1
+  AND ($2::text IS NULL OR user_id = $2)
+  AND ($3::text IS NULL OR closed_by = $3)
+  AND ($4::int IS NULL OR panel_id = $4)
+  AND ($5::bigint IS NULL OR closed_at >= $5)
+  AND ($6::bigint IS NULL OR closed_at < $6)
+  AND ($7::int IS NULL OR COALESCE(total_messages, 0) >= $7)
+  AND ($8::text IS NULL OR participant_ids @> ARRAY[$8::text])
+  AND ($9::text IS NULL OR close_reason = $9)
+  AND ($10::int IS NULL OR (opened_at, id) > ($11::bigint, $10::int))
+ORDER BY opened_at ASC, id ASC
+LIMIT $12
+`
+
+type ListTranscriptsByOpenedAtAscParams struct {
+	ServerConfigID int64
+	UserID         pgtype.Text
+	ClosedBy       pgtype.Text
+	PanelID        pgtype.Int4
+	ClosedFrom     pgtype.Int8
+	ClosedTo       pgtype.Int8
+	MinMessages    pgtype.Int4
+	Participant    pgtype.Text
+	CloseReason    pgtype.Text
+	CursorID       pgtype.Int4
+	CursorValue    pgtype.Int8
+	RowLimit       int32
+}
+
+func (q *Queries) ListTranscriptsByOpenedAtAsc(ctx context.Context, arg ListTranscriptsByOpenedAtAscParams) ([]Transcript, error) {
+	rows, err := q.db.Query(ctx, listTranscriptsByOpenedAtAsc,
+		arg.ServerConfigID,
+		arg.UserID,
+		arg.ClosedBy,
+		arg.PanelID,
+		arg.ClosedFrom,
+		arg.ClosedTo,
+		arg.MinMessages,
+		arg.Participant,
+		arg.CloseReason,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transcript
+	for rows.Next() {
+		var i Transcript
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TicketID,
+			&i.Username,
+			&i.UserID,
+			&i.OpenedAt,
+			&i.ClosedAt,
+			&i.ClosedBy,
+			&i.StorageKey,
+			&i.TotalMessages,
+			&i.TotalAttachments,
+			&i.TotalEmbeds,
+			&i.CloseReason,
+			&i.PanelID,
+			&i.ParticipantIds,
+			&i.LegalHold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptsByMessagesDesc = `-- name: ListTranscriptsByMessagesDesc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = $1
+  AND ($2::text IS NULL OR user_id = $2)
+  AND ($3::text IS NULL OR closed_by = $3)
+  AND ($4::int IS NULL OR panel_id = $4)
+  AND ($5::bigint IS NULL OR closed_at >= $5)
+  AND ($6::bigint IS NULL OR closed_at < $6)
+  AND ($7::int IS NULL OR COALESCE(total_messages, 0) >= $7)
+  AND ($8::text IS NULL OR participant_ids @> ARRAY[$8::text])
+  AND ($9::text IS NULL OR close_reason = $9)
+  AND ($10::int IS NULL OR (COALESCE(total_messages, 0), id) < ($11::bigint, $10::int))
+ORDER BY COALESCE(total_messages, 0) DESC, id DESC
+LIMIT $12
+`
+
+type ListTranscriptsByMessagesDescParams struct {
+	ServerConfigID int64
+	UserID         pgtype.Text
+	ClosedBy       pgtype.Text
+	PanelID        pgtype.Int4
+	ClosedFrom     pgtype.Int8
+	ClosedTo       pgtype.Int8
+	MinMessages    pgtype.Int4
+	Participant    pgtype.Text
+	CloseReason    pgtype.Text
+	CursorID       pgtype.Int4
+	CursorValue    pgtype.Int8
+	RowLimit       int32
+}
+
+func (q *Queries) ListTranscriptsByMessagesDesc(ctx context.Context, arg ListTranscriptsByMessagesDescParams) ([]Transcript, error) {
+	rows, err := q.db.Query(ctx, listTranscriptsByMessagesDesc,
+		arg.ServerConfigID,
+		arg.UserID,
+		arg.ClosedBy,
+		arg.PanelID,
+		arg.ClosedFrom,
+		arg.ClosedTo,
+		arg.MinMessages,
+		arg.Participant,
+		arg.CloseReason,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transcript
+	for rows.Next() {
+		var i Transcript
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TicketID,
+			&i.Username,
+			&i.UserID,
+			&i.OpenedAt,
+			&i.ClosedAt,
+			&i.ClosedBy,
+			&i.StorageKey,
+			&i.TotalMessages,
+			&i.TotalAttachments,
+			&i.TotalEmbeds,
+			&i.CloseReason,
+			&i.PanelID,
+			&i.ParticipantIds,
+			&i.LegalHold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTranscriptsByMessagesAsc = `-- name: ListTranscriptsByMessagesAsc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = $1
+  AND ($2::text IS NULL OR user_id = $2)
+  AND ($3::text IS NULL OR closed_by = $3)
+  AND ($4::int IS NULL OR panel_id = $4)
+  AND ($5::bigint IS NULL OR closed_at >= $5)
+  AND ($6::bigint IS NULL OR closed_at < $6)
+  AND ($7::int IS NULL OR COALESCE(total_messages, 0) >= $7)
+  AND ($8::text IS NULL OR participant_ids @> ARRAY[$8::text])
+  AND ($9::text IS NULL OR close_reason = $9)
+  AND ($10::int IS NULL OR (COALESCE(total_messages, 0), id) > ($11::bigint, $10::int))
+ORDER BY COALESCE(total_messages, 0) ASC, id ASC
+LIMIT $12
+`
+
+type ListTranscriptsByMessagesAscParams struct {
+	ServerConfigID int64
+	UserID         pgtype.Text
+	ClosedBy       pgtype.Text
+	PanelID        pgtype.Int4
+	ClosedFrom     pgtype.Int8
+	ClosedTo       pgtype.Int8
+	MinMessages    pgtype.Int4
+	Participant    pgtype.Text
+	CloseReason    pgtype.Text
+	CursorID       pgtype.Int4
+	CursorValue    pgtype.Int8
+	RowLimit       int32
+}
+
+func (q *Queries) ListTranscriptsByMessagesAsc(ctx context.Context, arg ListTranscriptsByMessagesAscParams) ([]Transcript, error) {
+	rows, err := q.db.Query(ctx, listTranscriptsByMessagesAsc,
+		arg.ServerConfigID,
+		arg.UserID,
+		arg.ClosedBy,
+		arg.PanelID,
+		arg.ClosedFrom,
+		arg.ClosedTo,
+		arg.MinMessages,
+		arg.Participant,
+		arg.CloseReason,
+		arg.CursorID,
+		arg.CursorValue,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transcript
+	for rows.Next() {
+		var i Transcript
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TicketID,
+			&i.Username,
+			&i.UserID,
+			&i.OpenedAt,
+			&i.ClosedAt,
+			&i.ClosedBy,
+			&i.StorageKey,
+			&i.TotalMessages,
+			&i.TotalAttachments,
+			&i.TotalEmbeds,
+			&i.CloseReason,
+			&i.PanelID,
+			&i.ParticipantIds,
+			&i.LegalHold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const upsertAuthorizedMember = `-- name: UpsertAuthorizedMember :exec
@@ -429,9 +936,13 @@ func (q *Queries) UpsertAutoCloseConfig(ctx context.Context, arg UpsertAutoClose
 const upsertServerConfig = `-- name: UpsertServerConfig :one
 INSERT INTO server_config (
     id, ticket_name_style, ticket_transcript_cid, max_ticket_per_user, 
-    ticket_permissions, max_panel, max_multi_panel
+    ticket_permissions, max_panel, max_multi_panel, modmail_enabled, transcript_log_html, transcript_dm_opener, transcript_log_format
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7,
+    COALESCE($8::boolean, false),
+    COALESCE($9::boolean, false),
+    COALESCE($10::boolean, false),
+    COALESCE($11::text, '')
 )
 ON CONFLICT (id) DO UPDATE SET
     ticket_name_style = EXCLUDED.ticket_name_style,
@@ -439,8 +950,12 @@ ON CONFLICT (id) DO UPDATE SET
     max_ticket_per_user = EXCLUDED.max_ticket_per_user,
     ticket_permissions = EXCLUDED.ticket_permissions,
     max_panel = EXCLUDED.max_panel,
-    max_multi_panel = EXCLUDED.max_multi_panel
-RETURNING id, ticket_name_style, ticket_transcript_cid, max_ticket_per_user, ticket_permissions, max_panel, max_multi_panel
+    max_multi_panel = EXCLUDED.max_multi_panel,
+    modmail_enabled = COALESCE($8::boolean, server_config.modmail_enabled),
+    transcript_log_html = COALESCE($9::boolean, server_config.transcript_log_html),
+    transcript_dm_opener = COALESCE($10::boolean, server_config.transcript_dm_opener),
+    transcript_log_format = COALESCE($11::text, server_config.transcript_log_format)
+RETURNING id, ticket_name_style, ticket_transcript_cid, max_ticket_per_user, ticket_permissions, max_panel, max_multi_panel, modmail_enabled, transcript_log_html, transcript_retention_days, transcript_dm_opener, transcript_log_format
 `
 
 type UpsertServerConfigParams struct {
@@ -451,8 +966,14 @@ type UpsertServerConfigParams struct {
 	TicketPermissions   []byte
 	MaxPanel            pgtype.Int4
 	MaxMultiPanel       pgtype.Int4
+	ModmailEnabled      pgtype.Bool
+	TranscriptLogHtml   pgtype.Bool
+	TranscriptDmOpener  pgtype.Bool
+	TranscriptLogFormat pgtype.Text
 }
 
+// The settings added after the dashboard form keep their stored value when
+// the request leaves them out. Retention has its own query.
 func (q *Queries) UpsertServerConfig(ctx context.Context, arg UpsertServerConfigParams) (ServerConfig, error) {
 	row := q.db.QueryRow(ctx, upsertServerConfig,
 		arg.ID,
@@ -462,6 +983,10 @@ func (q *Queries) UpsertServerConfig(ctx context.Context, arg UpsertServerConfig
 		arg.TicketPermissions,
 		arg.MaxPanel,
 		arg.MaxMultiPanel,
+		arg.ModmailEnabled,
+		arg.TranscriptLogHtml,
+		arg.TranscriptDmOpener,
+		arg.TranscriptLogFormat,
 	)
 	var i ServerConfig
 	err := row.Scan(
@@ -472,6 +997,11 @@ func (q *Queries) UpsertServerConfig(ctx context.Context, arg UpsertServerConfig
 		&i.TicketPermissions,
 		&i.MaxPanel,
 		&i.MaxMultiPanel,
+		&i.ModmailEnabled,
+		&i.TranscriptLogHtml,
+		&i.TranscriptRetentionDays,
+		&i.TranscriptDmOpener,
+		&i.TranscriptLogFormat,
 	)
 	return i, err
 }
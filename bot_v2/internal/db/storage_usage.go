@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type GuildStorageUsage struct {
+	ServerConfigID  int64
+	TranscriptCount int32
+	TranscriptBytes int64
+	AttachmentBytes int64
+	// QuotaBytes overrides the default quota when set; 0 is unlimited.
+	QuotaBytes pgtype.Int8
+	UpdatedAt  int64
+}
+
+// TotalBytes is everything the guild has in storage.
+func (u GuildStorageUsage) TotalBytes() int64 {
+	return u.TranscriptBytes + u.AttachmentBytes
+}
+
+// Quota returns the guild's quota in bytes: its own when set, and
+// defaultQuota otherwise. 0 is unlimited.
+func (u GuildStorageUsage) Quota(defaultQuota int64) int64 {
+	if u.QuotaBytes.Valid {
+		return u.QuotaBytes.Int64
+	}
+	return defaultQuota
+}
+
+const getGuildStorageUsage = `
+SELECT server_config_id, transcript_count, transcript_bytes, attachment_bytes, quota_bytes, updated_at
+FROM guild_storage_usage
+WHERE server_config_id = $1
+`
+
+func (q *Queries) GetGuildStorageUsage(ctx context.Context, serverConfigID int64) (GuildStorageUsage, error) {
+	var i GuildStorageUsage
+	err := q.db.QueryRow(ctx, getGuildStorageUsage, serverConfigID).Scan(
+		&i.ServerConfigID,
+		&i.TranscriptCount,
+		&i.TranscriptBytes,
+		&i.AttachmentBytes,
+		&i.QuotaBytes,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setGuildStorageQuota = `
+INSERT INTO guild_storage_usage (server_config_id, quota_bytes, updated_at)
+SELECT id, $2, $3 FROM server_config WHERE id = $1
+ON CONFLICT (server_config_id) DO UPDATE SET
+    quota_bytes = EXCLUDED.quota_bytes,
+    updated_at = EXCLUDED.updated_at
+`
+
+// SetGuildStorageQuota sets the guild's own quota; NULL goes back to the
+// default. It reports false when the guild has no server config.
+func (q *Queries) SetGuildStorageQuota(ctx context.Context, serverConfigID int64, quotaBytes pgtype.Int8, updatedAt int64) (bool, error) {
+	tag, err := q.db.Exec(ctx, setGuildStorageQuota, serverConfigID, quotaBytes, updatedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// The usage row is inserted only once per transcript, so recording it again
+// after a retried save leaves the totals alone.
+const recordTranscriptUsage = `
+WITH recorded AS (
+    INSERT INTO transcript_usage (transcript_id, server_config_id, transcript_bytes, attachment_bytes)
+    VALUES ($1, $2, $3, $4)
+    ON CONFLICT (transcript_id) DO NOTHING
+    RETURNING server_config_id, transcript_bytes, attachment_bytes
+)
+INSERT INTO guild_storage_usage (server_config_id, transcript_count, transcript_bytes, attachment_bytes, updated_at)
+SELECT server_config_id, 1, transcript_bytes, attachment_bytes, $5
+FROM recorded
+ON CONFLICT (server_config_id) DO UPDATE SET
+    transcript_count = guild_storage_usage.transcript_count + 1,
+    transcript_bytes = guild_storage_usage.transcript_bytes + EXCLUDED.transcript_bytes,
+    attachment_bytes = guild_storage_usage.attachment_bytes + EXCLUDED.attachment_bytes,
+    updated_at = EXCLUDED.updated_at
+`
+
+type RecordTranscriptUsageParams struct {
+	TranscriptID    int32
+	ServerConfigID  int64
+	TranscriptBytes int64
+	AttachmentBytes int64
+	UpdatedAt       int64
+}
+
+// RecordTranscriptUsage stores a transcript's size and adds it to the
+// guild's totals. FinishTranscriptPurge takes it off again.
+func (q *Queries) RecordTranscriptUsage(ctx context.Context, arg RecordTranscriptUsageParams) error {
+	_, err := q.db.Exec(ctx, recordTranscriptUsage,
+		arg.TranscriptID,
+		arg.ServerConfigID,
+		arg.TranscriptBytes,
+		arg.AttachmentBytes,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+type UnmeteredTranscript struct {
+	ID             int32
+	ServerConfigID int64
+	StorageKey     string
+}
+
+const listUnmeteredTranscripts = `
+SELECT t.id, t.server_config_id, t.storage_key
+FROM transcript t
+WHERE t.id > $1
+  AND NOT EXISTS (SELECT 1 FROM transcript_usage u WHERE u.transcript_id = t.id)
+  AND NOT EXISTS (SELECT 1 FROM transcript_purge p WHERE p.transcript_id = t.id)
+ORDER BY t.id
+LIMIT $2
+`
+
+// ListUnmeteredTranscripts pages through transcripts that have no usage row
+// yet, such as those stored before usage was tracked. Transcripts being
+// purged are left out.
+func (q *Queries) ListUnmeteredTranscripts(ctx context.Context, afterID int32, limit int32) ([]UnmeteredTranscript, error) {
+	rows, err := q.db.Query(ctx, listUnmeteredTranscripts, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]UnmeteredTranscript, 0)
+	for rows.Next() {
+		var i UnmeteredTranscript
+		if err := rows.Scan(&i.ID, &i.ServerConfigID, &i.StorageKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
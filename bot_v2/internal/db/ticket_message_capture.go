@@ -0,0 +1,70 @@
+package db
+
+import "context"
+
+type TicketMessageCapture struct {
+	ID             int64
+	ServerConfigID int64
+	ChannelID      string
+	MessageID      string
+	Event          string
+	Data           []byte
+	OccurredAt     int64
+}
+
+const captureTicketMessage = `
+INSERT INTO ticket_message_capture (server_config_id, channel_id, message_id, event, data, occurred_at)
+SELECT id, $2, $3, $4, $5, $6 FROM server_config WHERE id = $1
+`
+
+// CaptureTicketMessage stages one message event. Guilds without a config row
+// have no tickets, so their events are dropped.
+func (q *Queries) CaptureTicketMessage(ctx context.Context, arg TicketMessageCapture) error {
+	_, err := q.db.Exec(ctx, captureTicketMessage,
+		arg.ServerConfigID,
+		arg.ChannelID,
+		arg.MessageID,
+		arg.Event,
+		arg.Data,
+		arg.OccurredAt,
+	)
+	return err
+}
+
+const listTicketMessageCaptures = `
+SELECT id, server_config_id, channel_id, message_id, event, data, occurred_at
+FROM ticket_message_capture
+WHERE server_config_id = $1 AND channel_id = $2
+ORDER BY occurred_at, id
+`
+
+func (q *Queries) ListTicketMessageCaptures(ctx context.Context, serverConfigID int64, channelID string) ([]TicketMessageCapture, error) {
+	rows, err := q.db.Query(ctx, listTicketMessageCaptures, serverConfigID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TicketMessageCapture, 0)
+	for rows.Next() {
+		var i TicketMessageCapture
+		if err := rows.Scan(&i.ID, &i.ServerConfigID, &i.ChannelID, &i.MessageID, &i.Event, &i.Data, &i.OccurredAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteTicketMessageCaptures = `
+DELETE FROM ticket_message_capture
+WHERE server_config_id = $1 AND channel_id = $2
+`
+
+func (q *Queries) DeleteTicketMessageCaptures(ctx context.Context, serverConfigID int64, channelID string) error {
+	_, err := q.db.Exec(ctx, deleteTicketMessageCaptures, serverConfigID, channelID)
+	return err
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type TicketTransfer struct {
+	ID              int32
+	ServerConfigID  int64
+	TicketChannelID string
+	FromPanelID     pgtype.Int4
+	FromPanelTitle  string
+	ToPanelID       int32
+	ToPanelTitle    string
+	MovedBy         string
+	MovedAt         int64
+}
+
+const createTicketTransfer = `
+INSERT INTO ticket_transfer (server_config_id, ticket_channel_id, from_panel_id, from_panel_title, to_panel_id, to_panel_title, moved_by, moved_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+func (q *Queries) CreateTicketTransfer(ctx context.Context, arg TicketTransfer) error {
+	_, err := q.db.Exec(ctx, createTicketTransfer,
+		arg.ServerConfigID,
+		arg.TicketChannelID,
+		arg.FromPanelID,
+		arg.FromPanelTitle,
+		arg.ToPanelID,
+		arg.ToPanelTitle,
+		arg.MovedBy,
+		arg.MovedAt,
+	)
+	return err
+}
+
+const getTicketTransfers = `
+SELECT id, server_config_id, ticket_channel_id, from_panel_id, from_panel_title, to_panel_id, to_panel_title, moved_by, moved_at
+FROM ticket_transfer
+WHERE server_config_id = $1 AND ticket_channel_id = $2
+ORDER BY moved_at, id
+`
+
+func (q *Queries) GetTicketTransfers(ctx context.Context, serverConfigID int64, ticketChannelID string) ([]TicketTransfer, error) {
+	rows, err := q.db.Query(ctx, getTicketTransfers, serverConfigID, ticketChannelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TicketTransfer, 0)
+	for rows.Next() {
+		var i TicketTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TicketChannelID,
+			&i.FromPanelID,
+			&i.FromPanelTitle,
+			&i.ToPanelID,
+			&i.ToPanelTitle,
+			&i.MovedBy,
+			&i.MovedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteTicketTransfers = `
+DELETE FROM ticket_transfer
+WHERE server_config_id = $1 AND ticket_channel_id = $2
+`
+
+func (q *Queries) DeleteTicketTransfers(ctx context.Context, serverConfigID int64, ticketChannelID string) error {
+	_, err := q.db.Exec(ctx, deleteTicketTransfers, serverConfigID, ticketChannelID)
+	return err
+}
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type TicketVoiceChannel struct {
+	VoiceChannelID  string
+	ServerConfigID  int64
+	TicketChannelID string
+	CreatedAt       int64
+	LastActiveAt    int64
+}
+
+type TicketVoiceActivity struct {
+	ID              int32
+	ServerConfigID  int64
+	TicketChannelID string
+	VoiceChannelID  string
+	UserID          string
+	Username        string
+	JoinedAt        int64
+	LeftAt          pgtype.Int8
+}
+
+const createTicketVoiceChannel = `
+INSERT INTO ticket_voice_channel (voice_channel_id, server_config_id, ticket_channel_id, created_at, last_active_at)
+VALUES ($1, $2, $3, $4, $4)
+`
+
+func (q *Queries) CreateTicketVoiceChannel(ctx context.Context, voiceChannelID string, serverConfigID int64, ticketChannelID string, createdAt int64) error {
+	_, err := q.db.Exec(ctx, createTicketVoiceChannel, voiceChannelID, serverConfigID, ticketChannelID, createdAt)
+	return err
+}
+
+const getTicketVoiceByTicket = `
+SELECT voice_channel_id, server_config_id, ticket_channel_id, created_at, last_active_at
+FROM ticket_voice_channel
+WHERE server_config_id = $1 AND ticket_channel_id = $2
+LIMIT 1
+`
+
+func (q *Queries) GetTicketVoiceByTicket(ctx context.Context, serverConfigID int64, ticketChannelID string) (TicketVoiceChannel, error) {
+	row := q.db.QueryRow(ctx, getTicketVoiceByTicket, serverConfigID, ticketChannelID)
+	var i TicketVoiceChannel
+	err := row.Scan(
+		&i.VoiceChannelID,
+		&i.ServerConfigID,
+		&i.TicketChannelID,
+		&i.CreatedAt,
+		&i.LastActiveAt,
+	)
+	return i, err
+}
+
+const getTicketVoiceByVoiceChannel = `
+SELECT voice_channel_id, server_config_id, ticket_channel_id, created_at, last_active_at
+FROM ticket_voice_channel
+WHERE voice_channel_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetTicketVoiceByVoiceChannel(ctx context.Context, voiceChannelID string) (TicketVoiceChannel, error) {
+	row := q.db.QueryRow(ctx, getTicketVoiceByVoiceChannel, voiceChannelID)
+	var i TicketVoiceChannel
+	err := row.Scan(
+		&i.VoiceChannelID,
+		&i.ServerConfigID,
+		&i.TicketChannelID,
+		&i.CreatedAt,
+		&i.LastActiveAt,
+	)
+	return i, err
+}
+
+const getIdleTicketVoiceChannels = `
+SELECT voice_channel_id, server_config_id, ticket_channel_id, created_at, last_active_at
+FROM ticket_voice_channel
+WHERE last_active_at < $1
+`
+
+func (q *Queries) GetIdleTicketVoiceChannels(ctx context.Context, before int64) ([]TicketVoiceChannel, error) {
+	rows, err := q.db.Query(ctx, getIdleTicketVoiceChannels, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TicketVoiceChannel, 0)
+	for rows.Next() {
+		var i TicketVoiceChannel
+		if err := rows.Scan(
+			&i.VoiceChannelID,
+			&i.ServerConfigID,
+			&i.TicketChannelID,
+			&i.CreatedAt,
+			&i.LastActiveAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const touchTicketVoiceChannel = `
+UPDATE ticket_voice_channel SET last_active_at = $2 WHERE voice_channel_id = $1
+`
+
+func (q *Queries) TouchTicketVoiceChannel(ctx context.Context, voiceChannelID string, now int64) error {
+	_, err := q.db.Exec(ctx, touchTicketVoiceChannel, voiceChannelID, now)
+	return err
+}
+
+const deleteTicketVoiceChannel = `
+DELETE FROM ticket_voice_channel WHERE voice_channel_id = $1
+`
+
+func (q *Queries) DeleteTicketVoiceChannel(ctx context.Context, voiceChannelID string) error {
+	_, err := q.db.Exec(ctx, deleteTicketVoiceChannel, voiceChannelID)
+	return err
+}
+
+const openTicketVoiceActivity = `
+INSERT INTO ticket_voice_activity (server_config_id, ticket_channel_id, voice_channel_id, user_id, username, joined_at)
+SELECT $1, $2, $3, $4, $5, $6
+WHERE NOT EXISTS (
+	SELECT 1 FROM ticket_voice_activity
+	WHERE server_config_id = $1 AND voice_channel_id = $3 AND user_id = $4 AND left_at IS NULL
+)
+`
+
+// OpenTicketVoiceActivity records a join unless the user already has an open
+// session in the channel (mute/deafen changes also emit voice state updates).
+func (q *Queries) OpenTicketVoiceActivity(ctx context.Context, serverConfigID int64, ticketChannelID, voiceChannelID, userID, username string, joinedAt int64) error {
+	_, err := q.db.Exec(ctx, openTicketVoiceActivity, serverConfigID, ticketChannelID, voiceChannelID, userID, username, joinedAt)
+	return err
+}
+
+const closeTicketVoiceActivity = `
+UPDATE ticket_voice_activity
+SET left_at = $4
+WHERE server_config_id = $1 AND user_id = $2 AND voice_channel_id <> $3 AND left_at IS NULL
+RETURNING voice_channel_id
+`
+
+// CloseTicketVoiceActivity closes the user's open sessions in every ticket
+// voice channel except currentChannelID and returns the channels they left.
+func (q *Queries) CloseTicketVoiceActivity(ctx context.Context, serverConfigID int64, userID, currentChannelID string, leftAt int64) ([]string, error) {
+	rows, err := q.db.Query(ctx, closeTicketVoiceActivity, serverConfigID, userID, currentChannelID, leftAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]string, 0)
+	for rows.Next() {
+		var voiceChannelID string
+		if err := rows.Scan(&voiceChannelID); err != nil {
+			return nil, err
+		}
+		items = append(items, voiceChannelID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTicketVoiceActivity = `
+SELECT id, server_config_id, ticket_channel_id, voice_channel_id, user_id, username, joined_at, left_at
+FROM ticket_voice_activity
+WHERE server_config_id = $1 AND ticket_channel_id = $2
+ORDER BY joined_at, id
+`
+
+func (q *Queries) GetTicketVoiceActivity(ctx context.Context, serverConfigID int64, ticketChannelID string) ([]TicketVoiceActivity, error) {
+	rows, err := q.db.Query(ctx, getTicketVoiceActivity, serverConfigID, ticketChannelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TicketVoiceActivity, 0)
+	for rows.Next() {
+		var i TicketVoiceActivity
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TicketChannelID,
+			&i.VoiceChannelID,
+			&i.UserID,
+			&i.Username,
+			&i.JoinedAt,
+			&i.LeftAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteTicketVoiceActivity = `
+DELETE FROM ticket_voice_activity
+WHERE server_config_id = $1 AND ticket_channel_id = $2
+`
+
+func (q *Queries) DeleteTicketVoiceActivity(ctx context.Context, serverConfigID int64, ticketChannelID string) error {
+	_, err := q.db.Exec(ctx, deleteTicketVoiceActivity, serverConfigID, ticketChannelID)
+	return err
+}
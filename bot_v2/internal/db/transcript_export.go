@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ExportStatusPending = "pending"
+	ExportStatusRunning = "running"
+	ExportStatusDone    = "done"
+	ExportStatusFailed  = "failed"
+	// ExportStatusExpired means the archive was deleted from storage.
+	ExportStatusExpired = "expired"
+)
+
+// ErrExportInProgress is returned by CreateTranscriptExport when the server
+// already has a pending or running export.
+var ErrExportInProgress = errors.New("an export is already in progress")
+
+type TranscriptExport struct {
+	ID             int32
+	ServerConfigID int64
+	Filters        []byte
+	IncludeHTML    bool
+	Status         string
+	Total          int32
+	Processed      int32
+	Bytes          int64
+	StorageKey     pgtype.Text
+	Error          pgtype.Text
+	CreatedAt      int64
+	FinishedAt     pgtype.Int8
+	ExpiresAt      pgtype.Int8
+}
+
+const transcriptExportColumns = `id, server_config_id, filters, include_html, status, total, processed, bytes, storage_key, error, created_at, finished_at, expires_at`
+
+func scanTranscriptExport(row interface{ Scan(...any) error }) (TranscriptExport, error) {
+	var i TranscriptExport
+	err := row.Scan(
+		&i.ID,
+		&i.ServerConfigID,
+		&i.Filters,
+		&i.IncludeHTML,
+		&i.Status,
+		&i.Total,
+		&i.Processed,
+		&i.Bytes,
+		&i.StorageKey,
+		&i.Error,
+		&i.CreatedAt,
+		&i.FinishedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createTranscriptExport = `
+INSERT INTO transcript_export (server_config_id, filters, include_html, total, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + transcriptExportColumns
+
+// CreateTranscriptExport queues an export. A unique index allows one
+// unfinished export per server; a second one fails with ErrExportInProgress.
+func (q *Queries) CreateTranscriptExport(ctx context.Context, serverConfigID int64, filters []byte, includeHTML bool, total int32, createdAt int64) (TranscriptExport, error) {
+	job, err := scanTranscriptExport(q.db.QueryRow(ctx, createTranscriptExport, serverConfigID, filters, includeHTML, total, createdAt))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return TranscriptExport{}, ErrExportInProgress
+	}
+	return job, err
+}
+
+const getTranscriptExport = `
+SELECT ` + transcriptExportColumns + `
+FROM transcript_export
+WHERE id = $1 AND server_config_id = $2
+`
+
+func (q *Queries) GetTranscriptExport(ctx context.Context, id int32, serverConfigID int64) (TranscriptExport, error) {
+	return scanTranscriptExport(q.db.QueryRow(ctx, getTranscriptExport, id, serverConfigID))
+}
+
+const countUnfinishedTranscriptExports = `
+SELECT count(*) FROM transcript_export
+WHERE server_config_id = $1 AND status IN ('pending', 'running')
+`
+
+func (q *Queries) CountUnfinishedTranscriptExports(ctx context.Context, serverConfigID int64) (int64, error) {
+	var count int64
+	err := q.db.QueryRow(ctx, countUnfinishedTranscriptExports, serverConfigID).Scan(&count)
+	return count, err
+}
+
+const listUnfinishedTranscriptExports = `
+SELECT ` + transcriptExportColumns + `
+FROM transcript_export
+WHERE status IN ('pending', 'running')
+ORDER BY id
+`
+
+// ListUnfinishedTranscriptExports returns jobs that were queued or running
+// when the process stopped, so they can be restarted.
+func (q *Queries) ListUnfinishedTranscriptExports(ctx context.Context) ([]TranscriptExport, error) {
+	rows, err := q.db.Query(ctx, listUnfinishedTranscriptExports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptExport, 0)
+	for rows.Next() {
+		i, err := scanTranscriptExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const startTranscriptExport = `
+UPDATE transcript_export
+SET status = 'running', processed = 0, bytes = 0, error = NULL, expires_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) StartTranscriptExport(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, startTranscriptExport, id)
+	return err
+}
+
+const updateTranscriptExportProgress = `
+UPDATE transcript_export SET processed = $2, bytes = $3 WHERE id = $1
+`
+
+func (q *Queries) UpdateTranscriptExportProgress(ctx context.Context, id int32, processed int32, bytes int64) error {
+	_, err := q.db.Exec(ctx, updateTranscriptExportProgress, id, processed, bytes)
+	return err
+}
+
+// A purge that ran while the export was building may already have set
+// expires_at; the earlier time wins.
+const finishTranscriptExport = `
+UPDATE transcript_export
+SET status = 'done', processed = $2, bytes = $3, storage_key = $4, finished_at = $5,
+    expires_at = LEAST(COALESCE(expires_at, $6), $6)
+WHERE id = $1
+`
+
+func (q *Queries) FinishTranscriptExport(ctx context.Context, id int32, processed int32, bytes int64, storageKey string, finishedAt, expiresAt int64) error {
+	_, err := q.db.Exec(ctx, finishTranscriptExport, id, processed, bytes, storageKey, finishedAt, expiresAt)
+	return err
+}
+
+const failTranscriptExport = `
+UPDATE transcript_export
+SET status = 'failed', error = $2, finished_at = $3
+WHERE id = $1
+`
+
+func (q *Queries) FailTranscriptExport(ctx context.Context, id int32, message string, finishedAt int64) error {
+	_, err := q.db.Exec(ctx, failTranscriptExport, id, message, finishedAt)
+	return err
+}
+
+const listExpiredTranscriptExports = `
+SELECT ` + transcriptExportColumns + `
+FROM transcript_export
+WHERE status = 'done' AND expires_at <= $1
+ORDER BY expires_at
+LIMIT $2
+`
+
+// ListExpiredTranscriptExports returns finished exports whose archives are
+// due for deletion.
+func (q *Queries) ListExpiredTranscriptExports(ctx context.Context, now int64, limit int32) ([]TranscriptExport, error) {
+	rows, err := q.db.Query(ctx, listExpiredTranscriptExports, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptExport, 0)
+	for rows.Next() {
+		i, err := scanTranscriptExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const expireTranscriptExport = `
+UPDATE transcript_export
+SET status = 'expired', storage_key = NULL
+WHERE id = $1 AND status = 'done'
+`
+
+// ExpireTranscriptExport records that the export's archive was deleted.
+func (q *Queries) ExpireTranscriptExport(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, expireTranscriptExport, id)
+	return err
+}
@@ -0,0 +1,39 @@
+package db
+
+import "context"
+
+type TranscriptStorageKey struct {
+	ID         int32
+	StorageKey string
+}
+
+const listTranscriptStorageKeys = `
+SELECT id, storage_key
+FROM transcript
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+// ListTranscriptStorageKeys pages through every stored transcript by id, so
+// maintenance jobs can resume after afterID.
+func (q *Queries) ListTranscriptStorageKeys(ctx context.Context, afterID int32, limit int32) ([]TranscriptStorageKey, error) {
+	rows, err := q.db.Query(ctx, listTranscriptStorageKeys, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptStorageKey, 0)
+	for rows.Next() {
+		var i TranscriptStorageKey
+		if err := rows.Scan(&i.ID, &i.StorageKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import "context"
+
+const transcriptColumns = `id, server_config_id, ticket_id, username, user_id, opened_at, closed_at, closed_by, storage_key, total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold`
+
+func scanTranscript(row interface{ Scan(...any) error }) (Transcript, error) {
+	var i Transcript
+	err := row.Scan(
+		&i.ID,
+		&i.ServerConfigID,
+		&i.TicketID,
+		&i.Username,
+		&i.UserID,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.ClosedBy,
+		&i.StorageKey,
+		&i.TotalMessages,
+		&i.TotalAttachments,
+		&i.TotalEmbeds,
+		&i.CloseReason,
+		&i.PanelID,
+		&i.ParticipantIds,
+		&i.LegalHold,
+	)
+	return i, err
+}
+
+const listTranscriptsByOpener = `
+SELECT ` + transcriptColumns + `
+FROM transcript
+WHERE user_id = $1
+ORDER BY closed_at DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+// ListTranscriptsByOpener returns the transcripts of tickets userID opened,
+// across every server, newest first.
+func (q *Queries) ListTranscriptsByOpener(ctx context.Context, userID string, limit, offset int32) ([]Transcript, error) {
+	rows, err := q.db.Query(ctx, listTranscriptsByOpener, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]Transcript, 0)
+	for rows.Next() {
+		i, err := scanTranscript(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countTranscriptsByOpener = `
+SELECT count(*) FROM transcript WHERE user_id = $1
+`
+
+func (q *Queries) CountTranscriptsByOpener(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := q.db.QueryRow(ctx, countTranscriptsByOpener, userID).Scan(&count)
+	return count, err
+}
+
+const getTranscriptForOpener = `
+SELECT ` + transcriptColumns + `
+FROM transcript
+WHERE id = $1 AND user_id = $2
+`
+
+// GetTranscriptForOpener loads a transcript only when userID opened the ticket.
+func (q *Queries) GetTranscriptForOpener(ctx context.Context, id int32, userID string) (Transcript, error) {
+	return scanTranscript(q.db.QueryRow(ctx, getTranscriptForOpener, id, userID))
+}
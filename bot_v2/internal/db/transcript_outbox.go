@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// OutboxStatusPending entries hold a staged payload that still has to be
+	// uploaded and saved as a transcript row.
+	OutboxStatusPending = "pending"
+	// OutboxStatusStored entries have their transcript saved; only the
+	// ticket channel cleanup is left.
+	OutboxStatusStored = "stored"
+)
+
+type TranscriptOutbox struct {
+	ID             int64
+	ServerConfigID int64
+	ChannelID      string
+	Record         []byte
+	Payload        []byte
+	// Original is the unredacted payload, staged only when the guild keeps
+	// originals.
+	Original      []byte
+	StorageKey    string
+	Status        string
+	TranscriptID  pgtype.Int4
+	DeleteChannel bool
+	Attempts      int32
+	NextAttemptAt int64
+	LastError     pgtype.Text
+	Alerted       bool
+	CreatedAt     int64
+}
+
+const transcriptOutboxColumns = `id, server_config_id, channel_id, record, payload, original, storage_key, status, transcript_id, delete_channel, attempts, next_attempt_at, last_error, alerted, created_at`
+
+func scanTranscriptOutbox(row interface{ Scan(...any) error }) (TranscriptOutbox, error) {
+	var i TranscriptOutbox
+	err := row.Scan(
+		&i.ID,
+		&i.ServerConfigID,
+		&i.ChannelID,
+		&i.Record,
+		&i.Payload,
+		&i.Original,
+		&i.StorageKey,
+		&i.Status,
+		&i.TranscriptID,
+		&i.DeleteChannel,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.Alerted,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTranscriptOutbox = `
+INSERT INTO transcript_outbox (server_config_id, channel_id, record, payload, original, storage_key, delete_channel, next_attempt_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+RETURNING ` + transcriptOutboxColumns
+
+type CreateTranscriptOutboxParams struct {
+	ServerConfigID int64
+	ChannelID      string
+	Record         []byte
+	Payload        []byte
+	Original       []byte
+	StorageKey     string
+	DeleteChannel  bool
+	CreatedAt      int64
+}
+
+// CreateTranscriptOutbox stages a transcript payload. A channel has at most
+// one entry, so a second close of the same ticket fails on the unique key.
+func (q *Queries) CreateTranscriptOutbox(ctx context.Context, arg CreateTranscriptOutboxParams) (TranscriptOutbox, error) {
+	return scanTranscriptOutbox(q.db.QueryRow(ctx, createTranscriptOutbox,
+		arg.ServerConfigID,
+		arg.ChannelID,
+		arg.Record,
+		arg.Payload,
+		arg.Original,
+		arg.StorageKey,
+		arg.DeleteChannel,
+		arg.CreatedAt,
+	))
+}
+
+const getTranscriptOutboxByChannel = `
+SELECT ` + transcriptOutboxColumns + `
+FROM transcript_outbox
+WHERE server_config_id = $1 AND channel_id = $2
+`
+
+func (q *Queries) GetTranscriptOutboxByChannel(ctx context.Context, serverConfigID int64, channelID string) (TranscriptOutbox, error) {
+	return scanTranscriptOutbox(q.db.QueryRow(ctx, getTranscriptOutboxByChannel, serverConfigID, channelID))
+}
+
+const listDueTranscriptOutbox = `
+SELECT ` + transcriptOutboxColumns + `
+FROM transcript_outbox
+WHERE next_attempt_at <= $1
+ORDER BY next_attempt_at, id
+LIMIT $2
+`
+
+func (q *Queries) ListDueTranscriptOutbox(ctx context.Context, now int64, limit int32) ([]TranscriptOutbox, error) {
+	rows, err := q.db.Query(ctx, listDueTranscriptOutbox, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptOutbox, 0)
+	for rows.Next() {
+		i, err := scanTranscriptOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markTranscriptOutboxStored = `
+UPDATE transcript_outbox
+SET status = 'stored', transcript_id = $2, payload = NULL, original = NULL, attempts = 0, last_error = NULL
+WHERE id = $1
+`
+
+// MarkTranscriptOutboxStored records the saved transcript and drops the
+// staged payloads, which now live in storage.
+func (q *Queries) MarkTranscriptOutboxStored(ctx context.Context, id int64, transcriptID int32) error {
+	_, err := q.db.Exec(ctx, markTranscriptOutboxStored, id, transcriptID)
+	return err
+}
+
+const retryTranscriptOutbox = `
+UPDATE transcript_outbox
+SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+WHERE id = $1
+RETURNING attempts
+`
+
+// RetryTranscriptOutbox records a failed attempt and returns the attempt count.
+func (q *Queries) RetryTranscriptOutbox(ctx context.Context, id int64, nextAttemptAt int64, lastError string) (int32, error) {
+	var attempts int32
+	err := q.db.QueryRow(ctx, retryTranscriptOutbox, id, nextAttemptAt, lastError).Scan(&attempts)
+	return attempts, err
+}
+
+const markTranscriptOutboxAlerted = `
+UPDATE transcript_outbox SET alerted = TRUE WHERE id = $1
+`
+
+func (q *Queries) MarkTranscriptOutboxAlerted(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markTranscriptOutboxAlerted, id)
+	return err
+}
+
+const keepTranscriptOutboxChannel = `
+UPDATE transcript_outbox SET delete_channel = FALSE, next_attempt_at = $3
+WHERE server_config_id = $1 AND channel_id = $2
+`
+
+// KeepTranscriptOutboxChannel stops an entry from deleting its channel, for
+// when the channel is already gone, and makes it due right away.
+func (q *Queries) KeepTranscriptOutboxChannel(ctx context.Context, serverConfigID int64, channelID string, now int64) error {
+	_, err := q.db.Exec(ctx, keepTranscriptOutboxChannel, serverConfigID, channelID, now)
+	return err
+}
+
+const deleteTranscriptOutbox = `
+DELETE FROM transcript_outbox WHERE id = $1
+`
+
+func (q *Queries) DeleteTranscriptOutbox(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteTranscriptOutbox, id)
+	return err
+}
+
+const getTranscriptIDByStorageKey = `
+SELECT id FROM transcript WHERE server_config_id = $1 AND storage_key = $2
+`
+
+// GetTranscriptIDByStorageKey finds a transcript row saved by an attempt
+// that crashed before it could mark its outbox entry.
+func (q *Queries) GetTranscriptIDByStorageKey(ctx context.Context, serverConfigID int64, storageKey string) (int32, error) {
+	var id int32
+	err := q.db.QueryRow(ctx, getTranscriptIDByStorageKey, serverConfigID, storageKey).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,58 @@
+package db
+
+import "context"
+
+type TranscriptRedaction struct {
+	ServerConfigID int64
+	Detectors      []string
+	Rules          []byte
+	KeepOriginal   bool
+	UpdatedAt      int64
+}
+
+const getTranscriptRedaction = `
+SELECT server_config_id, detectors, rules, keep_original, updated_at
+FROM transcript_redaction
+WHERE server_config_id = $1
+`
+
+func (q *Queries) GetTranscriptRedaction(ctx context.Context, serverConfigID int64) (TranscriptRedaction, error) {
+	var i TranscriptRedaction
+	err := q.db.QueryRow(ctx, getTranscriptRedaction, serverConfigID).Scan(
+		&i.ServerConfigID,
+		&i.Detectors,
+		&i.Rules,
+		&i.KeepOriginal,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertTranscriptRedaction = `
+INSERT INTO transcript_redaction (server_config_id, detectors, rules, keep_original, updated_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (server_config_id) DO UPDATE SET
+	detectors = EXCLUDED.detectors,
+	rules = EXCLUDED.rules,
+	keep_original = EXCLUDED.keep_original,
+	updated_at = EXCLUDED.updated_at
+`
+
+type UpsertTranscriptRedactionParams struct {
+	ServerConfigID int64
+	Detectors      []string
+	Rules          []byte
+	KeepOriginal   bool
+	UpdatedAt      int64
+}
+
+func (q *Queries) UpsertTranscriptRedaction(ctx context.Context, arg UpsertTranscriptRedactionParams) error {
+	_, err := q.db.Exec(ctx, upsertTranscriptRedaction,
+		arg.ServerConfigID,
+		arg.Detectors,
+		arg.Rules,
+		arg.KeepOriginal,
+		arg.UpdatedAt,
+	)
+	return err
+}
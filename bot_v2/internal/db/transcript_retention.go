@@ -0,0 +1,187 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ExpiredTranscript struct {
+	ID             int32
+	ServerConfigID int64
+	StorageKey     string
+	Purging        bool
+}
+
+// Transcripts whose purge already started are returned regardless of the
+// current policy so an interrupted purge always finishes.
+const listExpiredTranscripts = `
+SELECT t.id, t.server_config_id, t.storage_key, p.transcript_id IS NOT NULL AS purging
+FROM transcript t
+JOIN server_config c ON c.id = t.server_config_id
+LEFT JOIN transcript_purge p ON p.transcript_id = t.id
+WHERE p.transcript_id IS NOT NULL
+   OR (c.transcript_retention_days > 0
+       AND NOT t.legal_hold
+       AND t.closed_at < $1 - c.transcript_retention_days::bigint * 86400)
+ORDER BY purging DESC, t.closed_at
+LIMIT $2
+`
+
+func (q *Queries) ListExpiredTranscripts(ctx context.Context, now int64, limit int32) ([]ExpiredTranscript, error) {
+	rows, err := q.db.Query(ctx, listExpiredTranscripts, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]ExpiredTranscript, 0)
+	for rows.Next() {
+		var i ExpiredTranscript
+		if err := rows.Scan(&i.ID, &i.ServerConfigID, &i.StorageKey, &i.Purging); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// The hold is re-checked here: a transcript held after it was listed is
+// skipped. Once the purge row exists the hold can no longer be set.
+const beginTranscriptPurge = `
+INSERT INTO transcript_purge (transcript_id, started_at)
+SELECT id, $2 FROM transcript WHERE id = $1 AND NOT legal_hold
+ON CONFLICT (transcript_id) DO NOTHING
+`
+
+// BeginTranscriptPurge marks a transcript as being purged. It reports false
+// when the transcript is gone or under legal hold.
+func (q *Queries) BeginTranscriptPurge(ctx context.Context, transcriptID int32, startedAt int64) (bool, error) {
+	tag, err := q.db.Exec(ctx, beginTranscriptPurge, transcriptID, startedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Deleting the transcript cascades to its search and purge rows; the audit
+// row is written and the guild's storage usage released in the same
+// statement. Exports of the guild created after the transcript closed may
+// hold a copy of it, so they are set to expire now and the export sweeper
+// deletes their archives.
+const finishTranscriptPurge = `
+WITH purged AS (
+    DELETE FROM transcript
+    WHERE id = $1 AND EXISTS (SELECT 1 FROM transcript_purge WHERE transcript_id = $1)
+    RETURNING id, server_config_id, ticket_id, user_id, storage_key, closed_at
+), released AS (
+    DELETE FROM transcript_usage
+    WHERE transcript_id IN (SELECT id FROM purged)
+    RETURNING server_config_id, transcript_bytes, attachment_bytes
+), totals AS (
+    UPDATE guild_storage_usage u SET
+        transcript_count = u.transcript_count - 1,
+        transcript_bytes = u.transcript_bytes - r.transcript_bytes,
+        attachment_bytes = u.attachment_bytes - r.attachment_bytes,
+        updated_at = $3
+    FROM released r
+    WHERE u.server_config_id = r.server_config_id
+), exports AS (
+    UPDATE transcript_export e SET expires_at = $3
+    FROM purged p
+    WHERE e.server_config_id = p.server_config_id
+      AND e.created_at >= p.closed_at
+      AND e.status IN ('pending', 'running', 'done')
+      AND (e.expires_at IS NULL OR e.expires_at > $3)
+)
+INSERT INTO transcript_purge_log (server_config_id, transcript_id, ticket_id, user_id, storage_key, closed_at, objects_deleted, purged_at)
+SELECT server_config_id, id, ticket_id, user_id, storage_key, closed_at, $2, $3
+FROM purged
+`
+
+func (q *Queries) FinishTranscriptPurge(ctx context.Context, transcriptID int32, objectsDeleted int32, purgedAt int64) error {
+	_, err := q.db.Exec(ctx, finishTranscriptPurge, transcriptID, objectsDeleted, purgedAt)
+	return err
+}
+
+const setTranscriptRetentionDays = `
+UPDATE server_config SET transcript_retention_days = $2 WHERE id = $1
+`
+
+// SetTranscriptRetentionDays sets how long the guild keeps transcripts; 0
+// keeps them forever. Only this query writes the column, so no other
+// settings update can change the policy by accident.
+func (q *Queries) SetTranscriptRetentionDays(ctx context.Context, serverConfigID int64, days int32) error {
+	_, err := q.db.Exec(ctx, setTranscriptRetentionDays, serverConfigID, days)
+	return err
+}
+
+const setTranscriptLegalHold = `
+UPDATE transcript SET legal_hold = $3
+WHERE id = $1 AND server_config_id = $2
+  AND NOT EXISTS (SELECT 1 FROM transcript_purge WHERE transcript_id = $1)
+`
+
+// SetTranscriptLegalHold reports false when the transcript does not exist or
+// is already being purged.
+func (q *Queries) SetTranscriptLegalHold(ctx context.Context, transcriptID int32, serverConfigID int64, hold bool) (bool, error) {
+	tag, err := q.db.Exec(ctx, setTranscriptLegalHold, transcriptID, serverConfigID, hold)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+type TranscriptPurgeLog struct {
+	ID             int32
+	ServerConfigID int64
+	TranscriptID   int32
+	TicketID       pgtype.Text
+	UserID         pgtype.Text
+	StorageKey     string
+	ClosedAt       int64
+	ObjectsDeleted int32
+	PurgedAt       int64
+}
+
+const getTranscriptPurgeLog = `
+SELECT id, server_config_id, transcript_id, ticket_id, user_id, storage_key, closed_at, objects_deleted, purged_at
+FROM transcript_purge_log
+WHERE server_config_id = $1
+ORDER BY purged_at DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+func (q *Queries) GetTranscriptPurgeLog(ctx context.Context, serverConfigID int64, limit, offset int32) ([]TranscriptPurgeLog, error) {
+	rows, err := q.db.Query(ctx, getTranscriptPurgeLog, serverConfigID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptPurgeLog, 0)
+	for rows.Next() {
+		var i TranscriptPurgeLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServerConfigID,
+			&i.TranscriptID,
+			&i.TicketID,
+			&i.UserID,
+			&i.StorageKey,
+			&i.ClosedAt,
+			&i.ObjectsDeleted,
+			&i.PurgedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
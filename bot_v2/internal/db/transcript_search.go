@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+)
+
+// Search uses the 'simple' configuration so servers in any language match
+// on exact words rather than English stems.
+const upsertTranscriptSearch = `
+INSERT INTO transcript_search (transcript_id, server_config_id, body, document)
+VALUES ($1, $2, $3, to_tsvector('simple', $3))
+ON CONFLICT (transcript_id) DO UPDATE
+SET body = EXCLUDED.body,
+    document = EXCLUDED.document
+`
+
+func (q *Queries) UpsertTranscriptSearch(ctx context.Context, transcriptID int32, serverConfigID int64, body string) error {
+	_, err := q.db.Exec(ctx, upsertTranscriptSearch, transcriptID, serverConfigID, body)
+	return err
+}
+
+// Snippet highlights are marked with SearchHighlightStart/End so callers can
+// escape the text before turning them into markup.
+const (
+	SearchHighlightStart = "\x02"
+	SearchHighlightEnd   = "\x03"
+)
+
+type TranscriptSearchResult struct {
+	Transcript Transcript
+	Rank       float32
+	Snippet    string
+}
+
+type SearchTranscriptsParams struct {
+	ServerConfigID int64
+	Query          string
+	Limit          int32
+	Offset         int32
+}
+
+const searchTranscripts = `
+WITH hits AS (
+    SELECT s.transcript_id, s.body, q.query, ts_rank(s.document, q.query) AS rank
+    FROM transcript_search s, websearch_to_tsquery('simple', $2) AS q(query)
+    WHERE s.server_config_id = $1 AND s.document @@ q.query
+    ORDER BY rank DESC, s.transcript_id DESC
+    LIMIT $3 OFFSET $4
+)
+SELECT t.id, t.server_config_id, t.ticket_id, t.username, t.user_id,
+       t.opened_at, t.closed_at, t.closed_by, t.storage_key,
+       t.total_messages, t.total_attachments, t.total_embeds, t.close_reason,
+       t.panel_id, t.participant_ids, t.legal_hold,
+       h.rank,
+       ts_headline('simple', h.body, h.query,
+           'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=3, MaxWords=20, MinWords=6, FragmentDelimiter=" … "')
+FROM hits h
+JOIN transcript t ON t.id = h.transcript_id
+ORDER BY h.rank DESC, t.id DESC
+`
+
+func (q *Queries) SearchTranscripts(ctx context.Context, arg SearchTranscriptsParams) ([]TranscriptSearchResult, error) {
+	rows, err := q.db.Query(ctx, searchTranscripts, arg.ServerConfigID, arg.Query, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptSearchResult, 0)
+	for rows.Next() {
+		var i TranscriptSearchResult
+		if err := rows.Scan(
+			&i.Transcript.ID,
+			&i.Transcript.ServerConfigID,
+			&i.Transcript.TicketID,
+			&i.Transcript.Username,
+			&i.Transcript.UserID,
+			&i.Transcript.OpenedAt,
+			&i.Transcript.ClosedAt,
+			&i.Transcript.ClosedBy,
+			&i.Transcript.StorageKey,
+			&i.Transcript.TotalMessages,
+			&i.Transcript.TotalAttachments,
+			&i.Transcript.TotalEmbeds,
+			&i.Transcript.CloseReason,
+			&i.Transcript.PanelID,
+			&i.Transcript.ParticipantIds,
+			&i.Transcript.LegalHold,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countTranscriptSearch = `
+SELECT count(*)
+FROM transcript_search
+WHERE server_config_id = $1 AND document @@ websearch_to_tsquery('simple', $2)
+`
+
+func (q *Queries) CountTranscriptSearch(ctx context.Context, serverConfigID int64, query string) (int64, error) {
+	row := q.db.QueryRow(ctx, countTranscriptSearch, serverConfigID, query)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+type UnindexedTranscript struct {
+	ID             int32
+	ServerConfigID int64
+	StorageKey     string
+}
+
+const listUnindexedTranscripts = `
+SELECT t.id, t.server_config_id, t.storage_key
+FROM transcript t
+WHERE t.id > $1
+  AND NOT EXISTS (SELECT 1 FROM transcript_search s WHERE s.transcript_id = t.id)
+ORDER BY t.id
+LIMIT $2
+`
+
+// ListUnindexedTranscripts pages through transcripts missing a search row,
+// ordered by id so a backfill can resume after afterID.
+func (q *Queries) ListUnindexedTranscripts(ctx context.Context, afterID int32, limit int32) ([]UnindexedTranscript, error) {
+	rows, err := q.db.Query(ctx, listUnindexedTranscripts, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]UnindexedTranscript, 0)
+	for rows.Next() {
+		var i UnindexedTranscript
+		if err := rows.Scan(&i.ID, &i.ServerConfigID, &i.StorageKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type TranscriptShare struct {
+	ID             int32
+	TranscriptID   int32
+	ServerConfigID int64
+	CreatedBy      string
+	CreatedAt      int64
+	ExpiresAt      int64
+	RevokedAt      pgtype.Int8
+	RevokedBy      pgtype.Text
+	ViewCount      int32
+	LastViewedAt   pgtype.Int8
+}
+
+type TranscriptShareEvent struct {
+	ID        int64
+	ShareID   int32
+	Event     string
+	Actor     pgtype.Text
+	IP        pgtype.Text
+	CreatedAt int64
+}
+
+const transcriptShareColumns = `id, transcript_id, server_config_id, created_by, created_at, expires_at, revoked_at, revoked_by, view_count, last_viewed_at`
+
+func scanTranscriptShare(row interface{ Scan(...any) error }) (TranscriptShare, error) {
+	var i TranscriptShare
+	err := row.Scan(
+		&i.ID,
+		&i.TranscriptID,
+		&i.ServerConfigID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.RevokedBy,
+		&i.ViewCount,
+		&i.LastViewedAt,
+	)
+	return i, err
+}
+
+const createTranscriptShare = `
+WITH share AS (
+    INSERT INTO transcript_share (transcript_id, server_config_id, created_by, created_at, expires_at)
+    SELECT id, server_config_id, $3, $4, $5
+    FROM transcript
+    WHERE id = $1 AND server_config_id = $2
+    RETURNING ` + transcriptShareColumns + `
+), event AS (
+    INSERT INTO transcript_share_event (share_id, event, actor, created_at)
+    SELECT id, 'created', created_by, created_at FROM share
+)
+SELECT ` + transcriptShareColumns + ` FROM share
+`
+
+// CreateTranscriptShare creates a share of a transcript belonging to the
+// server and records who created it. It returns pgx.ErrNoRows when the
+// transcript does not exist.
+func (q *Queries) CreateTranscriptShare(ctx context.Context, transcriptID int32, serverConfigID int64, createdBy string, createdAt, expiresAt int64) (TranscriptShare, error) {
+	return scanTranscriptShare(q.db.QueryRow(ctx, createTranscriptShare, transcriptID, serverConfigID, createdBy, createdAt, expiresAt))
+}
+
+const listTranscriptShares = `
+SELECT ` + transcriptShareColumns + `
+FROM transcript_share
+WHERE transcript_id = $1 AND server_config_id = $2
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) ListTranscriptShares(ctx context.Context, transcriptID int32, serverConfigID int64) ([]TranscriptShare, error) {
+	rows, err := q.db.Query(ctx, listTranscriptShares, transcriptID, serverConfigID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptShare, 0)
+	for rows.Next() {
+		i, err := scanTranscriptShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeTranscriptShare = `
+WITH share AS (
+    UPDATE transcript_share
+    SET revoked_at = $5, revoked_by = $4
+    WHERE id = $1 AND transcript_id = $2 AND server_config_id = $3 AND revoked_at IS NULL
+    RETURNING id
+), event AS (
+    INSERT INTO transcript_share_event (share_id, event, actor, created_at)
+    SELECT id, 'revoked', $4, $5 FROM share
+)
+SELECT count(*) FROM share
+`
+
+// RevokeTranscriptShare revokes an active share. It reports false when the
+// share does not exist or was already revoked.
+func (q *Queries) RevokeTranscriptShare(ctx context.Context, id, transcriptID int32, serverConfigID int64, revokedBy string, revokedAt int64) (bool, error) {
+	var count int64
+	err := q.db.QueryRow(ctx, revokeTranscriptShare, id, transcriptID, serverConfigID, revokedBy, revokedAt).Scan(&count)
+	return count > 0, err
+}
+
+const recordTranscriptShareView = `
+WITH share AS (
+    UPDATE transcript_share
+    SET view_count = view_count + 1, last_viewed_at = $3
+    WHERE id = $1 AND revoked_at IS NULL AND expires_at > $3
+    RETURNING ` + transcriptShareColumns + `
+), event AS (
+    INSERT INTO transcript_share_event (share_id, event, ip, created_at)
+    SELECT id, 'viewed', NULLIF($2, ''), $3 FROM share
+)
+SELECT ` + transcriptShareColumns + ` FROM share
+`
+
+// RecordTranscriptShareView counts a view of an active share and logs it.
+// It returns pgx.ErrNoRows when the share is unknown, expired or revoked.
+func (q *Queries) RecordTranscriptShareView(ctx context.Context, id int32, ip string, now int64) (TranscriptShare, error) {
+	return scanTranscriptShare(q.db.QueryRow(ctx, recordTranscriptShareView, id, ip, now))
+}
+
+const getActiveTranscriptShare = `
+SELECT ` + transcriptShareColumns + `
+FROM transcript_share
+WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2
+`
+
+func (q *Queries) GetActiveTranscriptShare(ctx context.Context, id int32, now int64) (TranscriptShare, error) {
+	return scanTranscriptShare(q.db.QueryRow(ctx, getActiveTranscriptShare, id, now))
+}
+
+const listTranscriptShareEvents = `
+SELECT e.id, e.share_id, e.event, e.actor, e.ip, e.created_at
+FROM transcript_share_event e
+JOIN transcript_share s ON s.id = e.share_id
+WHERE e.share_id = $1 AND s.transcript_id = $2 AND s.server_config_id = $3
+ORDER BY e.id DESC
+LIMIT $4 OFFSET $5
+`
+
+func (q *Queries) ListTranscriptShareEvents(ctx context.Context, shareID, transcriptID int32, serverConfigID int64, limit, offset int32) ([]TranscriptShareEvent, error) {
+	rows, err := q.db.Query(ctx, listTranscriptShareEvents, shareID, transcriptID, serverConfigID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TranscriptShareEvent, 0)
+	for rows.Next() {
+		var i TranscriptShareEvent
+		if err := rows.Scan(&i.ID, &i.ShareID, &i.Event, &i.Actor, &i.IP, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,32 @@
+package db
+
+import "context"
+
+// Kinds of v1 documents tracked in v1_import.
+const (
+	V1ImportPanel      = "panel"
+	V1ImportMultiPanel = "multi_panel"
+	V1ImportTranscript = "transcript"
+)
+
+const getV1Import = `
+SELECT v2_id FROM v1_import WHERE kind = $1 AND v1_id = $2
+`
+
+// GetV1Import returns the v2 id created for a v1 document.
+func (q *Queries) GetV1Import(ctx context.Context, kind, v1ID string) (int64, error) {
+	var id int64
+	err := q.db.QueryRow(ctx, getV1Import, kind, v1ID).Scan(&id)
+	return id, err
+}
+
+const recordV1Import = `
+INSERT INTO v1_import (kind, v1_id, v2_id, imported_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (kind, v1_id) DO UPDATE SET v2_id = EXCLUDED.v2_id, imported_at = EXCLUDED.imported_at
+`
+
+func (q *Queries) RecordV1Import(ctx context.Context, kind, v1ID string, v2ID int64, importedAt int64) error {
+	_, err := q.db.Exec(ctx, recordV1Import, kind, v1ID, v2ID, importedAt)
+	return err
+}
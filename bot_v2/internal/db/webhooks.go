@@ -0,0 +1,386 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// WebhookStatusPending deliveries are queued or waiting for a retry.
+	WebhookStatusPending = "pending"
+	// WebhookStatusDelivered deliveries got a 2xx response.
+	WebhookStatusDelivered = "delivered"
+	// WebhookStatusFailed deliveries ran out of attempts, or were a test
+	// that is never retried.
+	WebhookStatusFailed = "failed"
+)
+
+type WebhookSubscription struct {
+	ID             int32
+	ServerConfigID int64
+	URL            string
+	Secret         string
+	Events         []string
+	Description    string
+	Enabled        bool
+	CreatedAt      int64
+	UpdatedAt      int64
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int32
+	ServerConfigID int64
+	EventID        string
+	Event          string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  int64
+	ResponseStatus pgtype.Int4
+	LastError      pgtype.Text
+	CreatedAt      int64
+	DeliveredAt    pgtype.Int8
+}
+
+// DueWebhookDelivery is a claimed delivery with what is needed to send it.
+type DueWebhookDelivery struct {
+	WebhookDelivery
+	URL     string
+	Secret  string
+	Enabled bool
+}
+
+const webhookSubscriptionColumns = `id, server_config_id, url, secret, events, description, enabled, created_at, updated_at`
+
+func scanWebhookSubscription(row interface{ Scan(...any) error }) (WebhookSubscription, error) {
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.ServerConfigID,
+		&i.URL,
+		&i.Secret,
+		&i.Events,
+		&i.Description,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const webhookDeliveryColumns = `id, subscription_id, server_config_id, event_id, event, payload, status, attempts, next_attempt_at, response_status, last_error, created_at, delivered_at`
+
+func webhookDeliveryFields(i *WebhookDelivery) []any {
+	return []any{
+		&i.ID,
+		&i.SubscriptionID,
+		&i.ServerConfigID,
+		&i.EventID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	}
+}
+
+func scanWebhookDelivery(row interface{ Scan(...any) error }) (WebhookDelivery, error) {
+	var i WebhookDelivery
+	err := row.Scan(webhookDeliveryFields(&i)...)
+	return i, err
+}
+
+const createWebhookSubscription = `
+INSERT INTO webhook_subscription (server_config_id, url, secret, events, description, enabled, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+RETURNING ` + webhookSubscriptionColumns
+
+type CreateWebhookSubscriptionParams struct {
+	ServerConfigID int64
+	URL            string
+	Secret         string
+	Events         []string
+	Description    string
+	Enabled        bool
+	CreatedAt      int64
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	return scanWebhookSubscription(q.db.QueryRow(ctx, createWebhookSubscription,
+		arg.ServerConfigID,
+		arg.URL,
+		arg.Secret,
+		arg.Events,
+		arg.Description,
+		arg.Enabled,
+		arg.CreatedAt,
+	))
+}
+
+const listWebhookSubscriptions = `
+SELECT ` + webhookSubscriptionColumns + `
+FROM webhook_subscription
+WHERE server_config_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context, serverConfigID int64) ([]WebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptions, serverConfigID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]WebhookSubscription, 0)
+	for rows.Next() {
+		i, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookSubscription = `
+SELECT ` + webhookSubscriptionColumns + `
+FROM webhook_subscription
+WHERE id = $1 AND server_config_id = $2
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id int32, serverConfigID int64) (WebhookSubscription, error) {
+	return scanWebhookSubscription(q.db.QueryRow(ctx, getWebhookSubscription, id, serverConfigID))
+}
+
+const updateWebhookSubscription = `
+UPDATE webhook_subscription
+SET url = $3, events = $4, description = $5, enabled = $6, secret = COALESCE(NULLIF($7, ''), secret), updated_at = $8
+WHERE id = $1 AND server_config_id = $2
+RETURNING ` + webhookSubscriptionColumns
+
+type UpdateWebhookSubscriptionParams struct {
+	ID             int32
+	ServerConfigID int64
+	URL            string
+	Events         []string
+	Description    string
+	Enabled        bool
+	// Secret replaces the signing secret when set.
+	Secret    string
+	UpdatedAt int64
+}
+
+// UpdateWebhookSubscription returns pgx.ErrNoRows when the subscription does
+// not belong to the server.
+func (q *Queries) UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	return scanWebhookSubscription(q.db.QueryRow(ctx, updateWebhookSubscription,
+		arg.ID,
+		arg.ServerConfigID,
+		arg.URL,
+		arg.Events,
+		arg.Description,
+		arg.Enabled,
+		arg.Secret,
+		arg.UpdatedAt,
+	))
+}
+
+const deleteWebhookSubscription = `
+DELETE FROM webhook_subscription WHERE id = $1 AND server_config_id = $2
+`
+
+// DeleteWebhookSubscription removes the subscription with its deliveries and
+// reports whether it existed.
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id int32, serverConfigID int64) (bool, error) {
+	tag, err := q.db.Exec(ctx, deleteWebhookSubscription, id, serverConfigID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+const enqueueWebhookEvent = `
+INSERT INTO webhook_delivery (subscription_id, server_config_id, event_id, event, payload, next_attempt_at, created_at)
+SELECT id, server_config_id, $3, $2, $4, $5, $5
+FROM webhook_subscription
+WHERE server_config_id = $1 AND enabled AND $2 = ANY(events)
+ON CONFLICT (subscription_id, event_id) DO NOTHING
+`
+
+// EnqueueWebhookEvent queues a delivery of payload for every enabled
+// subscription of the server that wants event. An event already queued
+// under the same eventID is not queued again. It returns how many
+// deliveries were queued.
+func (q *Queries) EnqueueWebhookEvent(ctx context.Context, serverConfigID int64, event, eventID string, payload []byte, now int64) (int64, error) {
+	tag, err := q.db.Exec(ctx, enqueueWebhookEvent, serverConfigID, event, eventID, payload, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+const createWebhookDelivery = `
+INSERT INTO webhook_delivery (subscription_id, server_config_id, event_id, event, payload, next_attempt_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ` + webhookDeliveryColumns
+
+// CreateWebhookDelivery queues a delivery for a single subscription, for
+// test events.
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, subscriptionID int32, serverConfigID int64, event, eventID string, payload []byte, nextAttemptAt, now int64) (WebhookDelivery, error) {
+	return scanWebhookDelivery(q.db.QueryRow(ctx, createWebhookDelivery, subscriptionID, serverConfigID, eventID, event, payload, nextAttemptAt, now))
+}
+
+const claimDueWebhookDeliveries = `
+WITH due AS (
+    SELECT id FROM webhook_delivery
+    WHERE status = 'pending' AND next_attempt_at <= $1
+    ORDER BY next_attempt_at, id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE webhook_delivery d
+SET next_attempt_at = $2
+FROM due, webhook_subscription s
+WHERE d.id = due.id AND s.id = d.subscription_id
+RETURNING d.id, d.subscription_id, d.server_config_id, d.event_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at, d.response_status, d.last_error, d.created_at, d.delivered_at, s.url, s.secret, s.enabled
+`
+
+// ClaimDueWebhookDeliveries picks pending deliveries that are due and pushes
+// them back to leaseUntil, so a delivery still in flight is not picked up
+// again. A crashed sender's deliveries become due once the lease runs out.
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, now, leaseUntil int64, limit int32) ([]DueWebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, claimDueWebhookDeliveries, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]DueWebhookDelivery, 0)
+	for rows.Next() {
+		var i DueWebhookDelivery
+		fields := append(webhookDeliveryFields(&i.WebhookDelivery), &i.URL, &i.Secret, &i.Enabled)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markWebhookDelivered = `
+UPDATE webhook_delivery
+SET status = 'delivered', attempts = attempts + 1, response_status = $2, last_error = NULL, delivered_at = $3
+WHERE id = $1
+`
+
+func (q *Queries) MarkWebhookDelivered(ctx context.Context, id int64, responseStatus int32, now int64) error {
+	_, err := q.db.Exec(ctx, markWebhookDelivered, id, responseStatus, now)
+	return err
+}
+
+const retryWebhookDelivery = `
+UPDATE webhook_delivery
+SET attempts = attempts + 1, next_attempt_at = $2, response_status = $3, last_error = $4
+WHERE id = $1
+RETURNING attempts
+`
+
+// RetryWebhookDelivery records a failed attempt and returns the attempt count.
+func (q *Queries) RetryWebhookDelivery(ctx context.Context, id int64, nextAttemptAt int64, responseStatus pgtype.Int4, lastError string) (int32, error) {
+	var attempts int32
+	err := q.db.QueryRow(ctx, retryWebhookDelivery, id, nextAttemptAt, responseStatus, lastError).Scan(&attempts)
+	return attempts, err
+}
+
+const failWebhookDelivery = `
+UPDATE webhook_delivery
+SET status = 'failed', attempts = attempts + 1, response_status = $2, last_error = $3
+WHERE id = $1
+`
+
+// FailWebhookDelivery records a failed attempt and gives up on the delivery.
+func (q *Queries) FailWebhookDelivery(ctx context.Context, id int64, responseStatus pgtype.Int4, lastError string) error {
+	_, err := q.db.Exec(ctx, failWebhookDelivery, id, responseStatus, lastError)
+	return err
+}
+
+const getWebhookDelivery = `
+SELECT ` + webhookDeliveryColumns + `
+FROM webhook_delivery
+WHERE id = $1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	return scanWebhookDelivery(q.db.QueryRow(ctx, getWebhookDelivery, id))
+}
+
+const listWebhookDeliveries = `
+SELECT ` + webhookDeliveryColumns + `
+FROM webhook_delivery
+WHERE subscription_id = $1 AND server_config_id = $2 AND ($3::BIGINT = 0 OR id < $3)
+ORDER BY id DESC
+LIMIT $4
+`
+
+// ListWebhookDeliveries returns the subscription's newest deliveries first.
+// A non-zero beforeID continues from an earlier page.
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, subscriptionID int32, serverConfigID int64, beforeID int64, limit int32) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, subscriptionID, serverConfigID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]WebhookDelivery, 0)
+	for rows.Next() {
+		i, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const redeliverWebhookDelivery = `
+UPDATE webhook_delivery
+SET status = 'pending', attempts = 0, next_attempt_at = $4, delivered_at = NULL
+WHERE id = $1 AND subscription_id = $2 AND server_config_id = $3 AND status <> 'pending'
+RETURNING ` + webhookDeliveryColumns
+
+// RedeliverWebhookDelivery queues a finished delivery to be sent again with
+// a fresh set of attempts. It returns pgx.ErrNoRows when the delivery does
+// not exist or is still pending.
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, id int64, subscriptionID int32, serverConfigID int64, now int64) (WebhookDelivery, error) {
+	return scanWebhookDelivery(q.db.QueryRow(ctx, redeliverWebhookDelivery, id, subscriptionID, serverConfigID, now))
+}
+
+const deleteWebhookDeliveriesBefore = `
+DELETE FROM webhook_delivery
+WHERE status <> 'pending' AND created_at < $1
+`
+
+// DeleteWebhookDeliveriesBefore prunes the delivery log of finished
+// deliveries created before cutoff.
+func (q *Queries) DeleteWebhookDeliveriesBefore(ctx context.Context, cutoff int64) (int64, error) {
+	tag, err := q.db.Exec(ctx, deleteWebhookDeliveriesBefore, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,74 @@
+// Package metrics keeps process-wide counters and gauges and serves them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type metric struct {
+	kind  string
+	help  string
+	value float64
+}
+
+var (
+	mu      sync.RWMutex
+	metrics = map[string]*metric{}
+)
+
+// Add increments the counter name by delta, creating it on first use.
+func Add(name, help string, delta float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	m := lookup(name, "counter", help)
+	m.value += delta
+}
+
+// Set stores value in the gauge name, creating it on first use.
+func Set(name, help string, value float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	m := lookup(name, "gauge", help)
+	m.value = value
+}
+
+func lookup(name, kind, help string) *metric {
+	m, ok := metrics[name]
+	if !ok {
+		m = &metric{kind: kind, help: help}
+		metrics[name] = m
+	}
+	return m
+}
+
+// Handler writes every registered metric, sorted by name.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	mu.RLock()
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		m := metrics[name]
+		if m.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, m.help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, m.kind)
+		fmt.Fprintf(&b, "%s %g\n", name, m.value)
+	}
+	mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(b.String()))
+}
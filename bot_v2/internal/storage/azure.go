@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+)
+
+type azureBackend struct {
+	client        *azblob.Client
+	containerName string
+	connStr       string // kept to create per-blob clients for SAS links
+}
+
+// NewAzureBackend connects to an Azure Storage container.
+func NewAzureBackend(connStr, containerName string) (Backend, error) {
+	if connStr == "" || containerName == "" {
+		return nil, errors.New("azure storage needs AZURE_STORAGE_CONNECTION_STRING and AZURE_STORAGE_CONTAINER")
+	}
+
+	// The root client is highly optimized for uploading streams
+	client, err := azblob.NewClientFromConnectionString(connStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &azureBackend{client: client, containerName: containerName, connStr: connStr}, nil
+}
+
+func (b *azureBackend) Put(ctx context.Context, key string, data io.Reader, meta ObjectMeta) error {
+	opts := &azblob.UploadStreamOptions{}
+	if meta.ContentType != "" || meta.ContentEncoding != "" {
+		opts.HTTPHeaders = &blob.HTTPHeaders{}
+		if meta.ContentType != "" {
+			opts.HTTPHeaders.BlobContentType = &meta.ContentType
+		}
+		if meta.ContentEncoding != "" {
+			opts.HTTPHeaders.BlobContentEncoding = &meta.ContentEncoding
+		}
+	}
+	_, err := b.client.UploadStream(ctx, b.containerName, key, data, opts)
+	return err
+}
+
+func (b *azureBackend) Get(ctx context.Context, key string) (io.ReadCloser, ObjectMeta, error) {
+	resp, err := b.client.DownloadStream(ctx, b.containerName, key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			err = ErrNotFound
+		}
+		return nil, ObjectMeta{}, err
+	}
+
+	meta := ObjectMeta{Size: -1}
+	if resp.ContentType != nil {
+		meta.ContentType = *resp.ContentType
+	}
+	if resp.ContentEncoding != nil {
+		meta.ContentEncoding = *resp.ContentEncoding
+	}
+	if resp.ContentLength != nil {
+		meta.Size = *resp.ContentLength
+	}
+	return resp.Body, meta, nil
+}
+
+func (b *azureBackend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteBlob(ctx, b.containerName, key, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (b *azureBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	pager := b.client.NewListBlobsFlatPager(b.containerName, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	keys := make([]string, 0)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil {
+				keys = append(keys, *item.Name)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// SignedURL creates a read-only SAS link for the blob.
+func (b *azureBackend) SignedURL(key string, expiry time.Duration) (string, error) {
+	blobClient, err := blob.NewClientFromConnectionString(b.connStr, b.containerName, key, nil)
+	if err != nil {
+		return "", err
+	}
+	return blobClient.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().UTC().Add(expiry), nil)
+}
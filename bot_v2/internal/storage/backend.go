@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Backend names accepted in Config.Backend.
+const (
+	BackendAzure = "azure"
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+var (
+	// ErrNotFound is returned by backends when a key does not exist.
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrSignedURLUnsupported is returned by backends that cannot hand out
+	// direct links, such as the local filesystem.
+	ErrSignedURLUnsupported = errors.New("storage: signed urls are not supported by this backend")
+)
+
+// ObjectMeta describes a stored object. Size is -1 when unknown.
+type ObjectMeta struct {
+	ContentType     string
+	ContentEncoding string
+	Size            int64
+}
+
+// Backend is a flat object store. Keys are slash-separated paths.
+type Backend interface {
+	// Put writes data to key, replacing any existing object.
+	Put(ctx context.Context, key string, data io.Reader, meta ObjectMeta) error
+	// Get opens key for reading. The caller must close the body.
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectMeta, error)
+	// Delete removes key. A key that is already gone is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns every key starting with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// SignedURL returns a read-only link to key that expires after expiry.
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
+
+// Config selects and configures a Backend.
+type Config struct {
+	// Backend is BackendAzure, BackendLocal or BackendS3. Empty means Azure,
+	// the original backend.
+	Backend string
+
+	AzureConnectionString string
+	AzureContainer        string
+
+	// LocalDir is the root directory of the local backend.
+	LocalDir string
+
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3UseSSL          bool
+}
+
+// OpenBackend connects to the backend cfg selects.
+func OpenBackend(cfg Config) (Backend, error) {
+	switch cfg.Backend {
+	case "", BackendAzure:
+		return NewAzureBackend(cfg.AzureConnectionString, cfg.AzureContainer)
+	case BackendLocal:
+		return NewLocalBackend(cfg.LocalDir)
+	case BackendS3:
+		return NewS3Backend(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// Open connects to the backend cfg selects and wraps it in a Client. A nil
+// keyring leaves new transcripts unencrypted.
+func Open(cfg Config, keyring *Keyring) (Client, error) {
+	backend, err := OpenBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return New(backend, keyring), nil
+}
+
+// IsNotFound reports whether err means the object does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// EncodingGzip marks a gzip-compressed transcript. The gzip magic number at
+// the start of the blob is the format marker; transcripts stored before
+// compression are plain JSON and start with '{'.
+const EncodingGzip = "gzip"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func compressTranscript(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sniffTranscript peeks at the start of body to find its encoding without
+// consuming it.
+func sniffTranscript(body io.ReadCloser) (io.ReadCloser, string) {
+	br := bufio.NewReader(body)
+	encoding := ""
+	if magic, err := br.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		encoding = EncodingGzip
+	}
+	return readCloser{Reader: br, Closer: body}, encoding
+}
+
+// sniffEnvelope reports whether body is an encrypted envelope without
+// consuming it.
+func sniffEnvelope(body io.ReadCloser) (io.ReadCloser, bool) {
+	br := bufio.NewReader(body)
+	magic, err := br.Peek(len(envelopeMagic))
+	return readCloser{Reader: br, Closer: body}, err == nil && isEnvelope(magic)
+}
+
+// DecodeTranscript wraps body so it yields plain JSON. Closing the result
+// does not close body.
+func DecodeTranscript(body io.Reader, encoding string) (io.ReadCloser, error) {
+	if encoding == EncodingGzip {
+		return gzip.NewReader(body)
+	}
+	return io.NopCloser(body), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+)
+
+// Encrypted transcripts use an envelope: each blob has its own random data
+// key, and only that key is encrypted ("wrapped") with a master key. Layout:
+//
+//	"FNSE" | version (1) | key ID length (1) | key ID | wrapped key length (2) | wrapped key | body
+//
+// The body is the AES-GCM sealed transcript. Rotating the master key only
+// rewrites the header, since the body does not depend on it. The version
+// tells transcripts (1) from streamed objects (2), whose body is sealed in
+// chunks; see stream.go.
+var envelopeMagic = []byte("FNSE")
+
+const envelopeVersion = 1
+
+var envelopeBodyAAD = []byte("fns-transcript-v1")
+
+var errNoKeyring = errors.New("transcript is encrypted but no transcript keys are configured")
+
+// Keyring holds the master keys by ID. New transcripts are wrapped with the
+// active key; older keys stay available for reading until re-wrapped.
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+func NewKeyring(activeID string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active transcript key %q is not configured", activeID)
+	}
+	for id, key := range keys {
+		if len(id) == 0 || len(id) > 255 {
+			return nil, fmt.Errorf("invalid transcript key id %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("transcript key %q must be 32 bytes", id)
+		}
+	}
+	return &Keyring{activeID: activeID, keys: keys}, nil
+}
+
+func (k *Keyring) ActiveID() string {
+	return k.activeID
+}
+
+func isEnvelope(data []byte) bool {
+	return bytes.HasPrefix(data, envelopeMagic)
+}
+
+// seal encrypts plaintext under a fresh data key wrapped with the active key.
+func (k *Keyring) seal(plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	body, err := utils.SealAESGCM(dataKey, plaintext, envelopeBodyAAD)
+	if err != nil {
+		return nil, err
+	}
+	header, err := k.header(envelopeVersion, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+// open decrypts an envelope and returns the plaintext and its key ID.
+func (k *Keyring) open(data []byte) ([]byte, string, error) {
+	version, keyID, dataKey, body, err := k.unwrap(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if version != envelopeVersion {
+		return nil, "", errors.New("unsupported transcript envelope")
+	}
+	plaintext, err := utils.OpenAESGCM(dataKey, body, envelopeBodyAAD)
+	if err != nil {
+		return nil, "", fmt.Errorf("decrypt transcript: %w", err)
+	}
+	return plaintext, keyID, nil
+}
+
+// rewrap re-encrypts the data key of an envelope with the active key. It
+// reports false when the envelope already uses the active key.
+func (k *Keyring) rewrap(data []byte) ([]byte, bool, error) {
+	version, keyID, dataKey, body, err := k.unwrap(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if keyID == k.activeID {
+		return data, false, nil
+	}
+	header, err := k.header(version, dataKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return append(header, body...), true, nil
+}
+
+func (k *Keyring) header(version byte, dataKey []byte) ([]byte, error) {
+	wrapped, err := utils.SealAESGCM(k.keys[k.activeID], dataKey, []byte(k.activeID))
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(envelopeMagic)+4+len(k.activeID)+len(wrapped))
+	header = append(header, envelopeMagic...)
+	header = append(header, version, byte(len(k.activeID)))
+	header = append(header, k.activeID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	return append(header, wrapped...), nil
+}
+
+// unwrap splits an envelope and decrypts its data key. It returns the
+// envelope version, the key ID, the data key and the body.
+func (k *Keyring) unwrap(data []byte) (byte, string, []byte, []byte, error) {
+	rest, ok := bytes.CutPrefix(data, envelopeMagic)
+	if !ok || len(rest) < 2 || (rest[0] != envelopeVersion && rest[0] != objectEnvelopeVersion) {
+		return 0, "", nil, nil, errors.New("unsupported transcript envelope")
+	}
+	version := rest[0]
+	idLen := int(rest[1])
+	rest = rest[2:]
+	if len(rest) < idLen+2 {
+		return 0, "", nil, nil, errors.New("truncated transcript envelope")
+	}
+	keyID := string(rest[:idLen])
+	rest = rest[idLen:]
+	wrappedLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < wrappedLen {
+		return 0, "", nil, nil, errors.New("truncated transcript envelope")
+	}
+
+	masterKey, ok := k.keys[keyID]
+	if !ok {
+		return 0, "", nil, nil, fmt.Errorf("transcript key %q is not configured", keyID)
+	}
+	dataKey, err := utils.OpenAESGCM(masterKey, rest[:wrappedLen], []byte(keyID))
+	if err != nil {
+		return 0, "", nil, nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return version, keyID, dataKey, rest[wrappedLen:], nil
+}
+
+// readHeader reads just the envelope header from the start of r, which
+// must begin with envelopeMagic.
+func readHeader(r io.Reader) ([]byte, error) {
+	header := make([]byte, len(envelopeMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	rest := make([]byte, int(header[len(header)-1])+2)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+	header = append(header, rest...)
+	wrapped := make([]byte, binary.BigEndian.Uint16(rest[len(rest)-2:]))
+	if _, err := io.ReadFull(r, wrapped); err != nil {
+		return nil, err
+	}
+	return append(header, wrapped...), nil
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localMetaDir holds each object's ObjectMeta as JSON, mirroring the object
+// tree, so objects keep their content type without extensions in keys.
+const localMetaDir = ".meta"
+
+type localBackend struct {
+	root string
+}
+
+// NewLocalBackend stores objects as files under dir, for self-hosting and
+// development. It has no signed URLs, so the dashboard reads transcripts
+// through the API.
+func NewLocalBackend(dir string) (Backend, error) {
+	if dir == "" {
+		return nil, errors.New("local storage needs STORAGE_LOCAL_DIR")
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	return &localBackend{root: root}, nil
+}
+
+// path maps key to a file under root, rejecting keys that would escape it.
+func (b *localBackend) path(dir, key string) (string, error) {
+	rel := filepath.FromSlash(key)
+	if key == "" || !filepath.IsLocal(rel) || strings.HasPrefix(key, localMetaDir+"/") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(b.root, dir, rel), nil
+}
+
+func (b *localBackend) Put(ctx context.Context, key string, data io.Reader, meta ObjectMeta) error {
+	path, err := b.path("", key)
+	if err != nil {
+		return err
+	}
+	metaPath, err := b.path(localMetaDir, key)
+	if err != nil {
+		return err
+	}
+
+	size, err := writeFileAtomic(path, data)
+	if err != nil {
+		return err
+	}
+	meta.Size = size
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	_, err = writeFileAtomic(metaPath+".json", bytes.NewReader(encoded))
+	return err
+}
+
+func (b *localBackend) Get(ctx context.Context, key string) (io.ReadCloser, ObjectMeta, error) {
+	path, err := b.path("", key)
+	if err != nil {
+		return nil, ObjectMeta{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = ErrNotFound
+		}
+		return nil, ObjectMeta{}, err
+	}
+
+	meta := ObjectMeta{Size: -1}
+	if metaPath, err := b.path(localMetaDir, key); err == nil {
+		if encoded, err := os.ReadFile(metaPath + ".json"); err == nil {
+			_ = json.Unmarshal(encoded, &meta)
+		}
+	}
+	if info, err := f.Stat(); err == nil {
+		meta.Size = info.Size()
+	}
+	return f, meta, nil
+}
+
+func (b *localBackend) Delete(ctx context.Context, key string) error {
+	path, err := b.path("", key)
+	if err != nil {
+		return err
+	}
+	metaPath, err := b.path(localMetaDir, key)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{path, metaPath + ".json"} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *localBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	// Walk only the directory the prefix points into, not the whole root.
+	start := b.root
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		var err error
+		if start, err = b.path("", prefix[:i]); err != nil {
+			return nil, err
+		}
+	}
+	err := filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(b.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == localMetaDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(key, prefix) && !strings.HasSuffix(d.Name(), ".tmp") {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (b *localBackend) SignedURL(key string, expiry time.Duration) (string, error) {
+	return "", ErrSignedURLUnsupported
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partial object.
+func writeFileAtomic(path string, data io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(f, data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return 0, err
+	}
+	return size, nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the multipart chunk size. minio-go buffers one part per
+// upload, and for streams of unknown size it would otherwise pick parts of
+// about 512 MiB to fit its largest possible object.
+const s3PartSize = 16 << 20
+
+type s3Backend struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Backend connects to an S3-compatible bucket, such as AWS S3 or MinIO.
+// The bucket must already exist.
+func NewS3Backend(cfg Config) (Backend, error) {
+	if cfg.S3Endpoint == "" || cfg.S3Bucket == "" {
+		return nil, errors.New("s3 storage needs S3_ENDPOINT and S3_BUCKET")
+	}
+	if cfg.S3AccessKeyID == "" || cfg.S3SecretAccessKey == "" {
+		return nil, errors.New("s3 storage needs S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+	}
+
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKeyID, cfg.S3SecretAccessKey, ""),
+		Secure: cfg.S3UseSSL,
+		// Setting the region skips a bucket location lookup, which also
+		// keeps presigning offline.
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3Backend{client: client, bucket: cfg.S3Bucket}, nil
+}
+
+func (b *s3Backend) Put(ctx context.Context, key string, data io.Reader, meta ObjectMeta) error {
+	size := meta.Size
+	if size == 0 {
+		size = -1
+	}
+	_, err := b.client.PutObject(ctx, b.bucket, key, data, size, minio.PutObjectOptions{
+		ContentType:     meta.ContentType,
+		ContentEncoding: meta.ContentEncoding,
+		PartSize:        s3PartSize,
+	})
+	return err
+}
+
+func (b *s3Backend) Get(ctx context.Context, key string) (io.ReadCloser, ObjectMeta, error) {
+	obj, err := b.client.GetObject(ctx, b.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectMeta{}, s3Error(err)
+	}
+	// GetObject is lazy; Stat makes the request and surfaces missing keys.
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, ObjectMeta{}, s3Error(err)
+	}
+	return obj, ObjectMeta{
+		ContentType:     info.ContentType,
+		ContentEncoding: info.Metadata.Get("Content-Encoding"),
+		Size:            info.Size,
+	}, nil
+}
+
+func (b *s3Backend) Delete(ctx context.Context, key string) error {
+	// S3 deletes are idempotent, so a missing key is not an error.
+	return b.client.RemoveObject(ctx, b.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (b *s3Backend) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	for obj := range b.client.ListObjects(ctx, b.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
+
+func (b *s3Backend) SignedURL(key string, expiry time.Duration) (string, error) {
+	u, err := b.client.PresignedGetObject(context.Background(), b.bucket, key, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func s3Error(err error) error {
+	if code := minio.ToErrorResponse(err).Code; code == "NoSuchKey" || code == "NotFound" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -1,77 +1,281 @@
-// internal/storage/azure.go
 package storage
 
 import (
 	"bytes"
 	"context"
 	"io"
-	"log"
-	"os"
 	"time"
-
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob" // Added specific blob package
-	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 )
 
-type Client struct {
-	client        *azblob.Client
-	containerName string
-	connStr       string // We will store this to spawn blob clients instantly
+// Client stores transcripts and their companion objects. Transcripts are
+// gzipped; with a keyring set, transcripts and other objects alike are
+// encrypted.
+type Client interface {
+	// EncryptsTranscripts reports whether stored transcripts are encrypted,
+	// in which case presigned links would only serve ciphertext.
+	EncryptsTranscripts() bool
+	UploadTranscript(ctx context.Context, key string, data []byte) (int64, error)
+	DownloadTranscript(ctx context.Context, key string) ([]byte, error)
+	OpenTranscript(ctx context.Context, key string) (io.ReadCloser, string, error)
+	RewrapTranscript(ctx context.Context, key string) (bool, error)
+	GeneratePresignedURL(key string) (string, error)
+
+	UploadObject(ctx context.Context, key, contentType string, data io.Reader, size int64) error
+	DownloadObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error)
+	RewrapObject(ctx context.Context, key string) (bool, error)
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	ObjectSize(ctx context.Context, key string) (int64, error)
+	DeleteObject(ctx context.Context, key string) error
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// presignedURLExpiry is how long links from GeneratePresignedURL last.
+const presignedURLExpiry = time.Hour
+
+type client struct {
+	backend Backend
+	keyring *Keyring
+}
+
+// New wraps backend in a Client. With a keyring, transcripts written from
+// now on are encrypted, and can only be read while their key is in the
+// keyring.
+func New(backend Backend, keyring *Keyring) Client {
+	return &client{backend: backend, keyring: keyring}
+}
+
+func (c *client) EncryptsTranscripts() bool {
+	return c.keyring != nil
+}
+
+// UploadTranscript gzips the transcript JSON and stores it, returning the
+// stored size. The object's Content-Encoding lets presigned links
+// decompress in the browser. With a keyring set, the gzipped JSON is
+// encrypted in an envelope instead.
+func (c *client) UploadTranscript(ctx context.Context, key string, data []byte) (int64, error) {
+	compressed, err := compressTranscript(data)
+	if err != nil {
+		return 0, err
+	}
+	if c.keyring != nil {
+		sealed, err := c.keyring.seal(compressed)
+		if err != nil {
+			return 0, err
+		}
+		return int64(len(sealed)), c.putTranscript(ctx, key, sealed, "application/octet-stream", "")
+	}
+	return int64(len(compressed)), c.putTranscript(ctx, key, compressed, "application/json", EncodingGzip)
+}
+
+// RewrapTranscript re-wraps the data key of an encrypted transcript with the
+// active master key, leaving the body untouched. Transcripts stored before
+// encryption was enabled are encrypted in full. It reports whether the
+// object was rewritten.
+func (c *client) RewrapTranscript(ctx context.Context, key string) (bool, error) {
+	if c.keyring == nil {
+		return false, errNoKeyring
+	}
+	body, _, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return false, err
+	}
+
+	if isEnvelope(data) {
+		rewrapped, changed, err := c.keyring.rewrap(data)
+		if err != nil || !changed {
+			return false, err
+		}
+		return true, c.putTranscript(ctx, key, rewrapped, "application/octet-stream", "")
+	}
+
+	if !bytes.HasPrefix(data, gzipMagic) {
+		if data, err = compressTranscript(data); err != nil {
+			return false, err
+		}
+	}
+	sealed, err := c.keyring.seal(data)
+	if err != nil {
+		return false, err
+	}
+	return true, c.putTranscript(ctx, key, sealed, "application/octet-stream", "")
+}
+
+func (c *client) putTranscript(ctx context.Context, key string, data []byte, contentType, encoding string) error {
+	return c.backend.Put(ctx, key, bytes.NewReader(data), ObjectMeta{
+		ContentType:     contentType,
+		ContentEncoding: encoding,
+		Size:            int64(len(data)),
+	})
 }
 
-// NewAzureClient connects to your Azure Storage Account
-func NewAzureClient() *Client {
-	connStr := os.Getenv("AZURE_STORAGE_CONNECTION_STRING")
-	containerName := os.Getenv("AZURE_STORAGE_CONTAINER")
+// GeneratePresignedURL creates a read-only link for the dashboard that
+// lasts an hour. Backends without links return ErrSignedURLUnsupported.
+func (c *client) GeneratePresignedURL(key string) (string, error) {
+	return c.backend.SignedURL(key, presignedURLExpiry)
+}
 
-	if connStr == "" || containerName == "" {
-		log.Fatal("Missing AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_CONTAINER in .env")
+// DownloadTranscript reads transcript JSON, decrypting and decompressing it
+// when needed.
+func (c *client) DownloadTranscript(ctx context.Context, key string) ([]byte, error) {
+	body, encoding, err := c.OpenTranscript(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	decoded, err := DecodeTranscript(body, encoding)
+	if err != nil {
+		return nil, err
 	}
+	defer decoded.Close()
+	return io.ReadAll(decoded)
+}
 
-	// The root client is highly optimized for uploading streams
-	client, err := azblob.NewClientFromConnectionString(connStr, nil)
+// OpenTranscript opens the stored transcript without decompressing it and
+// reports its encoding: EncodingGzip, or "" for plain JSON. Encrypted
+// transcripts are decrypted first, so the result is never ciphertext.
+func (c *client) OpenTranscript(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	body, _, err := c.backend.Get(ctx, key)
 	if err != nil {
-		log.Fatalf("Failed to create Azure client: %v", err)
+		return nil, "", err
 	}
+	body, encrypted := sniffEnvelope(body)
+	if encrypted {
+		defer body.Close()
+		if c.keyring == nil {
+			return nil, "", errNoKeyring
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, "", err
+		}
+		plaintext, _, err := c.keyring.open(data)
+		if err != nil {
+			return nil, "", err
+		}
+		body = io.NopCloser(bytes.NewReader(plaintext))
+	}
+	body, encoding := sniffTranscript(body)
+	return body, encoding, nil
+}
 
-	return &Client{
-		client:        client,
-		containerName: containerName,
-		connStr:       connStr, // Save the connection string for SAS generation
+// UploadObject streams data to key with the given content type. size is the
+// length of data, or -1 when it is not known up front. With a keyring set,
+// data is encrypted on the way; the stored content type stays the
+// plaintext's so downloads can report it.
+func (c *client) UploadObject(ctx context.Context, key, contentType string, data io.Reader, size int64) error {
+	if c.keyring != nil {
+		sealed, sealedSize, err := c.keyring.sealObject(data, size)
+		if err != nil {
+			return err
+		}
+		data, size = sealed, sealedSize
 	}
+	return c.backend.Put(ctx, key, data, ObjectMeta{ContentType: contentType, Size: size})
 }
 
-// UploadTranscript streams your minified JSON straight into Azure
-func (c *Client) UploadTranscript(ctx context.Context, key string, data []byte) error {
-	reader := bytes.NewReader(data)
-	_, err := c.client.UploadStream(ctx, c.containerName, key, reader, &azblob.UploadStreamOptions{})
-	return err
+// DownloadObject opens key for streaming, decrypting it when needed, and
+// returns its content type and size. The size is -1 for encrypted objects.
+// The caller must close the body.
+func (c *client) DownloadObject(ctx context.Context, key string) (io.ReadCloser, string, int64, error) {
+	body, meta, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	body, encrypted := sniffEnvelope(body)
+	if !encrypted {
+		return body, meta.ContentType, meta.Size, nil
+	}
+	if c.keyring == nil {
+		body.Close()
+		return nil, "", 0, errNoKeyring
+	}
+	plaintext, _, err := c.keyring.openObject(body)
+	if err != nil {
+		body.Close()
+		return nil, "", 0, err
+	}
+	return readCloser{Reader: plaintext, Closer: body}, meta.ContentType, -1, nil
 }
 
-// GeneratePresignedURL creates a secure, 1-hour link for the React dashboard
-func (c *Client) GeneratePresignedURL(key string) (string, error) {
-	// 1. Create a client dedicated to this exact blob file
-	blobClient, err := blob.NewClientFromConnectionString(c.connStr, c.containerName, key, nil)
+// RewrapObject is RewrapTranscript for objects stored with UploadObject.
+// The body is streamed through, so large objects are never held in memory.
+func (c *client) RewrapObject(ctx context.Context, key string) (bool, error) {
+	if c.keyring == nil {
+		return false, errNoKeyring
+	}
+	body, meta, err := c.backend.Get(ctx, key)
 	if err != nil {
-		return "", err
+		return false, err
 	}
+	defer body.Close()
+	body, encrypted := sniffEnvelope(body)
 
-	// 2. Set Read-only permissions and 1-hour expiration
-	permissions := sas.BlobPermissions{Read: true}
-	expiry := time.Now().UTC().Add(1 * time.Hour)
+	var data io.Reader
+	size := int64(-1)
+	if encrypted {
+		header, err := readHeader(body)
+		if err != nil {
+			return false, err
+		}
+		rewrapped, changed, err := c.keyring.rewrap(header)
+		if err != nil || !changed {
+			return false, err
+		}
+		data = io.MultiReader(bytes.NewReader(rewrapped), body)
+		if meta.Size >= 0 {
+			size = meta.Size - int64(len(header)) + int64(len(rewrapped))
+		}
+	} else {
+		if data, size, err = c.keyring.sealObject(body, meta.Size); err != nil {
+			return false, err
+		}
+	}
+	return true, c.backend.Put(ctx, key, data, ObjectMeta{ContentType: meta.ContentType, Size: size})
+}
 
-	// 3. Generate the SAS URL directly from the blob client
-	return blobClient.GetSASURL(permissions, expiry, nil)
+// ListObjects returns every key starting with prefix.
+func (c *client) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	return c.backend.List(ctx, prefix)
 }
 
-// DownloadTranscript reads transcript JSON from Azure blob storage.
-func (c *Client) DownloadTranscript(ctx context.Context, key string) ([]byte, error) {
-	resp, err := c.client.DownloadStream(ctx, c.containerName, key, nil)
+// ObjectSize returns the stored size of key, which for encrypted objects is
+// the size of the ciphertext.
+func (c *client) ObjectSize(ctx context.Context, key string) (int64, error) {
+	body, meta, err := c.backend.Get(ctx, key)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer resp.Body.Close()
+	defer body.Close()
+	if meta.Size >= 0 {
+		return meta.Size, nil
+	}
+	return io.Copy(io.Discard, body)
+}
+
+// DeleteObject removes key. A key that is already gone is not an error.
+func (c *client) DeleteObject(ctx context.Context, key string) error {
+	return c.backend.Delete(ctx, key)
+}
 
-	return io.ReadAll(resp.Body)
+// DeletePrefix removes every object whose key starts with prefix and returns
+// how many were deleted.
+func (c *client) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	keys, err := c.backend.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, key := range keys {
+		if err := c.backend.Delete(ctx, key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
 }
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+)
+
+// Objects such as export archives and attachments are streamed and can be
+// too large to seal in one piece, so their envelope (version 2) seals the
+// body in chunks. The header is the same as a transcript's; each chunk is
+//
+//	last (1) | sealed length (4) | AES-GCM sealed plaintext
+//
+// with at most objectChunkSize bytes of plaintext. Each chunk is sealed with
+// its index and the last flag, so chunks cannot be reordered, dropped or cut
+// off at the end without failing to open.
+const (
+	objectEnvelopeVersion = 2
+	objectChunkSize       = 64 << 10
+	// objectChunkOverhead is the frame, nonce and tag added to each chunk.
+	objectChunkOverhead = 1 + 4 + 12 + 16
+)
+
+var objectChunkAAD = []byte("fns-object-v2")
+
+var errTruncatedObject = errors.New("encrypted object is truncated")
+
+// sealObject returns a reader of data sealed under a fresh data key, and the
+// sealed size when size, the length of data, is known. Otherwise the sealed
+// size is -1.
+func (k *Keyring) sealObject(data io.Reader, size int64) (io.Reader, int64, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, 0, err
+	}
+	header, err := k.header(objectEnvelopeVersion, dataKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	sealedSize := int64(-1)
+	if size >= 0 {
+		// A last chunk is always written, even an empty one.
+		chunks := size/objectChunkSize + 1
+		sealedSize = int64(len(header)) + size + chunks*objectChunkOverhead
+	}
+	return io.MultiReader(bytes.NewReader(header), &sealReader{src: data, dataKey: dataKey}), sealedSize, nil
+}
+
+// openObject reads the envelope header from body and returns a reader of
+// the plaintext, along with the key ID the data key is wrapped with.
+func (k *Keyring) openObject(body io.Reader) (io.Reader, string, error) {
+	header, err := readHeader(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("read object envelope: %w", err)
+	}
+	version, keyID, dataKey, _, err := k.unwrap(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if version != objectEnvelopeVersion {
+		return nil, "", errors.New("unsupported object envelope")
+	}
+	return &openReader{src: body, dataKey: dataKey}, keyID, nil
+}
+
+func chunkAAD(index uint64, last bool) []byte {
+	aad := binary.BigEndian.AppendUint64(append([]byte(nil), objectChunkAAD...), index)
+	if last {
+		return append(aad, 1)
+	}
+	return append(aad, 0)
+}
+
+type sealReader struct {
+	src     io.Reader
+	dataKey []byte
+	index   uint64
+	buf     []byte
+	done    bool
+}
+
+func (s *sealReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if err := s.sealChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+func (s *sealReader) sealChunk() error {
+	chunk := make([]byte, objectChunkSize)
+	n, err := io.ReadFull(s.src, chunk)
+	last := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !last {
+		return err
+	}
+	sealed, err := utils.SealAESGCM(s.dataKey, chunk[:n], chunkAAD(s.index, last))
+	if err != nil {
+		return err
+	}
+	s.index++
+	s.done = last
+
+	frame := make([]byte, 0, 5+len(sealed))
+	if last {
+		frame = append(frame, 1)
+	} else {
+		frame = append(frame, 0)
+	}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(sealed)))
+	s.buf = append(frame, sealed...)
+	return nil
+}
+
+type openReader struct {
+	src     io.Reader
+	dataKey []byte
+	index   uint64
+	buf     []byte
+	done    bool
+}
+
+func (o *openReader) Read(p []byte) (int, error) {
+	for len(o.buf) == 0 {
+		if o.done {
+			return 0, io.EOF
+		}
+		if err := o.openChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, o.buf)
+	o.buf = o.buf[n:]
+	return n, nil
+}
+
+func (o *openReader) openChunk() error {
+	frame := make([]byte, 5)
+	if _, err := io.ReadFull(o.src, frame); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errTruncatedObject
+		}
+		return err
+	}
+	last := frame[0] == 1
+	size := binary.BigEndian.Uint32(frame[1:])
+	if size > objectChunkSize+objectChunkOverhead-5 {
+		return errors.New("invalid encrypted object chunk")
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(o.src, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errTruncatedObject
+		}
+		return err
+	}
+	plaintext, err := utils.OpenAESGCM(o.dataKey, sealed, chunkAAD(o.index, last))
+	if err != nil {
+		return fmt.Errorf("decrypt object: %w", err)
+	}
+	o.index++
+	o.done = last
+	o.buf = plaintext
+	return nil
+}
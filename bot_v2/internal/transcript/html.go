@@ -0,0 +1,381 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Options customises RenderHTML.
+type Options struct {
+	// AttachmentURL returns the link for an archived attachment. When nil or
+	// when it returns "", the original Discord URL is used.
+	AttachmentURL func(a Attachment) string
+}
+
+type renderer struct {
+	users    map[string]string
+	mentions Mentions
+	opts     Options
+}
+
+func newRenderer(p Payload, opts Options) *renderer {
+	r := &renderer{users: make(map[string]string), opts: opts}
+	if p.Mentions != nil {
+		r.mentions = *p.Mentions
+		for id, user := range p.Mentions.Users {
+			r.users[id] = user.Username
+		}
+	}
+	if p.UserID != "" && p.Username != "" {
+		r.users[p.UserID] = p.Username
+	}
+	for _, participant := range p.Metadata.Participants {
+		r.users[participant.ID] = participant.Username
+	}
+	for _, m := range p.Messages {
+		if m.Author.ID != "" && m.Author.Username != "" {
+			r.users[m.Author.ID] = m.Author.Username
+		}
+	}
+	return r
+}
+
+func (r *renderer) userName(id string) string {
+	if name, ok := r.users[id]; ok {
+		return name
+	}
+	return "unknown-user"
+}
+
+func (r *renderer) roleName(id string) string {
+	if role, ok := r.mentions.Roles[id]; ok {
+		return role.Name
+	}
+	return "deleted-role"
+}
+
+func (r *renderer) roleColor(id string) int {
+	return r.mentions.Roles[id].Color
+}
+
+func (r *renderer) channelName(id string) string {
+	if channel, ok := r.mentions.Channels[id]; ok {
+		return channel.Name
+	}
+	return "deleted-channel"
+}
+
+func (r *renderer) attachmentURL(a Attachment) string {
+	if a.StorageKey != nil && r.opts.AttachmentURL != nil {
+		if url := r.opts.AttachmentURL(a); url != "" {
+			return url
+		}
+	}
+	return a.URL
+}
+
+// RenderHTML renders p as a single self-contained HTML page.
+func RenderHTML(p Payload, opts Options) ([]byte, error) {
+	r := newRenderer(p, opts)
+	tmpl, err := template.New("transcript").Funcs(template.FuncMap{
+		"markdown":  r.markdown,
+		"avatar":    avatarURL,
+		"fileURL":   r.attachmentURL,
+		"time":      formatRFC3339,
+		"color":     embedColor,
+		"size":      formatSize,
+		"isImage":   isImage,
+		"duration":  formatDuration,
+		"deref":     deref,
+		"grouped":   groupMessages,
+		"closeNote": closeNote,
+		"quotaNote": quotaNote,
+		"sticker":   stickerURL,
+		"excerpt":   excerpt,
+	}).Parse(htmlTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, p); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageGroup is a run of messages by the same author, shown under one header.
+type messageGroup struct {
+	Author   Author
+	Messages []Message
+	// System is set for a group holding a single Discord system message.
+	System string
+}
+
+// groupMessages folds consecutive messages by one author into a group, like
+// the Discord client. Replies and system messages always start a new group.
+func groupMessages(messages []Message) []messageGroup {
+	groups := make([]messageGroup, 0)
+	var lastAt time.Time
+	for _, m := range messages {
+		at, _ := time.Parse(time.RFC3339, m.Timestamp)
+		system := SystemText(m)
+		n := len(groups)
+		if n > 0 && system == "" && m.Reference == nil && groups[n-1].System == "" &&
+			groups[n-1].Author.ID == m.Author.ID && at.Sub(lastAt) < 7*time.Minute {
+			groups[n-1].Messages = append(groups[n-1].Messages, m)
+		} else {
+			groups = append(groups, messageGroup{Author: m.Author, Messages: []Message{m}, System: system})
+		}
+		lastAt = at
+	}
+	return groups
+}
+
+// stickerURL links the sticker image on the Discord CDN. Lottie stickers
+// have no image and return "".
+func stickerURL(st Sticker) string {
+	switch st.Format {
+	case "png", "apng":
+		return fmt.Sprintf("https://media.discordapp.net/stickers/%s.png?size=160", st.ID)
+	case "gif":
+		return fmt.Sprintf("https://media.discordapp.net/stickers/%s.gif?size=160", st.ID)
+	}
+	return ""
+}
+
+// excerpt shortens text for reply previews.
+func excerpt(text *string) string {
+	value := strings.Join(strings.Fields(deref(text)), " ")
+	if utf8.RuneCountInString(value) <= 100 {
+		return value
+	}
+	runes := []rune(value)
+	return string(runes[:100]) + "…"
+}
+
+func avatarURL(a Author) string {
+	if a.Avatar != nil && *a.Avatar != "" {
+		ext := "png"
+		if strings.HasPrefix(*a.Avatar, "a_") {
+			ext = "gif"
+		}
+		return fmt.Sprintf("https://cdn.discordapp.com/avatars/%s/%s.%s?size=64", a.ID, *a.Avatar, ext)
+	}
+	id, _ := strconv.ParseUint(a.ID, 10, 64)
+	return fmt.Sprintf("https://cdn.discordapp.com/embed/avatars/%d.png", (id>>22)%6)
+}
+
+func formatRFC3339(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format("Jan 2, 2006 15:04 UTC")
+}
+
+func formatUnix(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format("Jan 2, 2006 15:04 UTC")
+}
+
+func embedColor(color *int) string {
+	if color == nil {
+		return "#1e1f22"
+	}
+	return fmt.Sprintf("#%06x", *color)
+}
+
+func formatSize(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+func isImage(a Attachment) bool {
+	if a.ContentType != nil {
+		return strings.HasPrefix(*a.ContentType, "image/")
+	}
+	lower := strings.ToLower(a.Filename)
+	for _, ext := range []string{".png", ".jpg", ".jpeg", ".gif", ".webp"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDuration(seconds int) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func closeNote(reason string) string {
+	switch reason {
+	case "", "closed":
+		return ""
+	case "deleted_outside_bot":
+		return "The ticket channel was deleted outside the bot; messages could not be saved."
+	default:
+		return reason
+	}
+}
+
+// quotaNote explains a transcript saved while the guild was over its storage
+// quota.
+func quotaNote(meta Metadata) string {
+	if !meta.MetadataOnly {
+		return ""
+	}
+	return "The server was over its transcript storage quota; only the ticket's details were kept, not its messages."
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Transcript {{.TicketID}}</title>
+<style>
+*{box-sizing:border-box}
+body{margin:0;background:#313338;color:#dbdee1;font:16px/1.375 "gg sans","Noto Sans","Helvetica Neue",Helvetica,Arial,sans-serif}
+a{color:#00a8fc;text-decoration:none}a:hover{text-decoration:underline}
+header{background:#2b2d31;padding:20px 24px;border-bottom:1px solid #1e1f22}
+header h1{margin:0 0 8px;font-size:20px;color:#f2f3f5}
+.meta{display:flex;flex-wrap:wrap;gap:6px 24px;font-size:14px;color:#b5bac1}
+.meta b{color:#f2f3f5;font-weight:600}
+.note{margin-top:12px;padding:8px 12px;border-left:4px solid #f0b232;background:#2e2c28;font-size:14px}
+section.extra{padding:12px 24px;border-bottom:1px solid #1e1f22;font-size:14px}
+section.extra h2{margin:0 0 6px;font-size:12px;text-transform:uppercase;color:#b5bac1}
+section.extra table{border-collapse:collapse}
+section.extra td{padding:2px 16px 2px 0}
+main{padding:16px 0}
+.group{display:flex;padding:4px 24px 4px 16px;margin-top:16px}
+.group:hover{background:#2e3035}
+.avatar{width:40px;height:40px;border-radius:50%;margin-right:16px;flex-shrink:0}
+.body{min-width:0;flex:1}
+.header{display:flex;align-items:baseline;gap:8px}
+.name{font-weight:500;color:#f2f3f5}
+.bot{background:#5865f2;color:#fff;font-size:10px;padding:1px 4px;border-radius:3px;font-weight:600}
+.ts{font-size:12px;color:#949ba4}
+.msg{white-space:normal;word-wrap:break-word;margin-top:2px}
+.staffnote{border-left:4px solid #f0b232;background:#2e2c28;padding-left:8px}
+.notelabel{font-size:11px;font-weight:600;text-transform:uppercase;color:#f0b232}
+.deleted{border-left:4px solid #da373c;background:#2e2628;padding-left:8px}
+.deletedlabel{font-size:11px;font-weight:600;text-transform:uppercase;color:#da373c}
+.revisions{font-size:12px;color:#949ba4;margin-top:2px}
+.revisions summary{cursor:pointer}
+.revision{border-left:2px solid #3f4147;padding-left:6px;margin-top:4px}
+.edited{font-size:10px;color:#949ba4;margin-left:4px}
+.sysmsg{padding:4px 24px 4px 72px;margin-top:8px;color:#949ba4;font-size:14px}
+.sysmsg .name{color:#f2f3f5}
+.replyto{font-size:13px;color:#949ba4;white-space:nowrap;overflow:hidden;text-overflow:ellipsis}
+.replyto a{color:#b5bac1}
+.sticker{margin-top:4px;display:flex;flex-direction:column;gap:2px;font-size:12px;color:#949ba4}
+.sticker img{width:160px;height:160px}
+.components{display:flex;flex-wrap:wrap;gap:8px;margin-top:6px}
+.button,.select{display:inline-block;padding:4px 14px;border-radius:3px;font-size:14px;font-weight:500;color:#fff;background:#4e5058;text-decoration:none}
+.button.style1{background:#5865f2}.button.style3{background:#248046}.button.style4{background:#da373c}
+.select{background:#1e1f22;border:1px solid #3f4147;min-width:200px}
+.disabled{opacity:.5}
+.mention{background:rgba(88,101,242,.3);color:#c9cdfb;border-radius:3px;padding:0 2px;font-weight:500}
+.spoiler{background:#1e1f22;color:transparent;border-radius:3px}.spoiler:hover{color:inherit}
+code.inline{background:#2b2d31;padding:0 4px;border-radius:3px;font-size:85%;font-family:Consolas,"Courier New",monospace}
+pre.codeblock{background:#2b2d31;border:1px solid #1e1f22;border-radius:4px;padding:8px;overflow-x:auto;font-family:Consolas,"Courier New",monospace;font-size:14px;margin:4px 0}
+blockquote{margin:0;padding:0 0 0 12px;border-left:4px solid #4e5058}
+.emoji{width:22px;height:22px;vertical-align:bottom}
+.embed{display:flex;max-width:520px;margin-top:4px;background:#2b2d31;border-radius:4px;overflow:hidden}
+.embed .bar{width:4px;flex-shrink:0}
+.embed .content{padding:8px 16px 16px 12px;min-width:0;flex:1}
+.embed .eauthor{display:flex;align-items:center;gap:8px;font-size:14px;font-weight:600;margin-top:8px}
+.embed .eauthor img{width:24px;height:24px;border-radius:50%}
+.embed .title{font-weight:600;color:#f2f3f5;margin-top:8px}
+.embed .desc{font-size:14px;margin-top:8px}
+.embed .fields{display:flex;flex-wrap:wrap;gap:8px;margin-top:8px}
+.embed .field{flex:1 1 100%;font-size:14px}.embed .field.inline{flex:1 1 30%}
+.embed .field .fname{font-weight:600;color:#f2f3f5}
+.embed .thumb{max-width:80px;max-height:80px;border-radius:4px;margin:16px 16px 0 0}
+.embed .image{max-width:100%;border-radius:4px;margin-top:16px}
+.embed .footer{display:flex;align-items:center;gap:8px;font-size:12px;color:#b5bac1;margin-top:8px}
+.embed .footer img{width:20px;height:20px;border-radius:50%}
+.attachment{margin-top:4px}
+.attachment img{max-width:400px;max-height:300px;border-radius:4px}
+.file{display:inline-flex;align-items:center;gap:8px;background:#2b2d31;border:1px solid #1e1f22;border-radius:4px;padding:10px}
+.file .fsize{font-size:12px;color:#949ba4}
+.reactions{display:flex;gap:4px;margin-top:4px}
+.reaction{background:#2b2d31;border:1px solid #3f4147;border-radius:8px;padding:0 6px;font-size:14px}
+footer{padding:16px 24px;font-size:12px;color:#949ba4;border-top:1px solid #1e1f22}
+</style>
+</head>
+<body>
+<header>
+<h1>Ticket transcript · {{.TicketID}}</h1>
+<div class="meta">
+<span>Opened by <b>{{.Username}}</b> ({{.UserID}})</span>
+<span>Opened <b>{{time .Metadata.TicketOpenedAt}}</b></span>
+<span>Closed <b>{{time .Metadata.TicketClosedAt}}</b></span>
+<span>Closed by <b>{{if .Metadata.ClosedBy.Username}}{{.Metadata.ClosedBy.Username}}{{else}}unknown{{end}}</b></span>
+<span><b>{{.Metadata.TotalMessages}}</b> messages · <b>{{.Metadata.TotalAttachments}}</b> attachments · <b>{{.Metadata.TotalEmbeds}}</b> embeds</span>
+</div>
+{{with closeNote .Metadata.CloseReason}}<div class="note">{{.}}</div>{{end}}
+{{with quotaNote .Metadata}}<div class="note">{{.}}</div>{{end}}
+</header>
+{{if .Metadata.Participants}}<section class="extra"><h2>Participants</h2><table>{{range .Metadata.Participants}}<tr><td>{{.Username}}</td><td>{{.MessageCount}} messages</td></tr>{{end}}</table></section>{{end}}
+{{if .Metadata.Transfers}}<section class="extra"><h2>Escalations</h2><table>{{range .Metadata.Transfers}}<tr><td>{{time .MovedAt}}</td><td>{{if .FromPanelTitle}}{{.FromPanelTitle}}{{else}}unknown panel{{end}} → {{.ToPanelTitle}}</td><td>by {{.MovedBy.Username}}</td></tr>{{end}}</table></section>{{end}}
+{{if .Metadata.VoiceActivity}}<section class="extra"><h2>Voice activity</h2><table>{{range .Metadata.VoiceActivity}}<tr><td>{{.Username}}</td><td>{{time .JoinedAt}} → {{time (deref .LeftAt)}}</td><td>{{duration .Duration}}</td></tr>{{end}}</table></section>{{end}}
+<main>
+{{range grouped .Messages}}
+{{if .System}}{{$system := .System}}
+{{with index .Messages 0}}<div class="sysmsg" id="m-{{.ID}}"><span class="name">{{.Author.Username}}</span> {{$system}} <span class="ts">{{time .Timestamp}}</span></div>{{end}}
+{{else}}
+<div class="group">
+<img class="avatar" src="{{avatar .Author}}" alt="">
+<div class="body">
+{{with (index .Messages 0).Reference}}<div class="replyto">↪ {{with .Author}}<span class="name">{{.Username}}</span>{{end}} {{if .Content}}<a href="#m-{{.MessageID}}">{{excerpt .Content}}</a>{{else}}<a href="#m-{{.MessageID}}"><i>original message unavailable</i></a>{{end}}</div>{{end}}
+<div class="header"><span class="name" title="{{.Author.ID}}">{{.Author.Username}}</span>{{if .Author.Bot}}<span class="bot">BOT</span>{{end}}<span class="ts">{{time (index .Messages 0).Timestamp}}</span></div>
+{{range .Messages}}
+<div class="msg{{if eq .Type "note"}} staffnote{{end}}{{if .Deleted}} deleted{{end}}" id="m-{{.ID}}">{{if eq .Type "note"}}<span class="notelabel">Staff note</span>{{end}}{{if .Deleted}}<span class="deletedlabel">Deleted {{time (deref .DeletedAt)}}</span>{{end}}
+{{with .Content}}{{markdown .}}{{end}}{{if .Edited}}<span class="edited" title="{{deref .EditedTimestamp}}">(edited)</span>{{end}}
+{{if .Revisions}}<details class="revisions"><summary>{{len .Revisions}} earlier version{{if gt (len .Revisions) 1}}s{{end}}</summary>{{range .Revisions}}<div class="revision"><span class="ts">{{time .Timestamp}}</span>{{with .Content}}{{markdown .}}{{else}}<i>empty</i>{{end}}</div>{{end}}</details>{{end}}
+{{range .Embeds}}
+<div class="embed"><div class="bar" style="background:{{color .Color}}"></div><div class="content">
+{{with .Author}}<div class="eauthor">{{with .IconURL}}<img src="{{.}}" alt="">{{end}}{{if .URL}}<a href="{{deref .URL}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</div>{{end}}
+{{with .Title}}<div class="title">{{markdown .}}</div>{{end}}
+{{with .Description}}<div class="desc">{{markdown .}}</div>{{end}}
+{{if .Fields}}<div class="fields">{{range .Fields}}<div class="field{{if .Inline}} inline{{end}}"><div class="fname">{{markdown .Name}}</div><div>{{markdown .Value}}</div></div>{{end}}</div>{{end}}
+{{with .Image}}<img class="image" src="{{.URL}}" alt="">{{end}}
+{{with .Footer}}<div class="footer">{{with .IconURL}}<img src="{{.}}" alt="">{{end}}{{.Text}}</div>{{end}}
+</div>{{with .Thumbnail}}<img class="thumb" src="{{.URL}}" alt="">{{end}}</div>
+{{end}}
+{{range .Attachments}}
+<div class="attachment">{{if isImage .}}<a href="{{fileURL .}}" target="_blank" rel="noopener noreferrer"><img src="{{fileURL .}}" alt="{{.Filename}}"></a>{{else}}<div class="file"><a href="{{fileURL .}}" target="_blank" rel="noopener noreferrer">{{.Filename}}</a><span class="fsize">{{size .Size}}</span></div>{{end}}</div>
+{{end}}
+{{range .Stickers}}<div class="sticker">{{with sticker .}}<img src="{{.}}" alt="">{{end}}<span>{{.Name}}</span></div>{{end}}
+{{range .Components}}<div class="components">{{range .}}{{if eq .Type "button"}}{{if .URL}}<a class="button link{{if .Disabled}} disabled{{end}}" href="{{deref .URL}}" target="_blank" rel="noopener noreferrer">{{.Emoji}} {{.Label}}</a>{{else}}<span class="button style{{.Style}}{{if .Disabled}} disabled{{end}}">{{.Emoji}} {{.Label}}</span>{{end}}{{else}}<span class="select{{if .Disabled}} disabled{{end}}" title="{{range $i, $o := .Options}}{{if $i}}, {{end}}{{$o}}{{end}}">{{if .Placeholder}}{{.Placeholder}}{{else}}Select…{{end}} ▾</span>{{end}}{{end}}</div>{{end}}
+{{if .Reactions}}<div class="reactions">{{range .Reactions}}<span class="reaction">{{.Emoji}} {{.Count}}</span>{{end}}</div>{{end}}
+</div>
+{{end}}
+</div>
+</div>
+{{end}}
+{{end}}
+</main>
+<footer>Sushi Tickets • Transcript System</footer>
+</body>
+</html>
+`
@@ -0,0 +1,21 @@
+package transcript
+
+import "strings"
+
+// ObjectPrefix returns the prefix shared by every object stored alongside
+// the transcript blob at transcriptKey.
+func ObjectPrefix(transcriptKey string) string {
+	return strings.TrimSuffix(transcriptKey, ".json") + "/"
+}
+
+// AttachmentKey returns where attachmentID of the transcript stored at
+// transcriptKey is archived. Attachments live next to the transcript blob.
+func AttachmentKey(transcriptKey, attachmentID string) string {
+	return ObjectPrefix(transcriptKey) + "attachments/" + attachmentID
+}
+
+// OriginalKey returns where the unredacted copy of the transcript stored at
+// transcriptKey is kept, when the guild asks for one.
+func OriginalKey(transcriptKey string) string {
+	return ObjectPrefix(transcriptKey) + "original.json"
+}
@@ -0,0 +1,117 @@
+package transcript
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	codeBlockRe   = regexp.MustCompile("(?s)```(?:([a-zA-Z0-9_+-]+)\n)?(.*?)```")
+	inlineCodeRe  = regexp.MustCompile("`([^`\n]+)`")
+	maskedLinkRe  = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^\s)]+)\)`)
+	bareLinkRe    = regexp.MustCompile(`(^|[\s(])(https?://[^\s<]+)`)
+	boldRe        = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	underlineRe   = regexp.MustCompile(`__(.+?)__`)
+	italicStarRe  = regexp.MustCompile(`\*([^*\n]+)\*`)
+	italicUnderRe = regexp.MustCompile(`(^|\W)_([^_\n]+)_(\W|$)`)
+	strikeRe      = regexp.MustCompile(`~~(.+?)~~`)
+	spoilerRe     = regexp.MustCompile(`\|\|(.+?)\|\|`)
+	headingRe     = regexp.MustCompile(`(?m)^(#{1,3}) (.+)$`)
+	quoteRe       = regexp.MustCompile(`(?m)^&gt; (.*)$`)
+	userMentionRe = regexp.MustCompile(`&lt;@!?(\d+)&gt;`)
+	roleMentionRe = regexp.MustCompile(`&lt;@&amp;(\d+)&gt;`)
+	chanMentionRe = regexp.MustCompile(`&lt;#(\d+)&gt;`)
+	customEmojiRe = regexp.MustCompile(`&lt;(a?):(\w+):(\d+)&gt;`)
+	timestampRe   = regexp.MustCompile(`&lt;t:(-?\d+)(?::[tTdDfFR])?&gt;`)
+	everyoneRe    = regexp.MustCompile(`@(everyone|here)\b`)
+)
+
+// markdown renders Discord flavoured markdown as HTML. Text is escaped
+// first, so every tag in the output comes from this function.
+func (r *renderer) markdown(text string) template.HTML {
+	if text == "" {
+		return ""
+	}
+
+	// Code is cut out before any other rule runs and restored at the end.
+	protected := make([]string, 0)
+	protect := func(fragment string) string {
+		protected = append(protected, fragment)
+		return fmt.Sprintf("\x00%d\x00", len(protected)-1)
+	}
+
+	out := codeBlockRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := codeBlockRe.FindStringSubmatch(m)
+		return protect(fmt.Sprintf(`<pre class="codeblock"><code>%s</code></pre>`, html.EscapeString(strings.Trim(parts[2], "\n"))))
+	})
+	out = inlineCodeRe.ReplaceAllStringFunc(out, func(m string) string {
+		parts := inlineCodeRe.FindStringSubmatch(m)
+		return protect(fmt.Sprintf(`<code class="inline">%s</code>`, html.EscapeString(parts[1])))
+	})
+
+	out = html.EscapeString(out)
+
+	out = maskedLinkRe.ReplaceAllStringFunc(out, func(m string) string {
+		parts := maskedLinkRe.FindStringSubmatch(m)
+		return protect(fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener noreferrer">%s</a>`, parts[2], parts[1]))
+	})
+	out = bareLinkRe.ReplaceAllStringFunc(out, func(m string) string {
+		parts := bareLinkRe.FindStringSubmatch(m)
+		return parts[1] + protect(fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener noreferrer">%s</a>`, parts[2], parts[2]))
+	})
+
+	out = customEmojiRe.ReplaceAllStringFunc(out, func(m string) string {
+		parts := customEmojiRe.FindStringSubmatch(m)
+		ext := "png"
+		if parts[1] == "a" {
+			ext = "gif"
+		}
+		return protect(fmt.Sprintf(`<img class="emoji" src="https://cdn.discordapp.com/emojis/%s.%s" alt=":%s:" title=":%s:">`, parts[3], ext, parts[2], parts[2]))
+	})
+	out = roleMentionRe.ReplaceAllStringFunc(out, func(m string) string {
+		id := roleMentionRe.FindStringSubmatch(m)[1]
+		if color := r.roleColor(id); color != 0 {
+			return protect(fmt.Sprintf(`<span class="mention" title="%s" style="color:#%06x;background:#%06x33">@%s</span>`, id, color, color, html.EscapeString(r.roleName(id))))
+		}
+		return protect(fmt.Sprintf(`<span class="mention" title="%s">@%s</span>`, id, html.EscapeString(r.roleName(id))))
+	})
+	out = userMentionRe.ReplaceAllStringFunc(out, func(m string) string {
+		id := userMentionRe.FindStringSubmatch(m)[1]
+		return protect(fmt.Sprintf(`<span class="mention" title="%s">@%s</span>`, id, html.EscapeString(r.userName(id))))
+	})
+	out = chanMentionRe.ReplaceAllStringFunc(out, func(m string) string {
+		id := chanMentionRe.FindStringSubmatch(m)[1]
+		return protect(fmt.Sprintf(`<span class="mention" title="%s">#%s</span>`, id, html.EscapeString(r.channelName(id))))
+	})
+	out = timestampRe.ReplaceAllStringFunc(out, func(m string) string {
+		unix, err := strconv.ParseInt(timestampRe.FindStringSubmatch(m)[1], 10, 64)
+		if err != nil {
+			return m
+		}
+		return protect(fmt.Sprintf(`<span class="timestamp">%s</span>`, formatUnix(unix)))
+	})
+	out = everyoneRe.ReplaceAllString(out, `<span class="mention">@$1</span>`)
+
+	out = boldRe.ReplaceAllString(out, "<strong>$1</strong>")
+	out = underlineRe.ReplaceAllString(out, "<u>$1</u>")
+	out = italicStarRe.ReplaceAllString(out, "<em>$1</em>")
+	out = italicUnderRe.ReplaceAllString(out, "$1<em>$2</em>$3")
+	out = strikeRe.ReplaceAllString(out, "<s>$1</s>")
+	out = spoilerRe.ReplaceAllString(out, `<span class="spoiler">$1</span>`)
+	out = headingRe.ReplaceAllStringFunc(out, func(m string) string {
+		parts := headingRe.FindStringSubmatch(m)
+		return fmt.Sprintf("<h%d>%s</h%d>", len(parts[1])+2, parts[2], len(parts[1])+2)
+	})
+	out = quoteRe.ReplaceAllString(out, `<blockquote>$1</blockquote>`)
+	out = strings.ReplaceAll(out, "\n", "<br>")
+	out = strings.ReplaceAll(out, "</blockquote><br>", "</blockquote>")
+
+	for idx := len(protected) - 1; idx >= 0; idx-- {
+		out = strings.ReplaceAll(out, fmt.Sprintf("\x00%d\x00", idx), protected[idx])
+	}
+	return template.HTML(out)
+}
@@ -0,0 +1,50 @@
+package transcript
+
+import "regexp"
+
+var mentionTokenRe = regexp.MustCompile(`<(@!?|@&|#)(\d+)>`)
+
+// MentionIDs lists the user, role and channel ids mentioned anywhere in
+// messages, each once.
+func MentionIDs(messages []Message) (users, roles, channels []string) {
+	seen := make(map[string]bool)
+	scan := func(text string) {
+		for _, match := range mentionTokenRe.FindAllStringSubmatch(text, -1) {
+			kind, id := match[1], match[2]
+			if kind == "@!" {
+				kind = "@"
+			}
+			if seen[kind+id] {
+				continue
+			}
+			seen[kind+id] = true
+			switch kind {
+			case "@":
+				users = append(users, id)
+			case "@&":
+				roles = append(roles, id)
+			case "#":
+				channels = append(channels, id)
+			}
+		}
+	}
+
+	for _, m := range messages {
+		scan(deref(m.Content))
+		for _, e := range m.Embeds {
+			scan(deref(e.Title))
+			scan(deref(e.Description))
+			for _, f := range e.Fields {
+				scan(f.Name)
+				scan(f.Value)
+			}
+		}
+		for _, r := range m.Revisions {
+			scan(r.Content)
+		}
+		if m.Reference != nil {
+			scan(deref(m.Reference.Content))
+		}
+	}
+	return users, roles, channels
+}
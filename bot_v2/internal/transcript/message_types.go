@@ -0,0 +1,65 @@
+package transcript
+
+import "strings"
+
+// Message types written since schema version 2. Version 1 payloads only use
+// MessageTypeDefault, MessageTypeNote and the voice types.
+const (
+	MessageTypeDefault               = "message"
+	MessageTypeReply                 = "reply"
+	MessageTypeCommand               = "command"
+	MessageTypeContextCommand        = "context_command"
+	MessageTypePin                   = "pin"
+	MessageTypeMemberJoin            = "member_join"
+	MessageTypeBoost                 = "boost"
+	MessageTypeFollowAdd             = "follow_add"
+	MessageTypeThreadCreated         = "thread_created"
+	MessageTypeThreadStarter         = "thread_starter"
+	MessageTypeChannelNameChange     = "channel_name_change"
+	MessageTypeChannelIconChange     = "channel_icon_change"
+	MessageTypeRecipientAdd          = "recipient_add"
+	MessageTypeRecipientRemove       = "recipient_remove"
+	MessageTypeCall                  = "call"
+	MessageTypeDiscoveryDisqualified = "discovery_disqualified"
+	MessageTypeDiscoveryRequalified  = "discovery_requalified"
+)
+
+// SystemText returns the line shown in place of a Discord system message,
+// or "" when m is a regular message.
+func SystemText(m Message) string {
+	content := strings.TrimSpace(deref(m.Content))
+	switch m.Type {
+	case MessageTypePin:
+		return "pinned a message to this channel."
+	case MessageTypeMemberJoin:
+		return "joined the server."
+	case MessageTypeBoost:
+		return "boosted the server!"
+	case MessageTypeFollowAdd:
+		return "added " + orDefault(content, "a channel") + " to this channel."
+	case MessageTypeThreadCreated:
+		return "started a thread: " + orDefault(content, "untitled")
+	case MessageTypeChannelNameChange:
+		return "changed the channel name: " + orDefault(content, "unknown")
+	case MessageTypeChannelIconChange:
+		return "changed the channel icon."
+	case MessageTypeRecipientAdd:
+		return "added someone to the conversation."
+	case MessageTypeRecipientRemove:
+		return "removed someone from the conversation."
+	case MessageTypeCall:
+		return "started a call."
+	case MessageTypeDiscoveryDisqualified:
+		return "This server has been removed from Server Discovery."
+	case MessageTypeDiscoveryRequalified:
+		return "This server is eligible for Server Discovery again."
+	}
+	return ""
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
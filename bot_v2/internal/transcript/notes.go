@@ -0,0 +1,70 @@
+package transcript
+
+import "strings"
+
+// Staff notes are ticket messages starting with NotePrefix. They stay in the
+// stored transcript for staff and are removed from the opener's copy.
+const (
+	NotePrefix      = "!note"
+	MessageTypeNote = "note"
+)
+
+// IsNote reports whether content is a staff note.
+func IsNote(content string) bool {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(content), NotePrefix)
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\n')
+}
+
+// ForOpener returns a copy of p without staff notes, with the totals and
+// participant counts adjusted to match. Replies keep their reference to a
+// note but lose the quoted note text.
+func ForOpener(p Payload) Payload {
+	removed := make(map[string]int)
+	notes := make(map[string]bool)
+	messages := make([]Message, 0, len(p.Messages))
+	out := p
+	for _, m := range p.Messages {
+		if m.Type != MessageTypeNote {
+			messages = append(messages, m)
+			continue
+		}
+		notes[m.ID] = true
+		removed[m.Author.ID]++
+		out.Metadata.TotalAttachments -= len(m.Attachments)
+		out.Metadata.TotalEmbeds -= len(m.Embeds)
+	}
+	if len(removed) == 0 {
+		return p
+	}
+	for i, m := range messages {
+		if m.Reference != nil && notes[m.Reference.MessageID] {
+			ref := *m.Reference
+			ref.Content = nil
+			messages[i].Reference = &ref
+		}
+	}
+	out.Messages = messages
+	out.Metadata.TotalMessages = len(messages)
+
+	participants := make([]Participant, 0, len(p.Metadata.Participants))
+	for _, participant := range p.Metadata.Participants {
+		participant.MessageCount -= removed[participant.ID]
+		if participant.MessageCount > 0 {
+			participants = append(participants, participant)
+		}
+	}
+	out.Metadata.Participants = participants
+	return out
+}
+
+// HasAttachment reports whether attachmentID belongs to a message in p.
+func (p Payload) HasAttachment(attachmentID string) bool {
+	for _, m := range p.Messages {
+		for _, a := range m.Attachments {
+			if a.ID == attachmentID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package transcript
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Built-in redaction detectors.
+const (
+	DetectorEmail = "email"
+	DetectorPhone = "phone"
+	DetectorCard  = "card"
+	DetectorToken = "token"
+)
+
+// Detectors lists the built-in detectors in the order they run. Cards run
+// before phone numbers, which would otherwise match their digit groups.
+var Detectors = []string{DetectorEmail, DetectorToken, DetectorCard, DetectorPhone}
+
+// Limits on custom rules, checked by NewRedactor.
+const (
+	MaxRedactionRules   = 20
+	MaxRedactionPattern = 256
+	maxRuleName         = 32
+)
+
+// RedactionRule is a guild's own pattern. Name labels the replacement and
+// the count in the metadata.
+type RedactionRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+var (
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	tokenRe = regexp.MustCompile(strings.Join([]string{
+		`[A-Za-z0-9_-]{23,28}\.[A-Za-z0-9_-]{6,7}\.[A-Za-z0-9_-]{27,}`, // Discord bot token
+		`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,         // JWT
+		`\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{16,}`,                // Stripe
+		`\bgh[pousr]_[A-Za-z0-9]{36,}`,                                 // GitHub
+		`\bAKIA[0-9A-Z]{16}\b`,                                         // AWS access key
+		`\bxox[abpors]-[A-Za-z0-9-]{10,}`,                              // Slack
+	}, "|"))
+	cardRe  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	phoneRe = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?\(?\d{2,4}\)?[ .-]\d{3,4}[ .-]\d{3,4}\b|\+\d{8,15}\b`)
+	// discordTokenRe matches mentions, emoji and timestamps, whose ids must
+	// survive redaction.
+	discordTokenRe = regexp.MustCompile(`<(?:@[!&]?|#|a?:\w+:|t:)-?\d+(?::[tTdDfFR])?>`)
+)
+
+type redactionRule struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// Redactor replaces personal data in transcript text with "[redacted:name]".
+type Redactor struct {
+	rules []redactionRule
+}
+
+// NewRedactor builds a redactor from the enabled built-in detectors and the
+// guild's custom rules. It returns nil when nothing is enabled.
+func NewRedactor(detectors []string, custom []RedactionRule) (*Redactor, error) {
+	enabled := make(map[string]bool, len(detectors))
+	for _, d := range detectors {
+		enabled[d] = true
+	}
+
+	r := &Redactor{}
+	for _, d := range Detectors {
+		if !enabled[d] {
+			continue
+		}
+		switch d {
+		case DetectorEmail:
+			r.rules = append(r.rules, redactionRule{name: d, re: emailRe})
+		case DetectorToken:
+			r.rules = append(r.rules, redactionRule{name: d, re: tokenRe})
+		case DetectorCard:
+			r.rules = append(r.rules, redactionRule{name: d, re: cardRe, valid: isCardNumber})
+		case DetectorPhone:
+			r.rules = append(r.rules, redactionRule{name: d, re: phoneRe})
+		}
+	}
+	for _, d := range detectors {
+		if !slices.Contains(Detectors, d) {
+			return nil, fmt.Errorf("unknown detector %q", d)
+		}
+	}
+
+	if len(custom) > MaxRedactionRules {
+		return nil, fmt.Errorf("at most %d custom rules are allowed", MaxRedactionRules)
+	}
+	for _, rule := range custom {
+		if err := ValidateRedactionRule(rule); err != nil {
+			return nil, err
+		}
+		r.rules = append(r.rules, redactionRule{name: rule.Name, re: regexp.MustCompile(rule.Pattern)})
+	}
+
+	if len(r.rules) == 0 {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// ValidateRedactionRule checks a custom rule's name and pattern.
+func ValidateRedactionRule(rule RedactionRule) error {
+	name := strings.TrimSpace(rule.Name)
+	if name == "" || len(name) > maxRuleName || name != rule.Name {
+		return fmt.Errorf("rule names must be 1-%d characters without surrounding spaces", maxRuleName)
+	}
+	if rule.Pattern == "" || len(rule.Pattern) > MaxRedactionPattern {
+		return fmt.Errorf("rule %q: pattern must be 1-%d characters", rule.Name, MaxRedactionPattern)
+	}
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return fmt.Errorf("rule %q: %v", rule.Name, err)
+	}
+	if re.MatchString("") {
+		return fmt.Errorf("rule %q: pattern must not match empty text", rule.Name)
+	}
+	return nil
+}
+
+// Redact rewrites every message's text in place and returns how many
+// matches each rule replaced. A nil Redactor does nothing.
+func (r *Redactor) Redact(messages []Message) map[string]int {
+	if r == nil {
+		return nil
+	}
+	counts := make(map[string]int)
+	text := func(s *string) {
+		if s != nil {
+			*s = r.redact(*s, counts)
+		}
+	}
+	for i := range messages {
+		m := &messages[i]
+		m.Content = r.redactPtr(m.Content, counts)
+		for j := range m.Revisions {
+			text(&m.Revisions[j].Content)
+		}
+		for j := range m.Embeds {
+			e := &m.Embeds[j]
+			e.Title = r.redactPtr(e.Title, counts)
+			e.Description = r.redactPtr(e.Description, counts)
+			for k := range e.Fields {
+				text(&e.Fields[k].Name)
+				text(&e.Fields[k].Value)
+			}
+			if e.Footer != nil {
+				footer := *e.Footer
+				text(&footer.Text)
+				e.Footer = &footer
+			}
+		}
+		if m.Reference != nil && m.Reference.Content != nil {
+			ref := *m.Reference
+			ref.Content = r.redactPtr(ref.Content, counts)
+			m.Reference = &ref
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	return counts
+}
+
+func (r *Redactor) redactPtr(s *string, counts map[string]int) *string {
+	if s == nil {
+		return nil
+	}
+	redacted := r.redact(*s, counts)
+	return &redacted
+}
+
+// redact applies every rule to the text between Discord tokens.
+func (r *Redactor) redact(s string, counts map[string]int) string {
+	if s == "" {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range discordTokenRe.FindAllStringIndex(s, -1) {
+		b.WriteString(r.redactPlain(s[last:loc[0]], counts))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(r.redactPlain(s[last:], counts))
+	return b.String()
+}
+
+func (r *Redactor) redactPlain(s string, counts map[string]int) string {
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			counts[rule.name]++
+			return "[redacted:" + rule.name + "]"
+		})
+	}
+	return s
+}
+
+// isCardNumber accepts 13-19 digits passing the Luhn check. Runs of more
+// than 16 digits without separators are left alone, since Discord ids look
+// the same.
+func isCardNumber(match string) bool {
+	digits := make([]int, 0, len(match))
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits = append(digits, int(c-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	if len(digits) > 16 && len(digits) == len(match) {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
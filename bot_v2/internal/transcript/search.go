@@ -0,0 +1,54 @@
+package transcript
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSearchText keeps the indexed text well under Postgres' 1MB tsvector limit.
+const maxSearchText = 512 << 10
+
+// SearchText flattens the searchable text of p: message content, embed text
+// and attachment file names, one message per line.
+func SearchText(p Payload) string {
+	var b strings.Builder
+	for _, m := range p.Messages {
+		parts := make([]string, 0, 4)
+		if m.Author.Username != "" {
+			parts = append(parts, m.Author.Username+":")
+		}
+		if m.Content != nil && *m.Content != "" {
+			parts = append(parts, *m.Content)
+		}
+		for _, e := range m.Embeds {
+			parts = append(parts, deref(e.Title), deref(e.Description))
+			for _, f := range e.Fields {
+				parts = append(parts, f.Name, f.Value)
+			}
+			if e.Footer != nil {
+				parts = append(parts, e.Footer.Text)
+			}
+		}
+		for _, a := range m.Attachments {
+			parts = append(parts, a.Filename)
+		}
+
+		line := strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+		if line == "" {
+			continue
+		}
+		if b.Len()+len(line)+1 > maxSearchText {
+			rest := maxSearchText - b.Len()
+			for rest > 0 && rest < len(line) && !utf8.RuneStart(line[rest]) {
+				rest--
+			}
+			if rest > 0 {
+				b.WriteString(line[:rest])
+			}
+			break
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,335 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Text formats a transcript can be rendered in besides JSON and HTML.
+const (
+	FormatText     = "txt"
+	FormatMarkdown = "md"
+)
+
+// QnATitle is the title of the embed the bot posts with the opener's answers
+// to the panel questions.
+const QnATitle = "📋 Ticket Responses"
+
+var (
+	rawUserMentionRe = regexp.MustCompile(`<@!?(\d+)>`)
+	rawRoleMentionRe = regexp.MustCompile(`<@&(\d+)>`)
+	rawChanMentionRe = regexp.MustCompile(`<#(\d+)>`)
+	rawCustomEmojiRe = regexp.MustCompile(`<a?:(\w+):\d+>`)
+	rawTimestampRe   = regexp.MustCompile(`<t:(-?\d+)(?::[tTdDfFR])?>`)
+)
+
+// IsTextFormat reports whether format is FormatText or FormatMarkdown.
+func IsTextFormat(format string) bool {
+	return format == FormatText || format == FormatMarkdown
+}
+
+// TextContentType returns the Content-Type for a text format.
+func TextContentType(format string) string {
+	if format == FormatMarkdown {
+		return "text/markdown; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// RenderText renders p as plain text in format, readable in any editor.
+// Embeds are flattened to indented lines and attachments become links.
+func RenderText(p Payload, format string, opts Options) []byte {
+	t := &textRenderer{renderer: newRenderer(p, opts), md: format == FormatMarkdown}
+	t.header(p)
+	t.messages(p.Messages)
+	return t.buf.Bytes()
+}
+
+type textRenderer struct {
+	*renderer
+	buf bytes.Buffer
+	md  bool
+}
+
+func (t *textRenderer) line(format string, args ...any) {
+	fmt.Fprintf(&t.buf, format, args...)
+	t.buf.WriteByte('\n')
+}
+
+// heading writes a section title: a markdown heading, or an underlined line.
+func (t *textRenderer) heading(level int, title string) {
+	if t.md {
+		t.line("%s %s", strings.Repeat("#", level), title)
+	} else {
+		t.line("%s", title)
+		t.line("%s", strings.Repeat("=-"[level-1:level], len([]rune(title))))
+	}
+	t.line("")
+}
+
+// item writes a list entry, indented by depth.
+func (t *textRenderer) item(depth int, format string, args ...any) {
+	bullet := ""
+	if t.md {
+		bullet = "- "
+	}
+	t.line("%s%s%s", strings.Repeat("  ", depth), bullet, fmt.Sprintf(format, args...))
+}
+
+func (t *textRenderer) header(p Payload) {
+	meta := p.Metadata
+	t.heading(1, "Ticket transcript · "+p.TicketID)
+
+	closedBy := meta.ClosedBy.Username
+	if closedBy == "" {
+		closedBy = "unknown"
+	}
+	t.item(0, "Opened by: %s (%s)", p.Username, p.UserID)
+	t.item(0, "Opened: %s", formatRFC3339(meta.TicketOpenedAt))
+	t.item(0, "Closed: %s by %s", formatRFC3339(meta.TicketClosedAt), closedBy)
+	t.item(0, "%d messages · %d attachments · %d embeds", meta.TotalMessages, meta.TotalAttachments, meta.TotalEmbeds)
+	if note := closeNote(meta.CloseReason); note != "" {
+		t.item(0, "Note: %s", note)
+	}
+	if note := quotaNote(meta); note != "" {
+		t.item(0, "Note: %s", note)
+	}
+	t.line("")
+
+	if answers := qnaAnswers(p.Messages); len(answers) > 0 {
+		t.heading(2, "Ticket responses")
+		for _, f := range answers {
+			t.item(0, "%s", t.inline(f.Name))
+			t.indented(1, f.Value)
+		}
+		t.line("")
+	}
+
+	if len(meta.Participants) > 0 {
+		t.heading(2, "Participants")
+		for _, participant := range meta.Participants {
+			t.item(0, "%s: %d messages", participant.Username, participant.MessageCount)
+		}
+		t.line("")
+	}
+
+	if len(meta.Transfers) > 0 {
+		t.heading(2, "Escalations")
+		for _, transfer := range meta.Transfers {
+			from := transfer.FromPanelTitle
+			if from == "" {
+				from = "unknown panel"
+			}
+			t.item(0, "%s: %s → %s by %s", formatRFC3339(transfer.MovedAt), from, transfer.ToPanelTitle, transfer.MovedBy.Username)
+		}
+		t.line("")
+	}
+
+	if len(meta.VoiceActivity) > 0 {
+		t.heading(2, "Voice activity")
+		for _, voice := range meta.VoiceActivity {
+			t.item(0, "%s: %s → %s (%s)", voice.Username, formatRFC3339(voice.JoinedAt), formatRFC3339(deref(voice.LeftAt)), formatDuration(voice.Duration))
+		}
+		t.line("")
+	}
+}
+
+func (t *textRenderer) messages(messages []Message) {
+	t.heading(2, "Messages")
+	for _, m := range messages {
+		t.message(m)
+	}
+}
+
+func (t *textRenderer) message(m Message) {
+	author := m.Author.Username
+	if m.Author.Bot {
+		author += " [BOT]"
+	}
+	stamp := formatRFC3339(m.Timestamp)
+
+	if system := SystemText(m); system != "" {
+		t.line("[%s] %s %s", stamp, author, system)
+		t.line("")
+		return
+	}
+
+	var flags []string
+	if m.Type == MessageTypeNote {
+		flags = append(flags, "staff note")
+	}
+	if m.Edited {
+		flags = append(flags, "edited")
+	}
+	if m.Deleted {
+		flags = append(flags, "deleted "+formatRFC3339(deref(m.DeletedAt)))
+	}
+	suffix := ""
+	if len(flags) > 0 {
+		suffix = " (" + strings.Join(flags, ", ") + ")"
+	}
+	if t.md {
+		t.line("**%s** · %s%s", t.escape(author), stamp, suffix)
+	} else {
+		t.line("[%s] %s%s", stamp, author, suffix)
+	}
+
+	if ref := m.Reference; ref != nil {
+		to := "a message"
+		if ref.Author != nil {
+			to = ref.Author.Username
+		}
+		preview := excerpt(ref.Content)
+		if preview == "" {
+			preview = "original message unavailable"
+		}
+		t.item(1, "↪ replying to %s: %s", to, t.inline(preview))
+	}
+
+	if content := deref(m.Content); content != "" {
+		t.indented(1, content)
+	}
+	for _, rev := range m.Revisions {
+		t.item(1, "earlier version, %s:", formatRFC3339(rev.Timestamp))
+		t.indented(2, orDefault(rev.Content, "(empty)"))
+	}
+	for _, e := range m.Embeds {
+		t.embed(e)
+	}
+	for _, a := range m.Attachments {
+		if t.md {
+			t.item(1, "Attachment: %s (%s)", t.link(a.Filename, t.attachmentURL(a)), formatSize(a.Size))
+		} else {
+			t.item(1, "Attachment: %s (%s) %s", a.Filename, formatSize(a.Size), t.attachmentURL(a))
+		}
+	}
+	for _, st := range m.Stickers {
+		t.item(1, "Sticker: %s", st.Name)
+	}
+	for _, row := range m.Components {
+		labels := make([]string, 0, len(row))
+		for _, c := range row {
+			labels = append(labels, "["+strings.TrimSpace(c.Emoji+" "+orDefault(c.Label, c.Placeholder))+"]")
+		}
+		t.item(1, "Components: %s", strings.Join(labels, " "))
+	}
+	if len(m.Reactions) > 0 {
+		reactions := make([]string, 0, len(m.Reactions))
+		for _, r := range m.Reactions {
+			reactions = append(reactions, fmt.Sprintf("%s %d", r.Emoji, r.Count))
+		}
+		t.item(1, "Reactions: %s", strings.Join(reactions, ", "))
+	}
+	t.line("")
+}
+
+// embed flattens an embed into indented lines: author, title, description,
+// fields, image links and footer.
+func (t *textRenderer) embed(e Embed) {
+	title := t.inline(deref(e.Title))
+	if e.URL != nil && title != "" {
+		title = t.link(title, *e.URL)
+	}
+	t.item(1, "Embed: %s", orDefault(title, "(untitled)"))
+	if e.Author != nil && e.Author.Name != "" {
+		t.item(2, "Author: %s", e.Author.Name)
+	}
+	if description := deref(e.Description); description != "" {
+		t.indented(2, description)
+	}
+	for _, f := range e.Fields {
+		t.item(2, "%s: %s", t.inline(f.Name), t.inline(f.Value))
+	}
+	if e.Image != nil && e.Image.URL != "" {
+		t.item(2, "Image: %s", e.Image.URL)
+	}
+	if e.Thumbnail != nil && e.Thumbnail.URL != "" {
+		t.item(2, "Thumbnail: %s", e.Thumbnail.URL)
+	}
+	if e.Footer != nil && e.Footer.Text != "" {
+		t.item(2, "Footer: %s", e.Footer.Text)
+	}
+}
+
+// indented writes multi-line text at depth: as a blockquote in markdown, so
+// the message's own formatting still renders, and as indented lines otherwise.
+func (t *textRenderer) indented(depth int, text string) {
+	text = t.plain(text)
+	prefix := strings.Repeat("  ", depth)
+	if t.md {
+		prefix = strings.Repeat("  ", depth-1) + "> "
+	}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		t.line("%s%s", prefix, line)
+	}
+	if t.md {
+		t.line("")
+	}
+}
+
+// inline collapses text onto one line for list entries.
+func (t *textRenderer) inline(text string) string {
+	return strings.Join(strings.Fields(t.plain(text)), " ")
+}
+
+// plain replaces Discord's mention, emoji and timestamp tokens with the
+// names and dates they stand for.
+func (t *textRenderer) plain(text string) string {
+	text = rawUserMentionRe.ReplaceAllStringFunc(text, func(m string) string {
+		return "@" + t.userName(rawUserMentionRe.FindStringSubmatch(m)[1])
+	})
+	text = rawRoleMentionRe.ReplaceAllStringFunc(text, func(m string) string {
+		return "@" + t.roleName(rawRoleMentionRe.FindStringSubmatch(m)[1])
+	})
+	text = rawChanMentionRe.ReplaceAllStringFunc(text, func(m string) string {
+		return "#" + t.channelName(rawChanMentionRe.FindStringSubmatch(m)[1])
+	})
+	text = rawCustomEmojiRe.ReplaceAllString(text, ":$1:")
+	return rawTimestampRe.ReplaceAllStringFunc(text, func(m string) string {
+		unix, err := strconv.ParseInt(rawTimestampRe.FindStringSubmatch(m)[1], 10, 64)
+		if err != nil {
+			return m
+		}
+		return formatUnix(unix)
+	})
+}
+
+func (t *textRenderer) link(label, url string) string {
+	if t.md {
+		return fmt.Sprintf("[%s](%s)", t.escape(label), url)
+	}
+	return label
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "~", `\~`, "|", `\|`)
+
+func (t *textRenderer) escape(text string) string {
+	if !t.md {
+		return text
+	}
+	return markdownEscaper.Replace(text)
+}
+
+// qnaAnswers returns the question and answer fields of the bot's responses
+// embed, if the ticket has one.
+func qnaAnswers(messages []Message) []EmbedField {
+	for _, m := range messages {
+		if !m.Author.Bot {
+			continue
+		}
+		for _, e := range m.Embeds {
+			if deref(e.Title) == QnATitle {
+				return e.Fields
+			}
+		}
+	}
+	return nil
+}
+
+// TextFilename names a rendered transcript file, e.g. "transcript-12.md".
+func TextFilename(name, format string) string {
+	return fmt.Sprintf("transcript-%s.%s", name, format)
+}
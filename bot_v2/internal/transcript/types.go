@@ -0,0 +1,209 @@
+// Package transcript defines the stored ticket transcript payload and renders
+// it for readers outside the dashboard.
+package transcript
+
+// SchemaVersion is the payload layout written by this build. Payloads
+// without a schemaVersion field predate it and are version 1: they have no
+// resolved mentions, reply references, stickers or components, and every
+// regular message has type "message".
+const SchemaVersion = 2
+
+// SchemaVersionV1 marks payloads in the version 1 layout, which includes
+// transcripts imported from the v1 MongoDB store.
+const SchemaVersionV1 = 1
+
+// Payload is the JSON document stored for every closed ticket.
+type Payload struct {
+	SchemaVersion int       `json:"schemaVersion,omitempty"`
+	TicketID      string    `json:"ticketId"`
+	Username      string    `json:"username"`
+	UserID        string    `json:"userId"`
+	Messages      []Message `json:"messages"`
+	Mentions      *Mentions `json:"mentions,omitempty"`
+	Metadata      Metadata  `json:"metadata"`
+}
+
+// Mentions maps the ids in <@id>, <@&id> and <#id> to the names they had
+// when the ticket closed, so they stay readable after the user, role or
+// channel is gone.
+type Mentions struct {
+	Users    map[string]MentionedUser    `json:"users,omitempty"`
+	Roles    map[string]MentionedRole    `json:"roles,omitempty"`
+	Channels map[string]MentionedChannel `json:"channels,omitempty"`
+}
+
+type MentionedUser struct {
+	Username string `json:"username"`
+}
+
+type MentionedRole struct {
+	Name  string `json:"name"`
+	Color int    `json:"color"`
+}
+
+type MentionedChannel struct {
+	Name string `json:"name"`
+}
+
+type Author struct {
+	ID            string  `json:"id"`
+	Username      string  `json:"username"`
+	Discriminator string  `json:"discriminator"`
+	Avatar        *string `json:"avatar"`
+	Bot           bool    `json:"bot"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type Image struct {
+	URL string `json:"url"`
+}
+
+type Footer struct {
+	Text    string  `json:"text"`
+	IconURL *string `json:"iconUrl"`
+}
+
+type EmbedAuthor struct {
+	Name    string  `json:"name"`
+	URL     *string `json:"url"`
+	IconURL *string `json:"iconUrl"`
+}
+
+type Embed struct {
+	Title       *string      `json:"title"`
+	Description *string      `json:"description"`
+	URL         *string      `json:"url"`
+	Color       *int         `json:"color"`
+	Fields      []EmbedField `json:"fields"`
+	Image       *Image       `json:"image"`
+	Thumbnail   *Image       `json:"thumbnail"`
+	Footer      *Footer      `json:"footer"`
+	Author      *EmbedAuthor `json:"author"`
+}
+
+type Attachment struct {
+	ID          string  `json:"id"`
+	Filename    string  `json:"filename"`
+	URL         string  `json:"url"`
+	ProxyURL    string  `json:"proxyUrl"`
+	Size        int     `json:"size"`
+	ContentType *string `json:"contentType"`
+	Width       *int    `json:"width"`
+	Height      *int    `json:"height"`
+	StorageKey  *string `json:"storageKey,omitempty"`
+}
+
+// Reference is the message a reply points at. Author and Content are
+// copied when Discord sent the referenced message along.
+type Reference struct {
+	MessageID string  `json:"messageId"`
+	ChannelID string  `json:"channelId"`
+	Author    *Author `json:"author,omitempty"`
+	Content   *string `json:"content,omitempty"`
+}
+
+type Sticker struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Format string `json:"format"`
+}
+
+// Component is a button or select menu. Components are stored as action
+// rows, one slice per row.
+type Component struct {
+	Type        string   `json:"type"`
+	Label       string   `json:"label,omitempty"`
+	Style       int      `json:"style,omitempty"`
+	URL         *string  `json:"url,omitempty"`
+	Emoji       string   `json:"emoji,omitempty"`
+	Disabled    bool     `json:"disabled,omitempty"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type Reaction struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+type Message struct {
+	ID              string        `json:"id"`
+	Type            string        `json:"type"`
+	Author          Author        `json:"author"`
+	Content         *string       `json:"content"`
+	Timestamp       string        `json:"timestamp"`
+	Embeds          []Embed       `json:"embeds,omitempty"`
+	Attachments     []Attachment  `json:"attachments,omitempty"`
+	Edited          bool          `json:"edited"`
+	EditedTimestamp *string       `json:"editedTimestamp"`
+	Reactions       []Reaction    `json:"reactions,omitempty"`
+	Reference       *Reference    `json:"reference,omitempty"`
+	Stickers        []Sticker     `json:"stickers,omitempty"`
+	Components      [][]Component `json:"components,omitempty"`
+	// Revisions holds earlier versions of an edited message, oldest first.
+	Revisions []Revision `json:"revisions,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	DeletedAt *string    `json:"deletedAt,omitempty"`
+}
+
+// Revision is one earlier version of a message and when it was written.
+type Revision struct {
+	Content   string `json:"content"`
+	Timestamp string `json:"timestamp"`
+}
+
+type ClosedBy struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type Participant struct {
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	MessageCount int    `json:"messageCount"`
+}
+
+type VoiceActivity struct {
+	UserID   string  `json:"userId"`
+	Username string  `json:"username"`
+	JoinedAt string  `json:"joinedAt"`
+	LeftAt   *string `json:"leftAt"`
+	Duration int     `json:"duration"`
+}
+
+type Transfer struct {
+	FromPanelID    *int     `json:"fromPanelId"`
+	FromPanelTitle string   `json:"fromPanelTitle"`
+	ToPanelID      int      `json:"toPanelId"`
+	ToPanelTitle   string   `json:"toPanelTitle"`
+	MovedBy        ClosedBy `json:"movedBy"`
+	MovedAt        string   `json:"movedAt"`
+}
+
+type Metadata struct {
+	TicketOpenedAt   string          `json:"ticketOpenedAt"`
+	TicketClosedAt   string          `json:"ticketClosedAt"`
+	ClosedBy         ClosedBy        `json:"closedBy"`
+	CloseReason      string          `json:"closeReason,omitempty"`
+	TotalMessages    int             `json:"totalMessages"`
+	TotalAttachments int             `json:"totalAttachments"`
+	TotalEmbeds      int             `json:"totalEmbeds"`
+	Participants     []Participant   `json:"participants"`
+	VoiceActivity    []VoiceActivity `json:"voiceActivity,omitempty"`
+	Transfers        []Transfer      `json:"transfers,omitempty"`
+	// TicketNumber is only set on transcripts imported from v1.
+	TicketNumber int `json:"ticketNumber,omitempty"`
+	// Redactions counts the matches each redaction rule replaced.
+	Redactions map[string]int `json:"redactions,omitempty"`
+	// OriginalKept is set when the unredacted transcript was also stored,
+	// encrypted, at OriginalKey.
+	OriginalKept bool `json:"originalKept,omitempty"`
+	// MetadataOnly is set when the guild was over its storage quota, so the
+	// messages were left out and only the metadata was kept.
+	MetadataOnly bool `json:"metadataOnly,omitempty"`
+}
@@ -17,22 +17,10 @@ func EncryptTokenV1(key []byte, token string) (string, error) {
 	if len(key) != 32 {
 		return "", errors.New("invalid access token key length")
 	}
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-	gcm, err := cipher.NewGCM(block)
+	payload, err := SealAESGCM(key, []byte(token), nil)
 	if err != nil {
 		return "", err
 	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nil, nonce, []byte(token), nil)
-	payload := append(nonce, ciphertext...)
 	return TokenEncPrefix + base64.StdEncoding.EncodeToString(payload), nil
 }
 
@@ -49,24 +37,48 @@ func DecryptTokenV1(key []byte, stored string) (string, error) {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	plaintext, err := OpenAESGCM(key, payload, nil)
 	if err != nil {
 		return "", err
 	}
-	gcm, err := cipher.NewGCM(block)
+	return string(plaintext), nil
+}
+
+// SealAESGCM encrypts plaintext with a 32-byte key and returns the random
+// nonce followed by the ciphertext. aad is authenticated but not encrypted.
+func SealAESGCM(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// OpenAESGCM reverses SealAESGCM.
+func OpenAESGCM(key, payload, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	nonceSize := gcm.NonceSize()
 	if len(payload) < nonceSize {
-		return "", errors.New("invalid token payload")
+		return nil, errors.New("invalid payload")
 	}
 
 	nonce := payload[:nonceSize]
 	ciphertext := payload[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return string(plaintext), nil
+	return cipher.NewGCM(block)
 }
@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
+	"github.com/Sush1sui/FNS_BOT/internal/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	dispatchInterval  = 15 * time.Second
+	dispatchBatchSize = 20
+	// deliveryLease keeps a claimed delivery from being claimed again while
+	// it is in flight. It must outlast deliveryTimeout.
+	deliveryLease   = 2 * time.Minute
+	deliveryTimeout = 10 * time.Second
+
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = 6 * time.Hour
+	// maxAttempts spans about eight and a half hours of retries before a
+	// delivery is marked failed.
+	maxAttempts = 10
+
+	// deliveryLogRetention is how long finished deliveries stay in the log.
+	deliveryLogRetention = 30 * 24 * time.Hour
+	pruneInterval        = time.Hour
+)
+
+var (
+	dispatchOnce sync.Once
+
+	httpClient = &http.Client{
+		Timeout: deliveryTimeout,
+		// A redirect could point anywhere, so it counts as a failed attempt.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 5 * time.Second,
+				Control: refusePrivateAddress,
+			}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+)
+
+// StartDispatcher sends due deliveries right away and then every
+// dispatchInterval, and prunes the delivery log. Safe to call more than once.
+func StartDispatcher() {
+	if queries == nil {
+		return
+	}
+	dispatchOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(dispatchInterval)
+			defer ticker.Stop()
+
+			dispatchDue()
+			pruneDeliveryLog()
+			lastPrune := time.Now()
+			for range ticker.C {
+				dispatchDue()
+				if time.Since(lastPrune) >= pruneInterval {
+					pruneDeliveryLog()
+					lastPrune = time.Now()
+				}
+			}
+		}()
+	})
+}
+
+func dispatchDue() {
+	now := time.Now()
+	due, err := queries.ClaimDueWebhookDeliveries(context.Background(), now.Unix(), now.Add(deliveryLease).Unix(), dispatchBatchSize)
+	if err != nil {
+		log.Printf("claim webhook deliveries failed: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range due {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deliver(context.Background(), d, true)
+		}()
+	}
+	wg.Wait()
+}
+
+func pruneDeliveryLog() {
+	cutoff := time.Now().Add(-deliveryLogRetention).Unix()
+	if _, err := queries.DeleteWebhookDeliveriesBefore(context.Background(), cutoff); err != nil {
+		log.Printf("prune webhook delivery log failed: %v", err)
+	}
+}
+
+// deliver sends d once and records the outcome. A failed attempt is retried
+// with backoff when retry is set, until maxAttempts is reached.
+func deliver(ctx context.Context, d db.DueWebhookDelivery, retry bool) {
+	if !d.Enabled {
+		if err := queries.FailWebhookDelivery(ctx, d.ID, pgtype.Int4{}, "subscription disabled"); err != nil {
+			log.Printf("update webhook delivery %d failed: %v", d.ID, err)
+		}
+		return
+	}
+
+	status, err := send(ctx, d)
+	if err == nil {
+		metrics.Add("webhook_deliveries_total", "Webhook deliveries that got a 2xx response.", 1)
+		if err := queries.MarkWebhookDelivered(ctx, d.ID, int32(status), time.Now().Unix()); err != nil {
+			log.Printf("update webhook delivery %d failed: %v", d.ID, err)
+		}
+		return
+	}
+
+	metrics.Add("webhook_delivery_failures_total", "Webhook delivery attempts that failed.", 1)
+	responseStatus := pgtype.Int4{Int32: int32(status), Valid: status != 0}
+	lastError := truncate(err.Error(), 500)
+	if !retry || d.Attempts+1 >= maxAttempts {
+		if retry {
+			metrics.Add("webhook_deliveries_abandoned_total", "Webhook deliveries given up on after every retry failed.", 1)
+			log.Printf("webhook delivery %d to subscription %d gave up after %d attempts: %v", d.ID, d.SubscriptionID, d.Attempts+1, err)
+		}
+		if err := queries.FailWebhookDelivery(ctx, d.ID, responseStatus, lastError); err != nil {
+			log.Printf("update webhook delivery %d failed: %v", d.ID, err)
+		}
+		return
+	}
+
+	next := time.Now().Add(retryBackoff(d.Attempts)).Unix()
+	if _, err := queries.RetryWebhookDelivery(ctx, d.ID, next, responseStatus, lastError); err != nil {
+		log.Printf("reschedule webhook delivery %d failed: %v", d.ID, err)
+	}
+}
+
+// send POSTs the signed payload and returns the response status, or 0 when
+// no response came back. Anything but a 2xx is an error.
+func send(ctx context.Context, d db.DueWebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SushiTickets-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Id", d.EventID)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(d.Secret, timestamp, d.Payload))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return resp.StatusCode, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if detail := strings.TrimSpace(string(body)); detail != "" {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, detail)
+	}
+	return resp.StatusCode, fmt.Errorf("HTTP %d", resp.StatusCode)
+}
+
+// retryBackoff doubles the delay after every failed attempt, up to
+// retryMaxDelay.
+func retryBackoff(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for n := int32(0); n < attempts && delay < retryMaxDelay; n++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// SendTest sends a webhook.test event to sub right away, whether or not it
+// is enabled, and returns the logged delivery. Test events are not retried.
+func SendTest(ctx context.Context, sub db.WebhookSubscription) (db.WebhookDelivery, error) {
+	if queries == nil {
+		return db.WebhookDelivery{}, fmt.Errorf("webhooks not configured")
+	}
+	nonce, err := utils.RandomState(12)
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	id := Test + ":" + nonce
+	body, err := json.Marshal(Envelope{
+		ID:        id,
+		Type:      Test,
+		GuildID:   strconv.FormatInt(sub.ServerConfigID, 10),
+		CreatedAt: time.Now().Unix(),
+		Data:      map[string]any{"subscriptionId": sub.ID},
+	})
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+
+	// The lease keeps the dispatcher away while the test is in flight.
+	now := time.Now()
+	d, err := queries.CreateWebhookDelivery(ctx, sub.ID, sub.ServerConfigID, Test, id, body, now.Add(deliveryLease).Unix(), now.Unix())
+	if err != nil {
+		return db.WebhookDelivery{}, err
+	}
+	deliver(ctx, db.DueWebhookDelivery{WebhookDelivery: d, URL: sub.URL, Secret: sub.Secret, Enabled: true}, false)
+	return queries.GetWebhookDelivery(ctx, d.ID)
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// errPrivateAddress stops webhooks from reaching the bot's own network. Guild
+// admins choose the URLs, so anything but a public address is refused.
+var errPrivateAddress = errors.New("webhook address is not public")
+
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ValidateURL checks a subscription URL: it must be https, with a host and
+// without credentials. Hosts that are private IP addresses or localhost are
+// refused here; names that resolve to one are refused when dialing.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("url must be a valid https URL")
+	}
+	if u.User != nil {
+		return errors.New("url must not contain credentials")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("url must point to a public host")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublic(addr) {
+		return errors.New("url must point to a public host")
+	}
+	return nil
+}
+
+// refusePrivateAddress is a net.Dialer Control func that refuses connections
+// to non-public addresses, after DNS resolution.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || !isPublic(addrPort.Addr()) {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,150 @@
+// Package webhook sends ticket lifecycle events to the HTTPS endpoints that
+// guilds subscribe to.
+//
+// Events are queued in the webhook_delivery table, one row per subscription,
+// and sent by the dispatcher with retries and exponential backoff. Each
+// request is a POST of a JSON Envelope with these headers:
+//
+//	X-Webhook-Event:     the event type, e.g. ticket.opened
+//	X-Webhook-Id:        the event ID; retries and redeliveries reuse it
+//	X-Webhook-Delivery:  the delivery ID
+//	X-Webhook-Timestamp: Unix seconds when the request was signed
+//	X-Webhook-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// The HMAC key is the subscription's secret. Receivers should compare the
+// signature in constant time and reject stale timestamps.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Sush1sui/FNS_BOT/internal/db"
+	"github.com/Sush1sui/FNS_BOT/internal/metrics"
+)
+
+const (
+	TicketOpened    = "ticket.opened"
+	TicketClaimed   = "ticket.claimed"
+	TicketClosed    = "ticket.closed"
+	TranscriptSaved = "transcript.saved"
+	PanelSent       = "panel.sent"
+
+	// Test is sent by the test-fire endpoint. It cannot be subscribed to.
+	Test = "webhook.test"
+)
+
+// Events lists the events a subscription can ask for. Nothing emits
+// TicketClaimed yet, since tickets cannot be claimed; subscribing to it is
+// allowed so that endpoints are ready when claiming lands.
+var Events = []string{TicketOpened, TicketClaimed, TicketClosed, TranscriptSaved, PanelSent}
+
+// IsEvent reports whether name is an event that can be subscribed to.
+func IsEvent(name string) bool {
+	for _, event := range Events {
+		if event == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Envelope is the JSON body of every webhook request.
+type Envelope struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	GuildID   string `json:"guildId"`
+	CreatedAt int64  `json:"createdAt"`
+	Data      any    `json:"data"`
+}
+
+// TicketData is the data of ticket.opened and ticket.closed events.
+type TicketData struct {
+	ChannelID   string `json:"channelId"`
+	UserID      string `json:"userId"`
+	PanelID     int32  `json:"panelId,omitempty"`
+	OpenedAt    int64  `json:"openedAt"`
+	ClosedAt    int64  `json:"closedAt,omitempty"`
+	ClosedBy    string `json:"closedBy,omitempty"`
+	CloseReason string `json:"closeReason,omitempty"`
+}
+
+// TranscriptData is the data of transcript.saved events.
+type TranscriptData struct {
+	TranscriptID     int32  `json:"transcriptId"`
+	ChannelID        string `json:"channelId"`
+	UserID           string `json:"userId"`
+	ClosedBy         string `json:"closedBy"`
+	CloseReason      string `json:"closeReason"`
+	TotalMessages    int    `json:"totalMessages"`
+	TotalAttachments int    `json:"totalAttachments"`
+}
+
+// PanelData is the data of panel.sent events. Exactly one of PanelID and
+// MultiPanelID is set.
+type PanelData struct {
+	PanelID      int32  `json:"panelId,omitempty"`
+	MultiPanelID int32  `json:"multiPanelId,omitempty"`
+	ChannelID    string `json:"channelId"`
+	MessageID    string `json:"messageId"`
+}
+
+var queries *db.Queries
+
+func SetQueries(q *db.Queries) {
+	queries = q
+}
+
+// Emit queues event for every subscription of the guild that wants it. key
+// identifies the occurrence within the event type, such as the ticket's
+// channel ID, so emitting the same occurrence twice delivers it once.
+// Failures are logged and never reach the caller; webhooks must not get in
+// the way of the ticket flow.
+func Emit(serverID int64, event, key string, data any) {
+	if queries == nil {
+		return
+	}
+	id := event + ":" + key
+	body, err := json.Marshal(Envelope{
+		ID:        id,
+		Type:      event,
+		GuildID:   strconv.FormatInt(serverID, 10),
+		CreatedAt: time.Now().Unix(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("encode webhook event %s failed: %v", id, err)
+		return
+	}
+	queued, err := queries.EnqueueWebhookEvent(context.Background(), serverID, event, id, body, time.Now().Unix())
+	if err != nil {
+		log.Printf("queue webhook event %s failed: %v", id, err)
+		return
+	}
+	metrics.Add("webhook_deliveries_queued_total", "Webhook deliveries queued for sending.", float64(queued))
+}
+
+// NewSecret returns a random signing secret for a subscription.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign returns the X-Webhook-Signature value for body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
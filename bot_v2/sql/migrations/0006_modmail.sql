@@ -0,0 +1,12 @@
+-- Opt-in DM based tickets ("modmail") and the DM channel to ticket mapping
+ALTER TABLE server_config ADD COLUMN IF NOT EXISTS modmail_enabled BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS modmail_ticket (
+    dm_channel_id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL UNIQUE,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_modmail_ticket_server ON modmail_ticket (server_config_id);
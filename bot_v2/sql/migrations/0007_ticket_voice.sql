@@ -0,0 +1,22 @@
+-- Temporary voice channels linked to tickets and the join/leave log used in transcripts
+CREATE TABLE IF NOT EXISTS ticket_voice_channel (
+    voice_channel_id TEXT PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL UNIQUE,
+    created_at BIGINT NOT NULL,
+    last_active_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ticket_voice_activity (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL,
+    voice_channel_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    username TEXT NOT NULL,
+    joined_at BIGINT NOT NULL,
+    left_at BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_voice_activity_ticket ON ticket_voice_activity (server_config_id, ticket_channel_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_voice_activity_open ON ticket_voice_activity (server_config_id, user_id) WHERE left_at IS NULL;
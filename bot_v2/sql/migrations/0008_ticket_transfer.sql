@@ -0,0 +1,14 @@
+-- History of tickets moved between panels, included in transcripts
+CREATE TABLE IF NOT EXISTS ticket_transfer (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL,
+    from_panel_id INTEGER,
+    from_panel_title TEXT NOT NULL DEFAULT '',
+    to_panel_id INTEGER NOT NULL,
+    to_panel_title TEXT NOT NULL,
+    moved_by TEXT NOT NULL,
+    moved_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_transfer_ticket ON ticket_transfer (server_config_id, ticket_channel_id);
@@ -0,0 +1,2 @@
+-- Why a ticket ended, so transcripts of channels deleted by hand can be told apart
+ALTER TABLE transcript ADD COLUMN IF NOT EXISTS close_reason TEXT NOT NULL DEFAULT 'closed';
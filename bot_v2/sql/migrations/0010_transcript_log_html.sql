@@ -0,0 +1,2 @@
+-- Attach the rendered HTML transcript to the transcript log message
+ALTER TABLE server_config ADD COLUMN IF NOT EXISTS transcript_log_html BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,10 @@
+-- Full-text index of transcript message text for dashboard search
+CREATE TABLE IF NOT EXISTS transcript_search (
+    transcript_id INTEGER PRIMARY KEY REFERENCES transcript(id) ON DELETE CASCADE,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_search_server ON transcript_search (server_config_id);
+CREATE INDEX IF NOT EXISTS idx_transcript_search_document ON transcript_search USING GIN (document);
@@ -0,0 +1,12 @@
+-- Panel and participants on transcripts, plus indexes for filtered keyset listing
+ALTER TABLE transcript ADD COLUMN IF NOT EXISTS panel_id INTEGER;
+ALTER TABLE transcript ADD COLUMN IF NOT EXISTS participant_ids TEXT[] NOT NULL DEFAULT '{}';
+
+DROP INDEX IF EXISTS idx_transcript_server_closed_at;
+CREATE INDEX IF NOT EXISTS idx_transcript_server_closed_at ON transcript (server_config_id, closed_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_opened_at ON transcript (server_config_id, opened_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_messages ON transcript (server_config_id, (COALESCE(total_messages, 0)) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_user ON transcript (server_config_id, user_id, closed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_closed_by ON transcript (server_config_id, closed_by, closed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_panel ON transcript (server_config_id, panel_id, closed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_participants_gin ON transcript USING GIN (participant_ids);
@@ -0,0 +1,25 @@
+-- Per-guild transcript retention, legal holds and an audit log of purged transcripts
+ALTER TABLE server_config ADD COLUMN IF NOT EXISTS transcript_retention_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transcript ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT false;
+
+-- A row here means the transcript's storage objects are being deleted; the
+-- transcript row goes only after they are gone.
+CREATE TABLE IF NOT EXISTS transcript_purge (
+    transcript_id INTEGER PRIMARY KEY REFERENCES transcript(id) ON DELETE CASCADE,
+    started_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS transcript_purge_log (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    transcript_id INTEGER NOT NULL,
+    ticket_id TEXT,
+    user_id TEXT,
+    storage_key TEXT NOT NULL,
+    closed_at BIGINT NOT NULL,
+    objects_deleted INTEGER NOT NULL DEFAULT 0,
+    purged_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_purge_log_server ON transcript_purge_log (server_config_id, purged_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_retention ON transcript (server_config_id, closed_at) WHERE NOT legal_hold;
@@ -0,0 +1,18 @@
+-- Asynchronous bulk transcript exports packaged as ZIP archives
+CREATE TABLE IF NOT EXISTS transcript_export (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    filters JSONB NOT NULL DEFAULT '{}',
+    include_html BOOLEAN NOT NULL DEFAULT false,
+    status TEXT NOT NULL DEFAULT 'pending',
+    total INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    storage_key TEXT,
+    error TEXT,
+    created_at BIGINT NOT NULL,
+    finished_at BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_export_server ON transcript_export (server_config_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_export_unfinished ON transcript_export (status) WHERE status IN ('pending', 'running');
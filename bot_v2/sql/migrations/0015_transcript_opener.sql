@@ -0,0 +1,3 @@
+-- Lets ticket openers list their own transcripts and get a DM link on close
+CREATE INDEX IF NOT EXISTS idx_transcript_user_closed_at ON transcript (user_id, closed_at DESC, id DESC);
+ALTER TABLE server_config ADD COLUMN IF NOT EXISTS transcript_dm_opener BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,25 @@
+-- Expiring, revocable share links for single transcripts, with an audit trail
+CREATE TABLE IF NOT EXISTS transcript_share (
+    id SERIAL PRIMARY KEY,
+    transcript_id INTEGER NOT NULL REFERENCES transcript(id) ON DELETE CASCADE,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    created_by TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    revoked_at BIGINT,
+    revoked_by TEXT,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    last_viewed_at BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS transcript_share_event (
+    id BIGSERIAL PRIMARY KEY,
+    share_id INTEGER NOT NULL REFERENCES transcript_share(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    actor TEXT,
+    ip TEXT,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_share_transcript ON transcript_share (transcript_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_share_event_share ON transcript_share_event (share_id, id DESC);
@@ -0,0 +1,12 @@
+-- Live capture of ticket messages, edits and deletions, merged into the transcript on close
+CREATE TABLE IF NOT EXISTS ticket_message_capture (
+    id BIGSERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    channel_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    data JSONB,
+    occurred_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_message_capture_channel ON ticket_message_capture (server_config_id, channel_id, occurred_at, id);
@@ -0,0 +1,20 @@
+-- Transcripts staged for upload; the ticket channel is deleted only after its transcript is stored
+CREATE TABLE IF NOT EXISTS transcript_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    channel_id TEXT NOT NULL,
+    record JSONB NOT NULL,
+    payload BYTEA,
+    storage_key TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    transcript_id INTEGER,
+    delete_channel BOOLEAN NOT NULL DEFAULT FALSE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    last_error TEXT,
+    alerted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at BIGINT NOT NULL,
+    UNIQUE (server_config_id, channel_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_outbox_due ON transcript_outbox (next_attempt_at);
@@ -0,0 +1,8 @@
+-- Maps v1 MongoDB documents to the v2 rows created for them, so the importer can be re-run
+CREATE TABLE IF NOT EXISTS v1_import (
+    kind TEXT NOT NULL,
+    v1_id TEXT NOT NULL,
+    v2_id BIGINT NOT NULL,
+    imported_at BIGINT NOT NULL,
+    PRIMARY KEY (kind, v1_id)
+);
@@ -0,0 +1,2 @@
+-- Attach a plain-text or Markdown transcript ('txt' or 'md') to the transcript log message
+ALTER TABLE server_config ADD COLUMN IF NOT EXISTS transcript_log_format TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Per-guild PII redaction of transcripts, with an optional encrypted unredacted original
+CREATE TABLE IF NOT EXISTS transcript_redaction (
+    server_config_id BIGINT PRIMARY KEY REFERENCES server_config(id) ON DELETE CASCADE,
+    detectors TEXT[] NOT NULL DEFAULT '{}',
+    rules JSONB NOT NULL DEFAULT '[]',
+    keep_original BOOLEAN NOT NULL DEFAULT false,
+    updated_at BIGINT NOT NULL
+);
+
+ALTER TABLE transcript_outbox ADD COLUMN IF NOT EXISTS original BYTEA;
@@ -0,0 +1,18 @@
+-- Storage used by each transcript and per-guild totals, for usage reports and quotas.
+-- Transcripts stored before this migration are counted by cmd/backfill-usage.
+CREATE TABLE IF NOT EXISTS transcript_usage (
+    transcript_id INTEGER PRIMARY KEY REFERENCES transcript(id) ON DELETE CASCADE,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    transcript_bytes BIGINT NOT NULL,
+    attachment_bytes BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS guild_storage_usage (
+    server_config_id BIGINT PRIMARY KEY REFERENCES server_config(id) ON DELETE CASCADE,
+    transcript_count INTEGER NOT NULL DEFAULT 0,
+    transcript_bytes BIGINT NOT NULL DEFAULT 0,
+    attachment_bytes BIGINT NOT NULL DEFAULT 0,
+    -- NULL uses the bot's default quota; 0 is unlimited.
+    quota_bytes BIGINT,
+    updated_at BIGINT NOT NULL
+);
@@ -0,0 +1,17 @@
+-- Export archives expire, and each server may have one unfinished export at a time
+ALTER TABLE transcript_export ADD COLUMN IF NOT EXISTS expires_at BIGINT;
+
+UPDATE transcript_export SET expires_at = finished_at + 604800
+WHERE status = 'done' AND expires_at IS NULL;
+
+-- Keep the oldest unfinished export of each server so the unique index can be built.
+UPDATE transcript_export SET status = 'failed', error = 'superseded by an earlier export', finished_at = created_at
+WHERE status IN ('pending', 'running')
+  AND id NOT IN (
+    SELECT min(id) FROM transcript_export
+    WHERE status IN ('pending', 'running')
+    GROUP BY server_config_id
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transcript_export_one_unfinished ON transcript_export (server_config_id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_transcript_export_expiry ON transcript_export (expires_at) WHERE status = 'done';
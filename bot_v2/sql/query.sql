@@ -3,11 +3,17 @@ SELECT * FROM server_config
 WHERE id = $1 LIMIT 1;
 
 -- name: UpsertServerConfig :one
+-- The settings added after the dashboard form keep their stored value when
+-- the request leaves them out. Retention has its own query.
 INSERT INTO server_config (
     id, ticket_name_style, ticket_transcript_cid, max_ticket_per_user, 
-    ticket_permissions, max_panel, max_multi_panel
+    ticket_permissions, max_panel, max_multi_panel, modmail_enabled, transcript_log_html, transcript_dm_opener, transcript_log_format
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7,
+    COALESCE(sqlc.narg('modmail_enabled')::boolean, false),
+    COALESCE(sqlc.narg('transcript_log_html')::boolean, false),
+    COALESCE(sqlc.narg('transcript_dm_opener')::boolean, false),
+    COALESCE(sqlc.narg('transcript_log_format')::text, '')
 )
 ON CONFLICT (id) DO UPDATE SET
     ticket_name_style = EXCLUDED.ticket_name_style,
@@ -15,7 +21,11 @@ ON CONFLICT (id) DO UPDATE SET
     max_ticket_per_user = EXCLUDED.max_ticket_per_user,
     ticket_permissions = EXCLUDED.ticket_permissions,
     max_panel = EXCLUDED.max_panel,
-    max_multi_panel = EXCLUDED.max_multi_panel
+    max_multi_panel = EXCLUDED.max_multi_panel,
+    modmail_enabled = COALESCE(sqlc.narg('modmail_enabled')::boolean, server_config.modmail_enabled),
+    transcript_log_html = COALESCE(sqlc.narg('transcript_log_html')::boolean, server_config.transcript_log_html),
+    transcript_dm_opener = COALESCE(sqlc.narg('transcript_dm_opener')::boolean, server_config.transcript_dm_opener),
+    transcript_log_format = COALESCE(sqlc.narg('transcript_log_format')::text, server_config.transcript_log_format)
 RETURNING *;
 
 -- name: GetAuthorizedMembers :many
@@ -73,17 +83,126 @@ ON CONFLICT (server_config_id) DO UPDATE SET
     close_since_last_message_mins = EXCLUDED.close_since_last_message_mins
 RETURNING *;
 
--- name: GetTranscriptsByServer :many
+-- name: ListTranscriptsByClosedAtDesc :many
 SELECT id, server_config_id, ticket_id, username, user_id,
        opened_at, closed_at, closed_by, storage_key,
-       total_messages, total_attachments, total_embeds
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
 FROM transcript
-WHERE server_config_id = $1
-ORDER BY closed_at DESC
-LIMIT $2 OFFSET $3;
-
--- name: CountTranscriptsByServer :one
-SELECT COUNT(*) FROM transcript WHERE server_config_id = $1;
+WHERE server_config_id = @server_config_id
+  AND (sqlc.narg('user_id')::text IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('closed_by')::text IS NULL OR closed_by = sqlc.narg('closed_by'))
+  AND (sqlc.narg('panel_id')::int IS NULL OR panel_id = sqlc.narg('panel_id'))
+  AND (sqlc.narg('closed_from')::bigint IS NULL OR closed_at >= sqlc.narg('closed_from'))
+  AND (sqlc.narg('closed_to')::bigint IS NULL OR closed_at < sqlc.narg('closed_to'))
+  AND (sqlc.narg('min_messages')::int IS NULL OR COALESCE(total_messages, 0) >= sqlc.narg('min_messages'))
+  AND (sqlc.narg('participant')::text IS NULL OR participant_ids @> ARRAY[sqlc.narg('participant')::text])
+  AND (sqlc.narg('close_reason')::text IS NULL OR close_reason = sqlc.narg('close_reason'))
+  AND (sqlc.narg('cursor_id')::int IS NULL OR (closed_at, id) < (sqlc.narg('cursor_value')::bigint, sqlc.narg('cursor_id')::int))
+ORDER BY closed_at DESC, id DESC
+LIMIT @row_limit;
+
+-- name: ListTranscriptsByClosedAtAsc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = @server_config_id
+  AND (sqlc.narg('user_id')::text IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('closed_by')::text IS NULL OR closed_by = sqlc.narg('closed_by'))
+  AND (sqlc.narg('panel_id')::int IS NULL OR panel_id = sqlc.narg('panel_id'))
+  AND (sqlc.narg('closed_from')::bigint IS NULL OR closed_at >= sqlc.narg('closed_from'))
+  AND (sqlc.narg('closed_to')::bigint IS NULL OR closed_at < sqlc.narg('closed_to'))
+  AND (sqlc.narg('min_messages')::int IS NULL OR COALESCE(total_messages, 0) >= sqlc.narg('min_messages'))
+  AND (sqlc.narg('participant')::text IS NULL OR participant_ids @> ARRAY[sqlc.narg('participant')::text])
+  AND (sqlc.narg('close_reason')::text IS NULL OR close_reason = sqlc.narg('close_reason'))
+  AND (sqlc.narg('cursor_id')::int IS NULL OR (closed_at, id) > (sqlc.narg('cursor_value')::bigint, sqlc.narg('cursor_id')::int))
+ORDER BY closed_at ASC, id ASC
+LIMIT @row_limit;
+
+-- name: ListTranscriptsByOpenedAtDesc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = @server_config_id
+  AND (sqlc.narg('user_id')::text IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('closed_by')::text IS NULL OR closed_by = sqlc.narg('closed_by'))
+  AND (sqlc.narg('panel_id')::int IS NULL OR panel_id = sqlc.narg('panel_id'))
+  AND (sqlc.narg('closed_from')::bigint IS NULL OR closed_at >= sqlc.narg('closed_from'))
+  AND (sqlc.narg('closed_to')::bigint IS NULL OR closed_at < sqlc.narg('closed_to'))
+  AND (sqlc.narg('min_messages')::int IS NULL OR COALESCE(total_messages, 0) >= sqlc.narg('min_messages'))
+  AND (sqlc.narg('participant')::text IS NULL OR participant_ids @> ARRAY[sqlc.narg('participant')::text])
+  AND (sqlc.narg('close_reason')::text IS NULL OR close_reason = sqlc.narg('close_reason'))
+  AND (sqlc.narg('cursor_id')::int IS NULL OR (opened_at, id) < (sqlc.narg('cursor_value')::bigint, sqlc.narg('cursor_id')::int))
+ORDER BY opened_at DESC, id DESC
+LIMIT @row_limit;
+
+-- name: ListTranscriptsByOpenedAtAsc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = @server_config_id
+  AND (sqlc.narg('user_id')::text IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('closed_by')::text IS NULL OR closed_by = sqlc.narg('closed_by'))
+  AND (sqlc.narg('panel_id')::int IS NULL OR panel_id = sqlc.narg('panel_id'))
+  AND (sqlc.narg('closed_from')::bigint IS NULL OR closed_at >= sqlc.narg('closed_from'))
+  AND (sqlc.narg('closed_to')::bigint IS NULL OR closed_at < sqlc.narg('closed_to'))
+  AND (sqlc.narg('min_messages')::int IS NULL OR COALESCE(total_messages, 0) >= sqlc.narg('min_messages'))
+  AND (sqlc.narg('participant')::text IS NULL OR participant_ids @> ARRAY[sqlc.narg('participant')::text])
+  AND (sqlc.narg('close_reason')::text IS NULL OR close_reason = sqlc.narg('close_reason'))
+  AND (sqlc.narg('cursor_id')::int IS NULL OR (opened_at, id) > (sqlc.narg('cursor_value')::bigint, sqlc.narg('cursor_id')::int))
+ORDER BY opened_at ASC, id ASC
+LIMIT @row_limit;
+
+-- name: ListTranscriptsByMessagesDesc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = @server_config_id
+  AND (sqlc.narg('user_id')::text IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('closed_by')::text IS NULL OR closed_by = sqlc.narg('closed_by'))
+  AND (sqlc.narg('panel_id')::int IS NULL OR panel_id = sqlc.narg('panel_id'))
+  AND (sqlc.narg('closed_from')::bigint IS NULL OR closed_at >= sqlc.narg('closed_from'))
+  AND (sqlc.narg('closed_to')::bigint IS NULL OR closed_at < sqlc.narg('closed_to'))
+  AND (sqlc.narg('min_messages')::int IS NULL OR COALESCE(total_messages, 0) >= sqlc.narg('min_messages'))
+  AND (sqlc.narg('participant')::text IS NULL OR participant_ids @> ARRAY[sqlc.narg('participant')::text])
+  AND (sqlc.narg('close_reason')::text IS NULL OR close_reason = sqlc.narg('close_reason'))
+  AND (sqlc.narg('cursor_id')::int IS NULL OR (COALESCE(total_messages, 0), id) < (sqlc.narg('cursor_value')::bigint, sqlc.narg('cursor_id')::int))
+ORDER BY COALESCE(total_messages, 0) DESC, id DESC
+LIMIT @row_limit;
+
+-- name: ListTranscriptsByMessagesAsc :many
+SELECT id, server_config_id, ticket_id, username, user_id,
+       opened_at, closed_at, closed_by, storage_key,
+       total_messages, total_attachments, total_embeds, close_reason, panel_id, participant_ids, legal_hold
+FROM transcript
+WHERE server_config_id = @server_config_id
+  AND (sqlc.narg('user_id')::text IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('closed_by')::text IS NULL OR closed_by = sqlc.narg('closed_by'))
+  AND (sqlc.narg('panel_id')::int IS NULL OR panel_id = sqlc.narg('panel_id'))
+  AND (sqlc.narg('closed_from')::bigint IS NULL OR closed_at >= sqlc.narg('closed_from'))
+  AND (sqlc.narg('closed_to')::bigint IS NULL OR closed_at < sqlc.narg('closed_to'))
+  AND (sqlc.narg('min_messages')::int IS NULL OR COALESCE(total_messages, 0) >= sqlc.narg('min_messages'))
+  AND (sqlc.narg('participant')::text IS NULL OR participant_ids @> ARRAY[sqlc.narg('participant')::text])
+  AND (sqlc.narg('close_reason')::text IS NULL OR close_reason = sqlc.narg('close_reason'))
+  AND (sqlc.narg('cursor_id')::int IS NULL OR (COALESCE(total_messages, 0), id) > (sqlc.narg('cursor_value')::bigint, sqlc.narg('cursor_id')::int))
+ORDER BY COALESCE(total_messages, 0) ASC, id ASC
+LIMIT @row_limit;
+
+-- name: CountTranscriptsFiltered :one
+SELECT COUNT(*)
+FROM transcript
+WHERE server_config_id = @server_config_id
+  AND (sqlc.narg('user_id')::text IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('closed_by')::text IS NULL OR closed_by = sqlc.narg('closed_by'))
+  AND (sqlc.narg('panel_id')::int IS NULL OR panel_id = sqlc.narg('panel_id'))
+  AND (sqlc.narg('closed_from')::bigint IS NULL OR closed_at >= sqlc.narg('closed_from'))
+  AND (sqlc.narg('closed_to')::bigint IS NULL OR closed_at < sqlc.narg('closed_to'))
+  AND (sqlc.narg('min_messages')::int IS NULL OR COALESCE(total_messages, 0) >= sqlc.narg('min_messages'))
+  AND (sqlc.narg('participant')::text IS NULL OR participant_ids @> ARRAY[sqlc.narg('participant')::text])
+  AND (sqlc.narg('close_reason')::text IS NULL OR close_reason = sqlc.narg('close_reason'));
 
 -- name: GetTranscriptByID :one
 SELECT * FROM transcript WHERE id = $1 AND server_config_id = $2;
@@ -92,7 +211,8 @@ SELECT * FROM transcript WHERE id = $1 AND server_config_id = $2;
 INSERT INTO transcript (
     server_config_id, ticket_id, username, user_id,
     opened_at, closed_at, closed_by, storage_key,
-    total_messages, total_attachments, total_embeds
+    total_messages, total_attachments, total_embeds, close_reason,
+    panel_id, participant_ids
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 ) RETURNING *;
@@ -7,7 +7,12 @@ CREATE TABLE server_config (
     max_ticket_per_user INTEGER NOT NULL DEFAULT 2,
     ticket_permissions JSONB,
     max_panel INTEGER DEFAULT 3,
-    max_multi_panel INTEGER DEFAULT 3
+    max_multi_panel INTEGER DEFAULT 3,
+    modmail_enabled BOOLEAN NOT NULL DEFAULT false,
+    transcript_log_html BOOLEAN NOT NULL DEFAULT false,
+    transcript_retention_days INTEGER NOT NULL DEFAULT 0,
+    transcript_dm_opener BOOLEAN NOT NULL DEFAULT false,
+    transcript_log_format TEXT NOT NULL DEFAULT ''
 );
 
 CREATE TABLE auto_close_config (
@@ -81,7 +86,11 @@ CREATE TABLE transcript (
     storage_key TEXT NOT NULL,
     total_messages INTEGER DEFAULT 0,
     total_attachments INTEGER DEFAULT 0,
-    total_embeds INTEGER DEFAULT 0
+    total_embeds INTEGER DEFAULT 0,
+    close_reason TEXT NOT NULL DEFAULT 'closed',
+    panel_id INTEGER,
+    participant_ids TEXT[] NOT NULL DEFAULT '{}',
+    legal_hold BOOLEAN NOT NULL DEFAULT false
 );
 
 CREATE TABLE active_ticket (
@@ -97,7 +106,14 @@ CREATE INDEX IF NOT EXISTS idx_questions_config_panel_id ON questions_config (pa
 CREATE INDEX IF NOT EXISTS idx_welcome_msg_panel_id ON welcome_msg_config (panel_config_id);
 CREATE INDEX IF NOT EXISTS idx_multi_panel_config_server_id ON multi_panel_config (server_config_id, id);
 CREATE INDEX IF NOT EXISTS idx_multi_panel_panel_ids_gin ON multi_panel_config USING GIN (panel_config_ids);
-CREATE INDEX IF NOT EXISTS idx_transcript_server_closed_at ON transcript (server_config_id, closed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_closed_at ON transcript (server_config_id, closed_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_opened_at ON transcript (server_config_id, opened_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_messages ON transcript (server_config_id, (COALESCE(total_messages, 0)) DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_user ON transcript (server_config_id, user_id, closed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_closed_by ON transcript (server_config_id, closed_by, closed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_server_panel ON transcript (server_config_id, panel_id, closed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_participants_gin ON transcript USING GIN (participant_ids);
+CREATE INDEX IF NOT EXISTS idx_transcript_user_closed_at ON transcript (user_id, closed_at DESC, id DESC);
 CREATE INDEX IF NOT EXISTS idx_active_ticket_user ON active_ticket (server_config_id, user_id);
 
 CREATE TABLE authorized_members (
@@ -141,3 +157,225 @@ CREATE TABLE idempotency_key (
     expires_at BIGINT NOT NULL,
     PRIMARY KEY (key, user_id)
 );
+
+CREATE TABLE modmail_ticket (
+    dm_channel_id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL UNIQUE,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_modmail_ticket_server ON modmail_ticket (server_config_id);
+
+CREATE TABLE ticket_voice_channel (
+    voice_channel_id TEXT PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL UNIQUE,
+    created_at BIGINT NOT NULL,
+    last_active_at BIGINT NOT NULL
+);
+
+CREATE TABLE ticket_voice_activity (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL,
+    voice_channel_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    username TEXT NOT NULL,
+    joined_at BIGINT NOT NULL,
+    left_at BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_voice_activity_ticket ON ticket_voice_activity (server_config_id, ticket_channel_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_voice_activity_open ON ticket_voice_activity (server_config_id, user_id) WHERE left_at IS NULL;
+
+CREATE TABLE ticket_transfer (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    ticket_channel_id TEXT NOT NULL,
+    from_panel_id INTEGER,
+    from_panel_title TEXT NOT NULL DEFAULT '',
+    to_panel_id INTEGER NOT NULL,
+    to_panel_title TEXT NOT NULL,
+    moved_by TEXT NOT NULL,
+    moved_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_transfer_ticket ON ticket_transfer (server_config_id, ticket_channel_id);
+
+CREATE TABLE transcript_search (
+    transcript_id INTEGER PRIMARY KEY REFERENCES transcript(id) ON DELETE CASCADE,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_search_server ON transcript_search (server_config_id);
+CREATE INDEX IF NOT EXISTS idx_transcript_search_document ON transcript_search USING GIN (document);
+
+CREATE TABLE transcript_purge (
+    transcript_id INTEGER PRIMARY KEY REFERENCES transcript(id) ON DELETE CASCADE,
+    started_at BIGINT NOT NULL
+);
+
+CREATE TABLE transcript_purge_log (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    transcript_id INTEGER NOT NULL,
+    ticket_id TEXT,
+    user_id TEXT,
+    storage_key TEXT NOT NULL,
+    closed_at BIGINT NOT NULL,
+    objects_deleted INTEGER NOT NULL DEFAULT 0,
+    purged_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_purge_log_server ON transcript_purge_log (server_config_id, purged_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_retention ON transcript (server_config_id, closed_at) WHERE NOT legal_hold;
+
+CREATE TABLE transcript_export (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    filters JSONB NOT NULL DEFAULT '{}',
+    include_html BOOLEAN NOT NULL DEFAULT false,
+    status TEXT NOT NULL DEFAULT 'pending',
+    total INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    storage_key TEXT,
+    error TEXT,
+    created_at BIGINT NOT NULL,
+    finished_at BIGINT,
+    expires_at BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_export_server ON transcript_export (server_config_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_export_unfinished ON transcript_export (status) WHERE status IN ('pending', 'running');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transcript_export_one_unfinished ON transcript_export (server_config_id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_transcript_export_expiry ON transcript_export (expires_at) WHERE status = 'done';
+
+CREATE TABLE transcript_share (
+    id SERIAL PRIMARY KEY,
+    transcript_id INTEGER NOT NULL REFERENCES transcript(id) ON DELETE CASCADE,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    created_by TEXT NOT NULL,
+    created_at BIGINT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    revoked_at BIGINT,
+    revoked_by TEXT,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    last_viewed_at BIGINT
+);
+
+CREATE TABLE transcript_share_event (
+    id BIGSERIAL PRIMARY KEY,
+    share_id INTEGER NOT NULL REFERENCES transcript_share(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    actor TEXT,
+    ip TEXT,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_share_transcript ON transcript_share (transcript_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transcript_share_event_share ON transcript_share_event (share_id, id DESC);
+
+CREATE TABLE ticket_message_capture (
+    id BIGSERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    channel_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    data JSONB,
+    occurred_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_message_capture_channel ON ticket_message_capture (server_config_id, channel_id, occurred_at, id);
+
+CREATE TABLE transcript_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    channel_id TEXT NOT NULL,
+    record JSONB NOT NULL,
+    payload BYTEA,
+    original BYTEA,
+    storage_key TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    transcript_id INTEGER,
+    delete_channel BOOLEAN NOT NULL DEFAULT FALSE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    last_error TEXT,
+    alerted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at BIGINT NOT NULL,
+    UNIQUE (server_config_id, channel_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transcript_outbox_due ON transcript_outbox (next_attempt_at);
+
+CREATE TABLE v1_import (
+    kind TEXT NOT NULL,
+    v1_id TEXT NOT NULL,
+    v2_id BIGINT NOT NULL,
+    imported_at BIGINT NOT NULL,
+    PRIMARY KEY (kind, v1_id)
+);
+
+CREATE TABLE transcript_redaction (
+    server_config_id BIGINT PRIMARY KEY REFERENCES server_config(id) ON DELETE CASCADE,
+    detectors TEXT[] NOT NULL DEFAULT '{}',
+    rules JSONB NOT NULL DEFAULT '[]',
+    keep_original BOOLEAN NOT NULL DEFAULT false,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE transcript_usage (
+    transcript_id INTEGER PRIMARY KEY REFERENCES transcript(id) ON DELETE CASCADE,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    transcript_bytes BIGINT NOT NULL,
+    attachment_bytes BIGINT NOT NULL
+);
+
+CREATE TABLE guild_storage_usage (
+    server_config_id BIGINT PRIMARY KEY REFERENCES server_config(id) ON DELETE CASCADE,
+    transcript_count INTEGER NOT NULL DEFAULT 0,
+    transcript_bytes BIGINT NOT NULL DEFAULT 0,
+    attachment_bytes BIGINT NOT NULL DEFAULT 0,
+    -- NULL uses the bot's default quota; 0 is unlimited.
+    quota_bytes BIGINT,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE webhook_subscription (
+    id SERIAL PRIMARY KEY,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscription_server ON webhook_subscription (server_config_id);
+
+CREATE TABLE webhook_delivery (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES webhook_subscription(id) ON DELETE CASCADE,
+    server_config_id BIGINT NOT NULL REFERENCES server_config(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    payload BYTEA NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT NOT NULL,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at BIGINT NOT NULL,
+    delivered_at BIGINT,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_log ON webhook_delivery (subscription_id, id DESC);
@@ -0,0 +1,155 @@
+"use client";
+
+import { useParams } from "next/navigation";
+import { Calendar, ShieldAlert } from "lucide-react";
+
+import useAuth from "../../../../lib/context/auth";
+import {
+  useMyTranscript,
+  useMyTranscriptContent,
+} from "../../../../lib/hooks/useTranscripts";
+import LoadingScreen from "../../../../components/LoadingScreen";
+import Navbar from "../../../../components/Navbar";
+import Footer from "../../../../components/Footer";
+import {
+  MessageContent,
+  formatTimestamp,
+  getAvatarUrl,
+} from "../../../../components/TranscriptMessageContent";
+
+export default function MyTranscriptPage() {
+  const params = useParams();
+  const transcriptId = params.transcriptId as string;
+  const { user, authLoading, login } = useAuth();
+
+  const { transcript, isLoading: metaLoading, isError: metaError } =
+    useMyTranscript(user ? transcriptId : "");
+  const {
+    content,
+    isLoading: contentLoading,
+    isError: contentError,
+  } = useMyTranscriptContent(user ? transcriptId : "");
+
+  if (authLoading || (user && metaLoading)) {
+    return <LoadingScreen />;
+  }
+
+  if (!user) {
+    return (
+      <div className="flex min-h-screen flex-col items-center justify-center gap-4 bg-background text-zinc-100 font-sans">
+        <p className="text-zinc-300 text-sm font-semibold">
+          Log in with Discord to view your transcript.
+        </p>
+        <button
+          onClick={login}
+          className="rounded-xl bg-[#FF5A36] px-5 py-2.5 text-sm font-bold text-white hover:bg-[#FF6B4A] shadow-md shadow-orange-950/15 transition-all duration-200 active:scale-95"
+        >
+          Log in
+        </button>
+      </div>
+    );
+  }
+
+  return (
+    <div className="relative min-h-screen overflow-hidden bg-[#1E1F22] text-zinc-100 font-sans selection:bg-[#FF5A36]/30">
+      <div className="relative mx-auto max-w-5xl px-6 py-8 md:py-16 flex flex-col min-h-screen justify-between gap-12">
+        <Navbar />
+
+        <main className="flex-1 space-y-6">
+          {metaError || !transcript ? (
+            <div className="rounded-2xl border border-red-500/20 bg-red-950/20 p-6 text-center shadow-lg">
+              <p className="text-sm text-red-400 font-bold">
+                Transcript not found. You can only view tickets you opened.
+              </p>
+            </div>
+          ) : (
+            <>
+              <h1 className="text-lg font-black text-white uppercase tracking-tight text-glow-sushi/10">
+                Ticket {transcript.ticketId || `#${transcript.id}`}
+              </h1>
+
+              <section className="grid grid-cols-1 gap-4 text-sm sm:grid-cols-3">
+                <div className="bg-zinc-950/20 p-3 rounded-xl border border-white/2 shadow-inner">
+                  <div className="text-[10px] font-extrabold uppercase tracking-widest text-zinc-400 flex items-center gap-1">
+                    <Calendar className="h-3 w-3" /> Opened
+                  </div>
+                  <div className="font-bold text-zinc-100 mt-1.5 text-xs truncate">
+                    {formatTimestamp(transcript.openedAt)}
+                  </div>
+                </div>
+                <div className="bg-zinc-950/20 p-3 rounded-xl border border-white/2 shadow-inner">
+                  <div className="text-[10px] font-extrabold uppercase tracking-widest text-zinc-400 flex items-center gap-1">
+                    <Calendar className="h-3 w-3" /> Closed
+                  </div>
+                  <div className="font-bold text-zinc-100 mt-1.5 text-xs truncate">
+                    {formatTimestamp(transcript.closedAt)}
+                  </div>
+                </div>
+                <div className="bg-zinc-950/20 p-3 rounded-xl border border-white/2 shadow-inner">
+                  <div className="text-[10px] font-extrabold uppercase tracking-widest text-zinc-400 flex items-center gap-1">
+                    <ShieldAlert className="h-3 w-3" /> Closed By
+                  </div>
+                  <div className="font-bold text-zinc-100 mt-1.5 text-xs truncate">
+                    {content?.metadata.closedBy.username || transcript.closedBy}
+                  </div>
+                </div>
+              </section>
+
+              <section className="rounded-2xl border border-white/5 bg-zinc-900/10 backdrop-blur-md p-6 shadow-xl">
+                <h2 className="mb-4 text-xs font-extrabold uppercase tracking-widest text-[#FF5A36] text-glow-sushi/10">
+                  Conversation Log
+                </h2>
+
+                {contentLoading && (
+                  <p className="py-20 text-center text-xs text-zinc-400 font-semibold animate-pulse">
+                    Loading messages...
+                  </p>
+                )}
+
+                {contentError && (
+                  <p className="py-10 text-center text-sm text-red-400 font-bold">
+                    Failed to load message content.
+                  </p>
+                )}
+
+                {content && (
+                  <div className="space-y-4">
+                    {content.messages.map((msg, idx) => (
+                      <div
+                        key={`${msg.id}-${idx}`}
+                        className="flex gap-4 rounded-2xl border border-white/5 bg-zinc-950/20 px-4 py-4 shadow-sm"
+                      >
+                        <img
+                          src={getAvatarUrl(msg.author)}
+                          alt={msg.author.username}
+                          className="h-10 w-10 shrink-0 rounded-full border border-white/10"
+                        />
+                        <div className="min-w-0 flex-1">
+                          <div className="mb-2 flex flex-wrap items-center gap-2">
+                            <span className="font-extrabold text-zinc-100 tracking-tight">
+                              {msg.author.username}
+                            </span>
+                            <span className="text-[11px] font-semibold text-zinc-400">
+                              {formatTimestamp(msg.timestamp)}
+                            </span>
+                          </div>
+                          <MessageContent
+                            message={msg}
+                            mentions={content.mentions}
+                            attachmentPath={`/api/me/transcripts/${transcriptId}/attachments`}
+                          />
+                        </div>
+                      </div>
+                    ))}
+                  </div>
+                )}
+              </section>
+            </>
+          )}
+        </main>
+
+        <Footer />
+      </div>
+    </div>
+  );
+}
@@ -30,6 +30,10 @@ type FormData = {
   AutoCloseSinceLastMessageDays: string;
   AutoCloseSinceLastMessageHours: string;
   AutoCloseSinceLastMessageMins: string;
+  ModmailEnabled: boolean;
+  TranscriptLogHTML: boolean;
+  TranscriptDMOpener: boolean;
+  TranscriptLogFormat: "" | "txt" | "md";
 };
 
 function TimeInput({
@@ -100,6 +104,10 @@ export default function ServerSettingsPage() {
     AutoCloseSinceLastMessageDays: "0",
     AutoCloseSinceLastMessageHours: "0",
     AutoCloseSinceLastMessageMins: "0",
+    ModmailEnabled: false,
+    TranscriptLogHTML: false,
+    TranscriptDMOpener: false,
+    TranscriptLogFormat: "",
   });
 
   useEffect(() => {
@@ -135,6 +143,10 @@ export default function ServerSettingsPage() {
       AutoCloseSinceLastMessageMins: String(
         config.AutoCloseSinceLastMessageMins ?? 0,
       ),
+      ModmailEnabled: config.ModmailEnabled || false,
+      TranscriptLogHTML: config.TranscriptLogHtml || false,
+      TranscriptDMOpener: config.TranscriptDmOpener || false,
+      TranscriptLogFormat: config.TranscriptLogFormat || "",
     }));
   }, [config, channels]);
 
@@ -345,6 +357,58 @@ export default function ServerSettingsPage() {
             </div>
           </SectionCard>
 
+          {/* Transcripts & Modmail */}
+          <SectionCard
+            title="Transcripts & Modmail"
+            description="What is sent when a ticket closes, and how users can reach staff."
+          >
+            <div className="space-y-4">
+              <div className="grid grid-cols-1 md:grid-cols-3 gap-4">
+                {[
+                  { field: "ModmailEnabled" as const, label: "Modmail", desc: "Relay DMs to the bot into tickets" },
+                  { field: "TranscriptLogHTML" as const, label: "HTML Log", desc: "Attach an HTML copy to the log" },
+                  { field: "TranscriptDMOpener" as const, label: "DM Opener", desc: "Send the transcript to the opener" },
+                ].map(({ field, label, desc }) => (
+                  <div key={field} className="bg-zinc-950/20 hover:bg-zinc-950/40 p-4 rounded-2xl border border-white/2 hover:border-white/5 transition-all duration-200">
+                    <DarkCheckbox
+                      checked={formData[field]}
+                      onChange={(v) =>
+                        setFormData((p) => ({ ...p, [field]: v }))
+                      }
+                      label={label}
+                      description={desc}
+                    />
+                  </div>
+                ))}
+              </div>
+
+              <FormLabel label="Text Log Attachment" hint="Plain text or Markdown copy in the log channel">
+                <div className="flex gap-3">
+                  {[
+                    { value: "" as const, label: "None" },
+                    { value: "txt" as const, label: "Text" },
+                    { value: "md" as const, label: "Markdown" },
+                  ].map(({ value, label }) => (
+                    <button
+                      key={label}
+                      type="button"
+                      onClick={() =>
+                        setFormData((p) => ({ ...p, TranscriptLogFormat: value }))
+                      }
+                      className={`flex-1 rounded-xl border px-3 py-2 text-xs font-bold transition-all duration-200 active:scale-98 cursor-pointer ${
+                        formData.TranscriptLogFormat === value
+                          ? "border-[#FF5A36] bg-[#FF5A36]/10 text-zinc-100"
+                          : "border-white/5 bg-zinc-950/20 text-zinc-400 hover:border-white/10 hover:bg-zinc-950/40"
+                      }`}
+                    >
+                      {label}
+                    </button>
+                  ))}
+                </div>
+              </FormLabel>
+            </div>
+          </SectionCard>
+
         </div>
 
         {/* RIGHT COLUMN: Auto-Close Automation (5/12 width) */}
@@ -8,208 +8,11 @@ import {
   useTranscript,
   useTranscriptContent,
 } from "../../../../../lib/hooks/useTranscripts";
-import type { TranscriptMessage } from "../../../../../lib/api";
-
-function formatTimestamp(ts: string | number): string {
-  if (!ts) return "—";
-  const date = typeof ts === "number" ? new Date(ts * 1000) : new Date(ts);
-  return date.toLocaleString("en-US", {
-    month: "short",
-    day: "numeric",
-    year: "numeric",
-    hour: "numeric",
-    minute: "2-digit",
-    hour12: true,
-  });
-}
-
-function getAvatarUrl(author: TranscriptMessage["author"]): string {
-  if (author.avatar) {
-    return `https://cdn.discordapp.com/avatars/${author.id}/${author.avatar}.png`;
-  }
-  const discrim = parseInt(author.discriminator || "0", 10);
-  return `https://cdn.discordapp.com/embed/avatars/${discrim % 5}.png`;
-}
-
-function MessageContent({ message }: { message: TranscriptMessage }) {
-  if (message.type === "system") {
-    return (
-      <div className="italic text-zinc-400 text-sm font-semibold flex items-center gap-2">
-        <span className="h-1.5 w-1.5 rounded-full bg-zinc-400" />
-        {message.content}
-      </div>
-    );
-  }
-
-  if (message.type === "voice_join") {
-    return (
-      <div className="text-sm text-emerald-400 font-semibold flex items-center gap-2">
-        <span className="h-1.5 w-1.5 rounded-full bg-emerald-400 animate-pulse" />
-        {message.author.username} joined the voice channel
-      </div>
-    );
-  }
-
-  if (message.type === "voice_leave") {
-    return (
-      <div className="text-sm text-zinc-500 font-semibold flex items-center gap-2">
-        <span className="h-1.5 w-1.5 rounded-full bg-zinc-500" />
-        {message.author.username} left the voice channel
-      </div>
-    );
-  }
-
-  return (
-    <>
-      {message.content && (
-        <div className="text-sm leading-relaxed whitespace-pre-wrap break-words text-zinc-100 font-medium">
-          {message.content}
-        </div>
-      )}
-
-      {message.embeds && message.embeds.length > 0 && (
-        <div className="mt-2.5 space-y-3">
-          {message.embeds.map((embed, idx) => (
-            <div
-              key={idx}
-              className="rounded-2xl border border-white/5 border-l-4 bg-zinc-950/40 p-4 text-sm shadow-inner max-w-[520px] w-full"
-              style={{
-                borderLeftColor: embed.color
-                  ? `#${embed.color.toString(16).padStart(6, "0")}`
-                  : "#FF5A36",
-              }}
-            >
-              {embed.author && (
-                <div className="mb-2 flex items-center gap-1.5 text-xs font-bold text-zinc-300">
-                  {embed.author.iconUrl && (
-                    <img
-                      src={embed.author.iconUrl}
-                      alt=""
-                      className="h-4 w-4 rounded-full"
-                    />
-                  )}
-                  {embed.author.url ? (
-                    <a
-                      href={embed.author.url}
-                      target="_blank"
-                      rel="noopener noreferrer"
-                      className="text-[#FF5A36] hover:underline"
-                    >
-                      {embed.author.name}
-                    </a>
-                  ) : (
-                    <span>{embed.author.name}</span>
-                  )}
-                </div>
-              )}
-              {embed.title && (
-                <div className="mb-1.5 font-extrabold text-zinc-100 tracking-tight">
-                  {embed.url ? (
-                    <a
-                      href={embed.url}
-                      target="_blank"
-                      rel="noopener noreferrer"
-                      className="text-[#FF5A36] hover:underline"
-                    >
-                      {embed.title}
-                    </a>
-                  ) : (
-                    embed.title
-                  )}
-                </div>
-              )}
-              {embed.description && (
-                <div className="mb-3 whitespace-pre-wrap text-zinc-300 font-medium leading-relaxed">
-                  {embed.description}
-                </div>
-              )}
-              {embed.fields && embed.fields.length > 0 && (
-                <div
-                  className="mb-3 grid gap-3"
-                  style={{
-                    gridTemplateColumns: embed.fields.some((f) => f.inline)
-                      ? "repeat(auto-fill, minmax(180px, 1fr))"
-                      : "1fr",
-                  }}
-                >
-                  {embed.fields.map((field, fIdx) => (
-                    <div key={fIdx} className="bg-zinc-950/20 p-2.5 rounded-xl border border-white/2">
-                      <div className="text-xs font-extrabold text-zinc-300">
-                        {field.name}
-                      </div>
-                      <div className="whitespace-pre-wrap text-zinc-400 font-medium mt-1 leading-relaxed text-xs">
-                        {field.value}
-                      </div>
-                    </div>
-                  ))}
-                </div>
-              )}
-              {embed.image && (
-                <img
-                  src={embed.image.url}
-                  alt=""
-                  className="mt-2.5 max-h-72 max-w-full rounded-xl object-contain border border-white/5"
-                />
-              )}
-              {embed.footer && (
-                <div className="mt-3 flex items-center gap-1.5 text-xs text-zinc-400 font-semibold border-t border-white/2 pt-2">
-                  {embed.footer.iconUrl && (
-                    <img
-                      src={embed.footer.iconUrl}
-                      alt=""
-                      className="h-4 w-4 rounded-full"
-                    />
-                  )}
-                  <span>{embed.footer.text}</span>
-                </div>
-              )}
-            </div>
-          ))}
-        </div>
-      )}
-
-      {message.attachments && message.attachments.length > 0 && (
-        <div className="mt-2.5 space-y-2.5">
-          {message.attachments.map((att) => (
-            <div key={att.id}>
-              {att.contentType?.startsWith("image/") ? (
-                <img
-                  src={att.url}
-                  alt={att.filename}
-                  className="max-h-72 max-w-full rounded-xl object-contain border border-white/5"
-                />
-              ) : (
-                <a
-                  href={att.url}
-                  target="_blank"
-                  rel="noopener noreferrer"
-                  className="inline-flex items-center gap-2 rounded-xl border border-white/10 bg-zinc-950/40 px-3.5 py-2 text-xs font-bold text-[#FF5A36] hover:bg-zinc-950/60 hover:text-white transition-all shadow-sm"
-                >
-                  <span className="text-xs">📎</span>
-                  {att.filename}
-                </a>
-              )}
-            </div>
-          ))}
-        </div>
-      )}
-
-      {message.reactions && message.reactions.length > 0 && (
-        <div className="mt-2 flex flex-wrap gap-1.5">
-          {message.reactions.map((reaction, idx) => (
-            <span
-              key={idx}
-              className="inline-flex items-center gap-1 rounded-xl border border-white/10 bg-zinc-950/40 px-2.5 py-1 text-xs font-bold text-zinc-300"
-            >
-              <span>{reaction.emoji}</span>
-              <span className="text-zinc-400">{reaction.count}</span>
-            </span>
-          ))}
-        </div>
-      )}
-    </>
-  );
-}
+import {
+  MessageContent,
+  formatTimestamp,
+  getAvatarUrl,
+} from "../../../../../components/TranscriptMessageContent";
 
 export default function TranscriptViewerPage() {
   const params = useParams();
@@ -402,7 +205,11 @@ export default function TranscriptViewerPage() {
                       </span>
                     )}
                   </div>
-                  <MessageContent message={msg} />
+                  <MessageContent
+                    message={msg}
+                    mentions={content.mentions}
+                    attachmentPath={`/api/servers/${serverId}/transcripts/${transcriptId}/attachments`}
+                  />
                 </div>
               </div>
             ))}
@@ -22,10 +22,13 @@ function formatTimestamp(ts: number): string {
 export default function TranscriptsPage() {
 	const params = useParams();
 	const serverId = params.serverId as string;
-	const [page, setPage] = useState(1);
+	// Cursors of the pages visited so far; the last one loads the current page.
+	const [cursors, setCursors] = useState<string[]>([""]);
 	const limit = 20;
 
-	const { transcripts, pagination, isLoading } = useTranscripts(serverId, page, limit);
+	const { transcripts, pagination, isLoading } = useTranscripts(serverId, cursors[cursors.length - 1], limit);
+	const page = cursors.length;
+	const pages = Math.max(1, Math.ceil(pagination.total / limit));
 
 	return (
 		<div className="space-y-5 pb-6">
@@ -107,10 +110,10 @@ export default function TranscriptsPage() {
 								: "No transcripts"}
 						</span>
 
-						{pagination.pages > 1 && (
+						{pages > 1 && (
 							<div className="flex items-center gap-3.5">
 								<button
-									onClick={() => setPage((p) => Math.max(1, p - 1))}
+									onClick={() => setCursors((c) => (c.length > 1 ? c.slice(0, -1) : c))}
 									disabled={page === 1}
 									className="flex items-center gap-1.5 rounded-xl border border-white/10 bg-white/5 px-3.5 py-2 text-xs font-bold text-zinc-300 hover:text-white hover:bg-white/10 hover:border-white/20 transition-all disabled:opacity-30 disabled:cursor-not-allowed shadow-sm"
 								>
@@ -118,11 +121,11 @@ export default function TranscriptsPage() {
 									Prev
 								</button>
 								<span className="text-xs text-zinc-300 font-bold font-mono">
-									{page} / {pagination.pages}
+									{page} / {pages}
 								</span>
 								<button
-									onClick={() => setPage((p) => Math.min(pagination.pages, p + 1))}
-									disabled={page === pagination.pages}
+									onClick={() => setCursors((c) => (pagination.nextCursor ? [...c, pagination.nextCursor] : c))}
+									disabled={!pagination.nextCursor}
 									className="flex items-center gap-1.5 rounded-xl border border-white/10 bg-white/5 px-3.5 py-2 text-xs font-bold text-zinc-300 hover:text-white hover:bg-white/10 hover:border-white/20 transition-all disabled:opacity-30 disabled:cursor-not-allowed shadow-sm"
 								>
 									Next
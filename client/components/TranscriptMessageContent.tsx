@@ -0,0 +1,403 @@
+import { API_BASE } from "../lib/api";
+import type { TranscriptMentions, TranscriptMessage } from "../lib/api";
+
+export function formatTimestamp(ts: string | number): string {
+  if (!ts) return "—";
+  const date = typeof ts === "number" ? new Date(ts * 1000) : new Date(ts);
+  return date.toLocaleString("en-US", {
+    month: "short",
+    day: "numeric",
+    year: "numeric",
+    hour: "numeric",
+    minute: "2-digit",
+    hour12: true,
+  });
+}
+
+export function getAvatarUrl(author: TranscriptMessage["author"]): string {
+  if (author.avatar) {
+    return `https://cdn.discordapp.com/avatars/${author.id}/${author.avatar}.png`;
+  }
+  const discrim = parseInt(author.discriminator || "0", 10);
+  return `https://cdn.discordapp.com/embed/avatars/${discrim % 5}.png`;
+}
+
+// resolveMentions swaps mention tokens for the names stored with the
+// transcript, so mentions of deleted users, roles and channels stay readable.
+export function resolveMentions(
+  text: string,
+  mentions?: TranscriptMentions,
+): string {
+  return text.replace(/<(@!?|@&|#)(\d+)>/g, (_, kind: string, id: string) => {
+    if (kind === "@&") {
+      return `@${mentions?.roles?.[id]?.name ?? "deleted-role"}`;
+    }
+    if (kind === "#") {
+      return `#${mentions?.channels?.[id]?.name ?? "deleted-channel"}`;
+    }
+    return `@${mentions?.users?.[id]?.username ?? "unknown-user"}`;
+  });
+}
+
+// systemText mirrors transcript.SystemText on the server.
+export function systemText(message: TranscriptMessage): string | null {
+  const content = (message.content ?? "").trim();
+  switch (message.type) {
+    case "pin":
+      return "pinned a message to this channel.";
+    case "member_join":
+      return "joined the server.";
+    case "boost":
+      return "boosted the server!";
+    case "follow_add":
+      return `added ${content || "a channel"} to this channel.`;
+    case "thread_created":
+      return `started a thread: ${content || "untitled"}`;
+    case "channel_name_change":
+      return `changed the channel name: ${content || "unknown"}`;
+    case "channel_icon_change":
+      return "changed the channel icon.";
+    case "recipient_add":
+      return "added someone to the conversation.";
+    case "recipient_remove":
+      return "removed someone from the conversation.";
+    case "call":
+      return "started a call.";
+    case "discovery_disqualified":
+      return "This server has been removed from Server Discovery.";
+    case "discovery_requalified":
+      return "This server is eligible for Server Discovery again.";
+    default:
+      return null;
+  }
+}
+
+function stickerUrl(sticker: { id: string; format: string }): string | null {
+  if (sticker.format === "png" || sticker.format === "apng") {
+    return `https://media.discordapp.net/stickers/${sticker.id}.png?size=160`;
+  }
+  if (sticker.format === "gif") {
+    return `https://media.discordapp.net/stickers/${sticker.id}.gif?size=160`;
+  }
+  return null;
+}
+
+const buttonStyles: Record<number, string> = {
+  1: "bg-[#5865F2]",
+  3: "bg-[#248046]",
+  4: "bg-[#DA373C]",
+};
+
+type Attachment = NonNullable<TranscriptMessage["attachments"]>[number];
+
+// attachmentSrc prefers the archived copy, served by the API under
+// attachmentPath, since Discord CDN links expire.
+function attachmentSrc(att: Attachment, attachmentPath?: string): string {
+  if (att.storageKey && attachmentPath) {
+    return `${API_BASE}${attachmentPath}/${encodeURIComponent(att.id)}`;
+  }
+  return att.url;
+}
+
+export function MessageContent({
+  message,
+  mentions,
+  attachmentPath,
+}: {
+  message: TranscriptMessage;
+  mentions?: TranscriptMentions;
+  attachmentPath?: string;
+}) {
+  const system = systemText(message);
+  if (system) {
+    return (
+      <div className="italic text-zinc-400 text-sm font-semibold flex items-center gap-2">
+        <span className="h-1.5 w-1.5 rounded-full bg-zinc-400" />
+        {system}
+      </div>
+    );
+  }
+
+  if (message.type === "system") {
+    return (
+      <div className="italic text-zinc-400 text-sm font-semibold flex items-center gap-2">
+        <span className="h-1.5 w-1.5 rounded-full bg-zinc-400" />
+        {message.content}
+      </div>
+    );
+  }
+
+  if (message.type === "voice_join") {
+    return (
+      <div className="text-sm text-emerald-400 font-semibold flex items-center gap-2">
+        <span className="h-1.5 w-1.5 rounded-full bg-emerald-400 animate-pulse" />
+        {message.author.username} joined the voice channel
+      </div>
+    );
+  }
+
+  if (message.type === "voice_leave") {
+    return (
+      <div className="text-sm text-zinc-500 font-semibold flex items-center gap-2">
+        <span className="h-1.5 w-1.5 rounded-full bg-zinc-500" />
+        {message.author.username} left the voice channel
+      </div>
+    );
+  }
+
+  return (
+    <>
+      {message.reference && (
+        <div className="mb-1.5 truncate text-xs text-zinc-500">
+          ↪{" "}
+          {message.reference.author && (
+            <span className="font-bold text-zinc-400">
+              {message.reference.author.username}
+            </span>
+          )}{" "}
+          {message.reference.content ? (
+            resolveMentions(message.reference.content, mentions)
+          ) : (
+            <i>original message unavailable</i>
+          )}
+        </div>
+      )}
+      {message.type === "note" && (
+        <div className="mb-1.5 text-[10px] font-black uppercase tracking-wider text-amber-400">
+          Staff note
+        </div>
+      )}
+      {message.deleted && (
+        <div className="mb-1.5 text-[10px] font-black uppercase tracking-wider text-red-400">
+          Deleted
+          {message.deletedAt &&
+            ` · ${new Date(message.deletedAt).toLocaleString()}`}
+        </div>
+      )}
+      {message.content && (
+        <div className="text-sm leading-relaxed whitespace-pre-wrap break-words text-zinc-100 font-medium">
+          {resolveMentions(message.content, mentions)}
+        </div>
+      )}
+      {message.revisions && message.revisions.length > 0 && (
+        <details className="mt-1 text-xs text-zinc-500">
+          <summary className="cursor-pointer font-semibold">
+            {message.revisions.length} earlier version
+            {message.revisions.length > 1 ? "s" : ""}
+          </summary>
+          <div className="mt-1.5 space-y-1.5">
+            {message.revisions.map((revision, idx) => (
+              <div key={idx} className="border-l-2 border-white/10 pl-2">
+                <div className="text-[10px] text-zinc-600">
+                  {new Date(revision.timestamp).toLocaleString()}
+                </div>
+                <div className="whitespace-pre-wrap break-words text-zinc-400">
+                  {revision.content || <i>empty</i>}
+                </div>
+              </div>
+            ))}
+          </div>
+        </details>
+      )}
+
+      {message.embeds && message.embeds.length > 0 && (
+        <div className="mt-2.5 space-y-3">
+          {message.embeds.map((embed, idx) => (
+            <div
+              key={idx}
+              className="rounded-2xl border border-white/5 border-l-4 bg-zinc-950/40 p-4 text-sm shadow-inner max-w-[520px] w-full"
+              style={{
+                borderLeftColor: embed.color
+                  ? `#${embed.color.toString(16).padStart(6, "0")}`
+                  : "#FF5A36",
+              }}
+            >
+              {embed.author && (
+                <div className="mb-2 flex items-center gap-1.5 text-xs font-bold text-zinc-300">
+                  {embed.author.iconUrl && (
+                    <img
+                      src={embed.author.iconUrl}
+                      alt=""
+                      className="h-4 w-4 rounded-full"
+                    />
+                  )}
+                  {embed.author.url ? (
+                    <a
+                      href={embed.author.url}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                      className="text-[#FF5A36] hover:underline"
+                    >
+                      {embed.author.name}
+                    </a>
+                  ) : (
+                    <span>{embed.author.name}</span>
+                  )}
+                </div>
+              )}
+              {embed.title && (
+                <div className="mb-1.5 font-extrabold text-zinc-100 tracking-tight">
+                  {embed.url ? (
+                    <a
+                      href={embed.url}
+                      target="_blank"
+                      rel="noopener noreferrer"
+                      className="text-[#FF5A36] hover:underline"
+                    >
+                      {embed.title}
+                    </a>
+                  ) : (
+                    embed.title
+                  )}
+                </div>
+              )}
+              {embed.description && (
+                <div className="mb-3 whitespace-pre-wrap text-zinc-300 font-medium leading-relaxed">
+                  {resolveMentions(embed.description, mentions)}
+                </div>
+              )}
+              {embed.fields && embed.fields.length > 0 && (
+                <div
+                  className="mb-3 grid gap-3"
+                  style={{
+                    gridTemplateColumns: embed.fields.some((f) => f.inline)
+                      ? "repeat(auto-fill, minmax(180px, 1fr))"
+                      : "1fr",
+                  }}
+                >
+                  {embed.fields.map((field, fIdx) => (
+                    <div key={fIdx} className="bg-zinc-950/20 p-2.5 rounded-xl border border-white/2">
+                      <div className="text-xs font-extrabold text-zinc-300">
+                        {field.name}
+                      </div>
+                      <div className="whitespace-pre-wrap text-zinc-400 font-medium mt-1 leading-relaxed text-xs">
+                        {resolveMentions(field.value, mentions)}
+                      </div>
+                    </div>
+                  ))}
+                </div>
+              )}
+              {embed.image && (
+                <img
+                  src={embed.image.url}
+                  alt=""
+                  className="mt-2.5 max-h-72 max-w-full rounded-xl object-contain border border-white/5"
+                />
+              )}
+              {embed.footer && (
+                <div className="mt-3 flex items-center gap-1.5 text-xs text-zinc-400 font-semibold border-t border-white/2 pt-2">
+                  {embed.footer.iconUrl && (
+                    <img
+                      src={embed.footer.iconUrl}
+                      alt=""
+                      className="h-4 w-4 rounded-full"
+                    />
+                  )}
+                  <span>{embed.footer.text}</span>
+                </div>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+
+      {message.attachments && message.attachments.length > 0 && (
+        <div className="mt-2.5 space-y-2.5">
+          {message.attachments.map((att) => (
+            <div key={att.id}>
+              {att.contentType?.startsWith("image/") ? (
+                <img
+                  src={attachmentSrc(att, attachmentPath)}
+                  alt={att.filename}
+                  className="max-h-72 max-w-full rounded-xl object-contain border border-white/5"
+                />
+              ) : (
+                <a
+                  href={attachmentSrc(att, attachmentPath)}
+                  target="_blank"
+                  rel="noopener noreferrer"
+                  className="inline-flex items-center gap-2 rounded-xl border border-white/10 bg-zinc-950/40 px-3.5 py-2 text-xs font-bold text-[#FF5A36] hover:bg-zinc-950/60 hover:text-white transition-all shadow-sm"
+                >
+                  <span className="text-xs">📎</span>
+                  {att.filename}
+                </a>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+
+      {message.stickers && message.stickers.length > 0 && (
+        <div className="mt-2 flex flex-wrap gap-3">
+          {message.stickers.map((sticker) => {
+            const url = stickerUrl(sticker);
+            return (
+              <div
+                key={sticker.id}
+                className="flex flex-col gap-1 text-xs font-semibold text-zinc-500"
+              >
+                {url && (
+                  <img
+                    src={url}
+                    alt={sticker.name}
+                    className="h-32 w-32 object-contain"
+                  />
+                )}
+                <span>{sticker.name}</span>
+              </div>
+            );
+          })}
+        </div>
+      )}
+
+      {message.components?.map((row, rowIdx) => (
+        <div key={rowIdx} className="mt-2 flex flex-wrap gap-2">
+          {row.map((component, idx) =>
+            component.type === "button" ? (
+              component.url ? (
+                <a
+                  key={idx}
+                  href={component.url}
+                  target="_blank"
+                  rel="noopener noreferrer"
+                  className={`rounded-lg bg-zinc-700 px-3 py-1.5 text-xs font-bold text-white ${component.disabled ? "opacity-50" : ""}`}
+                >
+                  {component.emoji} {component.label}
+                </a>
+              ) : (
+                <span
+                  key={idx}
+                  className={`rounded-lg px-3 py-1.5 text-xs font-bold text-white ${buttonStyles[component.style ?? 0] ?? "bg-zinc-700"} ${component.disabled ? "opacity-50" : ""}`}
+                >
+                  {component.emoji} {component.label}
+                </span>
+              )
+            ) : (
+              <span
+                key={idx}
+                title={component.options?.join(", ")}
+                className={`min-w-[200px] rounded-lg border border-white/10 bg-zinc-950/60 px-3 py-1.5 text-xs font-semibold text-zinc-400 ${component.disabled ? "opacity-50" : ""}`}
+              >
+                {component.placeholder || "Select…"} ▾
+              </span>
+            ),
+          )}
+        </div>
+      ))}
+
+      {message.reactions && message.reactions.length > 0 && (
+        <div className="mt-2 flex flex-wrap gap-1.5">
+          {message.reactions.map((reaction, idx) => (
+            <span
+              key={idx}
+              className="inline-flex items-center gap-1 rounded-xl border border-white/10 bg-zinc-950/40 px-2.5 py-1 text-xs font-bold text-zinc-300"
+            >
+              <span>{reaction.emoji}</span>
+              <span className="text-zinc-400">{reaction.count}</span>
+            </span>
+          ))}
+        </div>
+      )}
+    </>
+  );
+}
@@ -185,9 +185,9 @@ export const api = {
   },
 
   transcripts: {
-    list: (serverId: string, page = 1, limit = 20) =>
+    list: (serverId: string, cursor = "", limit = 20) =>
       fetchApi<TranscriptListResponse>(
-        `/api/servers/${serverId}/transcripts?page=${page}&limit=${limit}`,
+        `/api/servers/${serverId}/transcripts?limit=${limit}${cursor ? `&cursor=${encodeURIComponent(cursor)}` : ""}`,
       ),
     get: (serverId: string, transcriptId: string) =>
       fetchApi<TranscriptDetailResponse>(
@@ -229,6 +229,14 @@ export type ServerConfig = {
   AutoCloseSinceLastMessageDays: number;
   AutoCloseSinceLastMessageHours: number;
   AutoCloseSinceLastMessageMins: number;
+  // The server keeps any of these that an update leaves out. Reads spell
+  // two of them TranscriptLogHtml and TranscriptDmOpener.
+  ModmailEnabled?: boolean;
+  TranscriptLogHTML?: boolean;
+  TranscriptLogHtml?: boolean;
+  TranscriptDMOpener?: boolean;
+  TranscriptDmOpener?: boolean;
+  TranscriptLogFormat?: "" | "txt" | "md";
 };
 
 type ServerConfigResponse =
@@ -372,16 +380,32 @@ export type Transcript = {
   totalMessages: number;
   totalAttachments: number;
   totalEmbeds: number;
+  closeReason: string;
+  panelId?: number;
 };
 
 export type TranscriptListResponse = {
   transcripts: Transcript[];
-  pagination: { page: number; limit: number; total: number; pages: number };
+  pagination: { limit: number; total: number; nextCursor: string };
 };
 
 export type TranscriptDetailResponse = {
   transcript: Transcript & { storageKey: string };
-  presignedUrl: string;
+  presignedUrl: string | null;
+};
+
+export type MyTranscript = {
+  id: number;
+  serverId: string;
+  ticketId: string;
+  openedAt: number;
+  closedAt: number;
+  closedBy: string;
+  closeReason: string;
+};
+
+export type MyTranscriptDetailResponse = {
+  transcript: MyTranscript;
 };
 
 export type TranscriptMessage = {
@@ -392,7 +416,24 @@ export type TranscriptMessage = {
     | "attachment"
     | "voice_join"
     | "voice_leave"
-    | "system";
+    | "system"
+    | "note"
+    | "reply"
+    | "command"
+    | "context_command"
+    | "pin"
+    | "member_join"
+    | "boost"
+    | "follow_add"
+    | "thread_created"
+    | "thread_starter"
+    | "channel_name_change"
+    | "channel_icon_change"
+    | "recipient_add"
+    | "recipient_remove"
+    | "call"
+    | "discovery_disqualified"
+    | "discovery_requalified";
   author: {
     id: string;
     username: string;
@@ -426,17 +467,50 @@ export type TranscriptMessage = {
     contentType: string | null;
     width: number | null;
     height: number | null;
+    storageKey?: string;
   }[];
   edited: boolean;
   editedTimestamp: string | null;
   reactions?: { emoji: string; count: number }[];
+  revisions?: { content: string; timestamp: string }[];
+  deleted?: boolean;
+  deletedAt?: string;
+  reference?: {
+    messageId: string;
+    channelId: string;
+    author?: TranscriptMessage["author"];
+    content?: string;
+  };
+  stickers?: { id: string; name: string; format: string }[];
+  components?: TranscriptComponent[][];
+};
+
+export type TranscriptComponent = {
+  type: "button" | "select";
+  label?: string;
+  style?: number;
+  url?: string;
+  emoji?: string;
+  disabled?: boolean;
+  placeholder?: string;
+  options?: string[];
+};
+
+// Names behind <@id>, <@&id> and <#id> at the time the ticket closed.
+// Missing from transcripts written before schema version 2.
+export type TranscriptMentions = {
+  users?: Record<string, { username: string }>;
+  roles?: Record<string, { name: string; color: number }>;
+  channels?: Record<string, { name: string }>;
 };
 
 export type TranscriptContent = {
+  schemaVersion?: number;
   ticketId: string;
   username: string;
   userId: string;
   messages: TranscriptMessage[];
+  mentions?: TranscriptMentions;
   metadata: {
     ticketOpenedAt: string;
     ticketClosedAt: string;
@@ -4,6 +4,7 @@ import {
   type TranscriptListResponse,
   type TranscriptDetailResponse,
   type TranscriptContent,
+  type MyTranscriptDetailResponse,
 } from "../api";
 
 const fetcher = async <T>(url: string): Promise<T> => {
@@ -18,10 +19,10 @@ const contentFetcher = async <T>(url: string): Promise<T> => {
   return res.json();
 };
 
-export function useTranscripts(serverId: string, page: number, limit = 20) {
+export function useTranscripts(serverId: string, cursor: string, limit = 20) {
   const { data, error, isLoading, mutate } = useSWR(
     serverId
-      ? `/api/servers/${serverId}/transcripts?page=${page}&limit=${limit}`
+      ? `/api/servers/${serverId}/transcripts?limit=${limit}${cursor ? `&cursor=${encodeURIComponent(cursor)}` : ""}`
       : null,
     fetcher<TranscriptListResponse>,
     {
@@ -32,7 +33,7 @@ export function useTranscripts(serverId: string, page: number, limit = 20) {
 
   return {
     transcripts: data?.transcripts ?? [],
-    pagination: data?.pagination ?? { page: 1, limit: 20, total: 0, pages: 0 },
+    pagination: data?.pagination ?? { limit: 20, total: 0, nextCursor: "" },
     isLoading,
     isError: !!error,
     refresh: mutate,
@@ -78,3 +79,37 @@ export function useTranscriptContent(serverId: string, transcriptId: string) {
     isError: !!error,
   };
 }
+
+export function useMyTranscript(transcriptId: string) {
+  const { data, error, isLoading } = useSWR(
+    transcriptId ? `/api/me/transcripts/${transcriptId}` : null,
+    fetcher<MyTranscriptDetailResponse>,
+    {
+      revalidateOnFocus: false,
+      dedupingInterval: 60000,
+    },
+  );
+
+  return {
+    transcript: data?.transcript ?? null,
+    isLoading,
+    isError: !!error,
+  };
+}
+
+export function useMyTranscriptContent(transcriptId: string) {
+  const { data, error, isLoading } = useSWR(
+    transcriptId ? `/api/me/transcripts/${transcriptId}/content` : null,
+    contentFetcher<TranscriptContent>,
+    {
+      revalidateOnFocus: false,
+      dedupingInterval: 120000,
+    },
+  );
+
+  return {
+    content: data ?? null,
+    isLoading,
+    isError: !!error,
+  };
+}